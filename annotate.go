@@ -0,0 +1,42 @@
+package mysqldump
+
+import (
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// WithAnnotations prefix each statement of the dump with a machine readable comment
+// naming what it belongs to, so that tools can filter a dump without parsing SQL, eg:
+//
+//	-- @db=shop @table=orders @chunk=3
+//	INSERT INTO `orders` VALUES (...);
+//
+// The object key is table, view, trigger, procedure or function, @chunk numbers the
+// INSERT statements of a table from 1. Values are URL query escaped.
+func WithAnnotations() DumpOption {
+	return func(option *dumpOption) {
+		option.isAnnotations = true
+	}
+}
+
+// annotation the WithAnnotations comment line of a statement, "" when disabled,
+// name is "" for the statements of the whole database and chunk 0 for DDL
+func (o *dumpOption) annotation(dbName string, typ ObjectType, name string, chunk int) string {
+	if !o.isAnnotations {
+		return ""
+	}
+
+	var builder strings.Builder
+	builder.WriteString("-- @db=")
+	builder.WriteString(url.QueryEscape(dbName))
+	if name != "" {
+		builder.WriteString(" @" + strings.ToLower(string(typ)) + "=")
+		builder.WriteString(url.QueryEscape(name))
+	}
+	if chunk > 0 {
+		builder.WriteString(" @chunk=" + strconv.Itoa(chunk))
+	}
+	builder.WriteString("\n")
+	return builder.String()
+}
@@ -0,0 +1,72 @@
+package mysqldump
+
+import (
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"mysqldump/sqlutil"
+)
+
+// WithAuditLog write a JSON line to w for every statement executed by Source, eg:
+//
+//	{"time":"2024-01-02T15:04:05Z","sha256":"...","table":"orders","duration_ms":3.2,"rows_affected":1000,"chain":"..."}
+//
+// sha256 is the hash of the statement, chain the hash of the chain of the previous line
+// followed by sha256, so that removing or altering a line breaks every following chain.
+// A failed statement has an error instead of rows_affected. The restore fails if w fails.
+func WithAuditLog(w io.Writer) SourceOption {
+	return func(o *sourceOption) {
+		o.auditLog = w
+	}
+}
+
+// AuditRecord a line of WithAuditLog
+type AuditRecord struct {
+	Time         time.Time `json:"time"`
+	SHA256       string    `json:"sha256"`
+	Table        string    `json:"table,omitempty"`
+	DurationMs   float64   `json:"duration_ms"`
+	RowsAffected *int64    `json:"rows_affected,omitempty"`
+	Error        string    `json:"error,omitempty"`
+	Chain        string    `json:"chain"`
+}
+
+type auditLog struct {
+	encoder *json.Encoder
+	chain   string
+}
+
+func newAuditLog(w io.Writer) *auditLog {
+	return &auditLog{encoder: json.NewEncoder(w)}
+}
+
+func (a *auditLog) record(query string, start time.Time, cost time.Duration, res sql.Result, err error) error {
+	sum := sha256.Sum256([]byte(query))
+	record := AuditRecord{
+		Time:       start.UTC(),
+		SHA256:     hex.EncodeToString(sum[:]),
+		Table:      sqlutil.StatementTable(query),
+		DurationMs: float64(cost.Microseconds()) / 1000,
+	}
+	if err != nil {
+		record.Error = err.Error()
+	} else if res != nil {
+		if rows, rowsErr := res.RowsAffected(); rowsErr == nil {
+			record.RowsAffected = &rows
+		}
+	}
+
+	chain := sha256.Sum256([]byte(a.chain + record.SHA256))
+	a.chain = hex.EncodeToString(chain[:])
+	record.Chain = a.chain
+
+	if encodeErr := a.encoder.Encode(record); encodeErr != nil {
+		return fmt.Errorf("audit log: %w", encodeErr)
+	}
+	return nil
+}
@@ -0,0 +1,409 @@
+package mysqldump
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"math/big"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hamba/avro/v2"
+	"github.com/hamba/avro/v2/ocf"
+)
+
+// schemaRegistryTimeout the timeout of a schema registration
+const schemaRegistryTimeout = 30 * time.Second
+
+// avroColumn how a column of the result set is encoded
+type avroColumn struct {
+	name string
+	kind string
+}
+
+// DumpAvro write the rows of the selected tables into dir as Avro object container files, one
+// <table>.avro file per table with the schema of the table in its header, the names are encoded as
+// by DumpParquet. The schema is inferred from the column types, nullable columns are unions with
+// null. When several databases are exported each one gets its own sub directory. WithDBs,
+// WithAllDatabases, WithTables, WithAllTables and WithWhere select the rows.
+func DumpAvro(dns string, dir string, opts ...DumpOption) error {
+	var o dumpOption
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	multiDB := o.isAllDB || len(o.dbs) > 1
+
+	err := forEachTable(dns, &o, func(db dbConn, dbName, table string) error {
+		tableDir := dir
+		if multiDB {
+			tableDir = filepath.Join(dir, fileName(dbName))
+		}
+		err := os.MkdirAll(tableDir, 0755)
+		if err != nil {
+			return err
+		}
+
+		file, err := os.Create(filepath.Join(tableDir, fileName(table)+".avro"))
+		if err != nil {
+			return err
+		}
+		defer func() {
+			_ = file.Close()
+		}()
+
+		err = writeAvro(db, dbName, table, file, &o)
+		if err != nil {
+			return err
+		}
+		return file.Close()
+	})
+	if err != nil {
+		log.Printf("[error] %v \n", err)
+		return err
+	}
+	return nil
+}
+
+// writeAvro write the rows of table into w as an object container file
+func writeAvro(db dbConn, dbName, table string, w io.Writer, o *dumpOption) error {
+	var encoder *ocf.Encoder
+	var columns []avroColumn
+	err := scanRows(db, table, o, func(columnTypes []*sql.ColumnType, row []interface{}) error {
+		if encoder == nil {
+			var schema avro.Schema
+			var err error
+			schema, columns, err = avroSchema(dbName, table, columnTypes)
+			if err != nil {
+				return err
+			}
+			encoder, err = ocf.NewEncoderWithSchema(schema, w, ocf.WithCodec(ocf.Deflate))
+			if err != nil {
+				return err
+			}
+		}
+
+		record, err := avroRecord(columns, row)
+		if err != nil {
+			return fmt.Errorf("table %s: %v", table, err)
+		}
+		return encoder.Encode(record)
+	})
+	if err != nil {
+		return err
+	}
+
+	// an empty table still gets a file with its schema
+	if encoder == nil {
+		columnTypes, err := queryColumnTypes(db, table, o)
+		if err != nil {
+			return err
+		}
+		schema, _, err := avroSchema(dbName, table, columnTypes)
+		if err != nil {
+			return err
+		}
+		encoder, err = ocf.NewEncoderWithSchema(schema, w, ocf.WithCodec(ocf.Deflate))
+		if err != nil {
+			return err
+		}
+	}
+	return encoder.Close()
+}
+
+// DumpAvroToKafka publish each selected row as an Avro record to the topic topicPrefix+db+"."+table,
+// the message key is the JSON array of the primary key values. With a registryURL the schema of each
+// table is registered in the Confluent Schema Registry under the subject <topic>-value and the messages
+// use the Confluent wire format, eg: DumpAvroToKafka(dns, publisher, "mysql.", "http://registry:8081").
+// Without a registryURL a message is the Avro binary of the record alone.
+// WithDBs, WithAllDatabases, WithTables, WithAllTables and WithWhere select the rows,
+// WithRowTransformer applies.
+func DumpAvroToKafka(dns string, publisher Publisher, topicPrefix, registryURL string, opts ...DumpOption) error {
+	var o dumpOption
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	var registry *schemaRegistry
+	if registryURL != "" {
+		registry = &schemaRegistry{
+			url:    strings.TrimSuffix(registryURL, "/"),
+			client: &http.Client{Timeout: schemaRegistryTimeout},
+		}
+	}
+
+	err := forEachTable(dns, &o, func(db dbConn, dbName, table string) error {
+		return publishAvro(db, dbName, table, publisher, topicPrefix+dbName+"."+table, registry, &o)
+	})
+	if err != nil {
+		log.Printf("[error] %v \n", err)
+		return err
+	}
+	return nil
+}
+
+// publishAvro publish the rows of table to topic, the schema is registered before the first row
+func publishAvro(db dbConn, dbName, table string, publisher Publisher, topic string, registry *schemaRegistry, o *dumpOption) error {
+	primaryKey, err := getPrimaryKeyColumns(db, dbName, table)
+	if err != nil {
+		return err
+	}
+
+	var schema avro.Schema
+	var columns []avroColumn
+	var header []byte
+	return scanRows(db, table, o, func(columnTypes []*sql.ColumnType, row []interface{}) error {
+		names := make([]string, len(columnTypes))
+		index := make(map[string]int, len(columnTypes))
+		for i, columnType := range columnTypes {
+			names[i] = columnType.Name()
+			index[names[i]] = i
+		}
+
+		if schema == nil {
+			var err error
+			schema, columns, err = avroSchema(dbName, table, columnTypes)
+			if err != nil {
+				return err
+			}
+			if registry != nil {
+				id, err := registry.register(topic+"-value", schema)
+				if err != nil {
+					return err
+				}
+				// magic byte then the big-endian schema id
+				header = make([]byte, 5)
+				binary.BigEndian.PutUint32(header[1:], uint32(id))
+			}
+		}
+
+		rows, err := o.transformRow(table, names, row)
+		if err != nil {
+			return err
+		}
+
+		for _, row := range rows {
+			record, err := avroRecord(columns, row)
+			if err != nil {
+				return fmt.Errorf("table %s: %v", table, err)
+			}
+			value, err := avro.Marshal(schema, record)
+			if err != nil {
+				return err
+			}
+
+			var key []byte
+			if len(primaryKey) > 0 {
+				keyValues := make([]interface{}, len(primaryKey))
+				for i, column := range primaryKey {
+					if j, ok := index[column]; ok {
+						keyValues[i] = jsonValue(columnTypes[j], row[j])
+					}
+				}
+				key, err = json.Marshal(keyValues)
+				if err != nil {
+					return err
+				}
+			}
+
+			message := make([]byte, 0, len(header)+len(value))
+			message = append(append(message, header...), value...)
+			err = publisher.Publish(topic, key, message)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// avroSchema the record schema of a result set, named after the table in the namespace of the
+// database. Avro names only allow letters, digits and _, other characters become _
+func avroSchema(dbName, table string, columnTypes []*sql.ColumnType) (avro.Schema, []avroColumn, error) {
+	fields := make([]map[string]interface{}, len(columnTypes))
+	columns := make([]avroColumn, len(columnTypes))
+	seen := make(map[string]bool, len(columnTypes))
+	for i, columnType := range columnTypes {
+		name := avroName(columnType.Name())
+		if seen[name] {
+			name = fmt.Sprintf("%s_%d", name, i)
+		}
+		seen[name] = true
+
+		typ, kind := avroType(columnType)
+		field := map[string]interface{}{"name": name, "type": typ}
+		if nullable, ok := columnType.Nullable(); !ok || nullable {
+			field["type"] = []interface{}{"null", typ}
+			field["default"] = nil
+		}
+		fields[i] = field
+		columns[i] = avroColumn{name: name, kind: kind}
+	}
+
+	record := map[string]interface{}{
+		"type":   "record",
+		"name":   avroName(table),
+		"fields": fields,
+	}
+	if dbName != "" {
+		record["namespace"] = avroName(dbName)
+	}
+	schema, err := json.Marshal(record)
+	if err != nil {
+		return nil, nil, err
+	}
+	parsed, err := avro.ParseBytes(schema)
+	if err != nil {
+		return nil, nil, fmt.Errorf("avro schema of %s: %v", table, err)
+	}
+	return parsed, columns, nil
+}
+
+// avroType the Avro type of a column, eg: DECIMAL(10,2) is a decimal of bytes, BIGINT UNSIGNED a
+// decimal as its values overflow a long, DATETIME a timestamp in microseconds, TIME and the types
+// without an Avro equivalent strings
+func avroType(columnType *sql.ColumnType) (interface{}, string) {
+	databaseTypeName := columnType.DatabaseTypeName()
+	typ := strings.TrimSpace(strings.Replace(databaseTypeName, "UNSIGNED", "", -1))
+	switch {
+	case isDecimalType(typ):
+		precision, scale, ok := columnType.DecimalSize()
+		if !ok {
+			return "string", "string"
+		}
+		return map[string]interface{}{"type": "bytes", "logicalType": "decimal", "precision": precision, "scale": scale}, "decimal"
+	case typ == "BIGINT" && strings.Contains(databaseTypeName, "UNSIGNED"):
+		return map[string]interface{}{"type": "bytes", "logicalType": "decimal", "precision": 20, "scale": 0}, "decimal"
+	case typ == "DATE":
+		return map[string]interface{}{"type": "int", "logicalType": "date"}, "date"
+	case typ == "DATETIME" || typ == "TIMESTAMP":
+		return map[string]interface{}{"type": "long", "logicalType": "timestamp-micros"}, "timestamp"
+	}
+
+	switch kind := goType(columnType); kind {
+	case "int64", "uint64":
+		return "long", "int64"
+	case "float64":
+		return "double", kind
+	case "bool":
+		return "boolean", kind
+	case "[]byte":
+		return "bytes", kind
+	default:
+		return "string", "string"
+	}
+}
+
+// avroRecord the record of a row, the driver sends the text of the values unless the DSN has parseTime
+func avroRecord(columns []avroColumn, row []interface{}) (map[string]interface{}, error) {
+	record := make(map[string]interface{}, len(columns))
+	for i, col := range row {
+		column := columns[i]
+		if col == nil {
+			record[column.name] = nil
+			continue
+		}
+
+		s := rawString(col)
+		switch column.kind {
+		case "int64":
+			n, err := strconv.ParseInt(s, 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("column %s: %v", column.name, err)
+			}
+			record[column.name] = n
+		case "float64":
+			f, err := strconv.ParseFloat(s, 64)
+			if err != nil {
+				return nil, fmt.Errorf("column %s: %v", column.name, err)
+			}
+			record[column.name] = f
+		case "bool":
+			record[column.name] = s == "1" || s == "true"
+		case "decimal":
+			r, ok := new(big.Rat).SetString(s)
+			if !ok {
+				return nil, fmt.Errorf("column %s: invalid decimal %q", column.name, s)
+			}
+			record[column.name] = r
+		case "date", "timestamp":
+			layout := "2006-01-02 15:04:05.999999"
+			if column.kind == "date" {
+				layout = "2006-01-02"
+			}
+			t, err := parseTemporal(col, layout)
+			if err != nil {
+				return nil, fmt.Errorf("column %s: %v", column.name, err)
+			}
+			record[column.name] = t
+		case "[]byte":
+			record[column.name] = []byte(s)
+		default:
+			record[column.name] = s
+		}
+	}
+	return record, nil
+}
+
+func avroName(name string) string {
+	var b strings.Builder
+	for i, r := range name {
+		switch {
+		case r == '_' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z':
+		case r >= '0' && r <= '9':
+			if i == 0 {
+				b.WriteByte('_')
+			}
+		default:
+			r = '_'
+		}
+		b.WriteRune(r)
+	}
+	if b.Len() == 0 {
+		return "_"
+	}
+	return b.String()
+}
+
+type schemaRegistry struct {
+	url    string
+	client *http.Client
+}
+
+// register the schema under subject, the id of an identical schema already registered is returned
+func (r *schemaRegistry) register(subject string, schema avro.Schema) (int, error) {
+	body, err := json.Marshal(map[string]string{"schema": schema.String()})
+	if err != nil {
+		return 0, err
+	}
+	resp, err := r.client.Post(fmt.Sprintf("%s/subjects/%s/versions", r.url, url.PathEscape(subject)),
+		"application/vnd.schemaregistry.v1+json", bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	var response struct {
+		ID      int    `json:"id"`
+		Message string `json:"message"`
+	}
+	err = json.NewDecoder(resp.Body).Decode(&response)
+	if err != nil {
+		return 0, fmt.Errorf("schema registry %s: %s", subject, resp.Status)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("schema registry %s: %s %s", subject, resp.Status, response.Message)
+	}
+	return response.ID, nil
+}
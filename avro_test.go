@@ -0,0 +1,194 @@
+package mysqldump
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/hamba/avro/v2"
+	"github.com/hamba/avro/v2/ocf"
+)
+
+// avroRows the rows of table order mocked for the Avro tests
+func avroRows() *sqlmock.Rows {
+	rows := sqlmock.NewRowsWithColumnDefinition(
+		sqlmock.NewColumn("id").OfType("BIGINT UNSIGNED", []byte{}).Nullable(false),
+		sqlmock.NewColumn("price").OfType("DECIMAL", []byte{}).WithPrecisionAndScale(10, 2).Nullable(true),
+		sqlmock.NewColumn("created").OfType("DATETIME", []byte{}).Nullable(false),
+		sqlmock.NewColumn("day").OfType("DATE", []byte{}).Nullable(true),
+		sqlmock.NewColumn("first-name").OfType("VARCHAR", []byte{}).Nullable(true),
+	)
+	rows.AddRow([]byte("18446744073709551615"), []byte("-12.50"), []byte("2024-02-29 23:59:59.123456"), []byte("1969-12-31"), []byte("it's"))
+	rows.AddRow([]byte("1"), nil, []byte("1970-01-01 00:00:00"), nil, nil)
+	return rows
+}
+
+func checkAvroRecords(t *testing.T, records []map[string]interface{}) {
+	t.Helper()
+	if len(records) != 2 {
+		t.Fatalf("%d records, want 2", len(records))
+	}
+
+	id, _ := new(big.Rat).SetString("18446744073709551615")
+	price, _ := new(big.Rat).SetString("-12.50")
+	created := time.Date(2024, 2, 29, 23, 59, 59, 123456000, time.UTC)
+	tests := []struct {
+		field string
+		want  interface{}
+	}{
+		{"id", id},
+		{"price", price},
+		{"created", created},
+		{"day", time.Date(1969, 12, 31, 0, 0, 0, 0, time.UTC)},
+		{"first_name", "it's"},
+	}
+	for _, test := range tests {
+		got := records[0][test.field]
+		equal := false
+		switch want := test.want.(type) {
+		case *big.Rat:
+			r, ok := got.(*big.Rat)
+			equal = ok && r.Cmp(want) == 0
+		case time.Time:
+			tm, ok := got.(time.Time)
+			equal = ok && tm.Equal(want)
+		default:
+			equal = got == want
+		}
+		if !equal {
+			t.Errorf("%s = %v, want %v", test.field, got, test.want)
+		}
+	}
+	for _, field := range []string{"price", "day", "first_name"} {
+		if records[1][field] != nil {
+			t.Errorf("%s = %v, want null", field, records[1][field])
+		}
+	}
+}
+
+func TestWriteAvro(t *testing.T) {
+	conn, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = conn.Close()
+	}()
+	mock.ExpectQuery("SELECT * FROM `order`").WillReturnRows(avroRows())
+
+	var buf bytes.Buffer
+	err = writeAvro(conn, "shop", "order", &buf, &dumpOption{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+
+	decoder, err := ocf.NewDecoder(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if name := decoder.Schema().(*avro.RecordSchema).FullName(); name != "shop.order" {
+		t.Errorf("schema name = %s, want shop.order", name)
+	}
+	var records []map[string]interface{}
+	for decoder.HasNext() {
+		var record map[string]interface{}
+		if err = decoder.Decode(&record); err != nil {
+			t.Fatal(err)
+		}
+		records = append(records, record)
+	}
+	if err = decoder.Error(); err != nil {
+		t.Fatal(err)
+	}
+	checkAvroRecords(t, records)
+}
+
+type message struct {
+	topic      string
+	key, value []byte
+}
+
+type messagePublisher struct {
+	messages []message
+}
+
+func (p *messagePublisher) Publish(topic string, key, value []byte) error {
+	p.messages = append(p.messages, message{topic, key, value})
+	return nil
+}
+
+func TestPublishAvro(t *testing.T) {
+	var subjects []string
+	var registered avro.Schema
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var request struct {
+			Schema string `json:"schema"`
+		}
+		err := json.NewDecoder(r.Body).Decode(&request)
+		if err != nil {
+			t.Error(err)
+		}
+		registered, err = avro.Parse(request.Schema)
+		if err != nil {
+			t.Error(err)
+		}
+		subjects = append(subjects, r.URL.Path)
+		_, _ = w.Write([]byte(`{"id": 42}`))
+	}))
+	defer server.Close()
+
+	conn, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = conn.Close()
+	}()
+	mock.ExpectQuery("SELECT COLUMN_NAME FROM information_schema.KEY_COLUMN_USAGE "+
+		"WHERE TABLE_SCHEMA = ? AND TABLE_NAME = ? AND CONSTRAINT_NAME = 'PRIMARY' ORDER BY ORDINAL_POSITION").
+		WithArgs("shop", "order").
+		WillReturnRows(sqlmock.NewRows([]string{"COLUMN_NAME"}).AddRow("id"))
+	mock.ExpectQuery("SELECT * FROM `order`").WillReturnRows(avroRows())
+
+	publisher := &messagePublisher{}
+	registry := &schemaRegistry{url: server.URL, client: server.Client()}
+	err = publishAvro(conn, "shop", "order", publisher, "mysql.shop.order", registry, &dumpOption{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(subjects) != 1 || subjects[0] != "/subjects/mysql.shop.order-value/versions" {
+		t.Fatalf("registered subjects %q, want the one of mysql.shop.order-value", subjects)
+	}
+	var records []map[string]interface{}
+	for _, m := range publisher.messages {
+		if m.topic != "mysql.shop.order" {
+			t.Errorf("topic = %s, want mysql.shop.order", m.topic)
+		}
+		if len(m.value) < 5 || m.value[0] != 0 || binary.BigEndian.Uint32(m.value[1:5]) != 42 {
+			t.Fatalf("message %x misses the header of schema 42", m.value)
+		}
+		var record map[string]interface{}
+		err = avro.Unmarshal(registered, m.value[5:], &record)
+		if err != nil {
+			t.Fatal(err)
+		}
+		records = append(records, record)
+	}
+	checkAvroRecords(t, records)
+	if key := string(publisher.messages[0].key); key != "[18446744073709551615]" {
+		t.Errorf("key = %s, want [18446744073709551615]", key)
+	}
+}
@@ -0,0 +1,141 @@
+package mysqldump
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+)
+
+// snapshotAttempts the snapshots started by WithBackupLock before the dump fails on a binlog
+// position that keeps moving
+const snapshotAttempts = 10
+
+// WithBackupLock hold LOCK INSTANCE FOR BACKUP (MySQL 8.0, BACKUP_ADMIN privilege) for the
+// whole dump: DDL and other changes to the files of the tables wait for the dump, writes go
+// on. WithFlushLogs then takes the backup lock instead of FLUSH TABLES WITH READ LOCK, which
+// blocks every write while the snapshot starts. Writes are not stopped, the binlog position
+// of the snapshot is the Binlog_snapshot_file of the servers reporting it (Percona Server,
+// MariaDB), elsewhere performance_schema.log_status is read before and after the snapshot
+// starts and the snapshot is started again until no transaction was committed in between.
+// The dump fails when the position keeps moving, it never records a position that does not
+// match the snapshot. A server without backup locks records a warning and the dump goes on
+// as without it.
+func WithBackupLock() DumpOption {
+	return func(option *dumpOption) {
+		option.isBackupLock = true
+	}
+}
+
+// lockInstanceForBackup take the WithBackupLock lock, it reports whether it is held
+func lockInstanceForBackup(db dbConn, o *dumpOption) bool {
+	_, err := db.Exec("LOCK INSTANCE FOR BACKUP")
+	if err != nil {
+		o.warnf("LOCK INSTANCE FOR BACKUP failed, the dump goes on without the backup lock: %v", err)
+		return false
+	}
+	o.backupLocked = true
+	return true
+}
+
+// flushLogsUnderBackupLock the WithFlushLogs sequence under the backup lock
+func flushLogsUnderBackupLock(db dbConn, o *dumpOption) (bool, error) {
+	_, err := db.Exec("FLUSH LOGS")
+	if err != nil {
+		if isPrivilegeError(err) {
+			o.warnf("FLUSH LOGS denied, the binary logs are not flushed: %v", err)
+			return false, nil
+		}
+		return false, err
+	}
+
+	for attempt := 1; ; attempt++ {
+		before, beforeErr := readLogStatus(db)
+		err = startSnapshot(db, o.isolationLevel)
+		if err != nil {
+			return false, err
+		}
+
+		position := readSnapshotStatus(db)
+		if position == nil {
+			if beforeErr != nil {
+				// BACKUP_ADMIN is missing or the server has no log_status, the snapshot is
+				// still consistent
+				o.warnf("binlog position: %v", beforeErr)
+				return true, nil
+			}
+
+			after, err := readLogStatus(db)
+			if err != nil {
+				return false, err
+			}
+			if !sameBinlogPosition(before, after) {
+				if attempt == snapshotAttempts {
+					return false, fmt.Errorf("binlog position: transactions were committed while each of %d snapshots started", attempt)
+				}
+				_, err = db.Exec("ROLLBACK")
+				if err != nil {
+					return false, err
+				}
+				continue
+			}
+			position = before
+		}
+
+		if position != nil && o.result != nil {
+			o.result.BinlogPosition = position
+		}
+		return true, nil
+	}
+}
+
+// readSnapshotStatus the binlog coordinates of the snapshot of the transaction, nil when the
+// server does not report them
+func readSnapshotStatus(db dbConn) *BinlogPosition {
+	status := make(map[string]string)
+	err := queryRows(db, "SHOW STATUS LIKE 'Binlog_snapshot_%'", nil, func(values []string) {
+		status[values[0]] = values[1]
+	})
+	if err != nil || status["Binlog_snapshot_file"] == "" {
+		return nil
+	}
+	offset, err := strconv.ParseUint(status["Binlog_snapshot_position"], 10, 64)
+	if err != nil {
+		return nil
+	}
+	return &BinlogPosition{
+		File:     status["Binlog_snapshot_file"],
+		Position: offset,
+		GTIDSet:  status["Binlog_snapshot_gtid_executed"],
+	}
+}
+
+// readLogStatus the binlog coordinates of performance_schema.log_status (MySQL 8.0.14), the
+// server blocks the commits while it collects them. Nil when the binary log is disabled.
+func readLogStatus(db dbConn) (*BinlogPosition, error) {
+	var local []byte
+	err := db.QueryRow("SELECT LOCAL FROM performance_schema.log_status").Scan(&local)
+	if err != nil {
+		return nil, fmt.Errorf("performance_schema.log_status: %v", err)
+	}
+
+	var status struct {
+		GTIDExecuted string `json:"gtid_executed"`
+		File         string `json:"binary_log_file"`
+		Position     uint64 `json:"binary_log_position"`
+	}
+	err = json.Unmarshal(local, &status)
+	if err != nil {
+		return nil, fmt.Errorf("performance_schema.log_status: %v", err)
+	}
+	if status.File == "" {
+		return nil, nil
+	}
+	return &BinlogPosition{File: status.File, Position: status.Position, GTIDSet: status.GTIDExecuted}, nil
+}
+
+func sameBinlogPosition(a, b *BinlogPosition) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
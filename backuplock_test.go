@@ -0,0 +1,98 @@
+package mysqldump
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+// logStatus the LOCAL column of performance_schema.log_status at position
+func logStatus(position uint64) *sqlmock.Rows {
+	local := fmt.Sprintf(`{"gtid_executed": "3e11fa47-71ca-11e1-9e33-c80aa9429562:1-%d", "binary_log_file": "binlog.000002", "binary_log_position": %d}`, position, position)
+	return sqlmock.NewRows([]string{"LOCAL"}).AddRow([]byte(local))
+}
+
+func TestFlushLogsUnderBackupLock(t *testing.T) {
+	tests := []struct {
+		name string
+		// the log_status positions read before and after each snapshot start
+		positions [][2]uint64
+		snapshot  *sqlmock.Rows
+		want      uint64
+		wantErr   bool
+	}{
+		{name: "stable", positions: [][2]uint64{{120, 120}}, want: 120},
+		{name: "moved then stable", positions: [][2]uint64{{120, 150}, {150, 180}, {180, 180}}, want: 180},
+		{name: "always moving", positions: func() [][2]uint64 {
+			var positions [][2]uint64
+			for i := uint64(0); i < snapshotAttempts; i++ {
+				positions = append(positions, [2]uint64{i, i + 1})
+			}
+			return positions
+		}(), wantErr: true},
+		{name: "snapshot status", positions: [][2]uint64{{120, 0}}, want: 150,
+			snapshot: sqlmock.NewRows([]string{"Variable_name", "Value"}).
+				AddRow("Binlog_snapshot_file", "binlog.000002").
+				AddRow("Binlog_snapshot_position", "150").
+				AddRow("Binlog_snapshot_gtid_executed", "3e11fa47-71ca-11e1-9e33-c80aa9429562:1-150")},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			conn, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer func() {
+				_ = conn.Close()
+			}()
+
+			mock.ExpectExec("FLUSH LOGS").WillReturnResult(sqlmock.NewResult(0, 0))
+			for i, position := range test.positions {
+				mock.ExpectQuery("SELECT LOCAL FROM performance_schema.log_status").WillReturnRows(logStatus(position[0]))
+				mock.ExpectExec("SET SESSION TRANSACTION ISOLATION LEVEL REPEATABLE READ").WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectExec("START TRANSACTION WITH CONSISTENT SNAPSHOT").WillReturnResult(sqlmock.NewResult(0, 0))
+				snapshot := test.snapshot
+				if snapshot == nil {
+					snapshot = sqlmock.NewRows([]string{"Variable_name", "Value"})
+				}
+				mock.ExpectQuery("SHOW STATUS LIKE 'Binlog_snapshot_%'").WillReturnRows(snapshot)
+				if test.snapshot != nil {
+					break
+				}
+				mock.ExpectQuery("SELECT LOCAL FROM performance_schema.log_status").WillReturnRows(logStatus(position[1]))
+				if position[0] != position[1] && i < snapshotAttempts-1 {
+					mock.ExpectExec("ROLLBACK").WillReturnResult(sqlmock.NewResult(0, 0))
+				}
+			}
+
+			result := &DumpResult{}
+			o := &dumpOption{backupLocked: true, result: result}
+			snapshot, err := flushLogsUnderBackupLock(conn, o)
+			if mockErr := mock.ExpectationsWereMet(); mockErr != nil {
+				t.Fatal(mockErr)
+			}
+			if test.wantErr {
+				if err == nil {
+					t.Fatalf("a moving binlog position was accepted: %v", result.BinlogPosition)
+				}
+				if result.BinlogPosition != nil {
+					t.Errorf("binlog position %v recorded", result.BinlogPosition)
+				}
+				return
+			}
+			if err != nil || !snapshot {
+				t.Fatalf("snapshot %v: %v", snapshot, err)
+			}
+			want := &BinlogPosition{
+				File:     "binlog.000002",
+				Position: test.want,
+				GTIDSet:  fmt.Sprintf("3e11fa47-71ca-11e1-9e33-c80aa9429562:1-%d", test.want),
+			}
+			if !sameBinlogPosition(result.BinlogPosition, want) {
+				t.Errorf("binlog position %v, want %v", result.BinlogPosition, want)
+			}
+		})
+	}
+}
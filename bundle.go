@@ -0,0 +1,110 @@
+package mysqldump
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"mysqldump/sqlutil"
+)
+
+// WithTableTags assign a tag to tables, table or db.table -> tag, eg:
+// {"countries": "reference", "orders": "transactional", "audit_log": "audit"}.
+// With WithTagBundle the tables of a tag are written to a bundle of their own.
+func WithTableTags(tags map[string]string) DumpOption {
+	return func(o *dumpOption) {
+		if o.tableTags == nil {
+			o.tableTags = make(map[string]string)
+		}
+		for table, tag := range tags {
+			o.tableTags[table] = tag
+		}
+	}
+}
+
+// WithTagBundle write the tables tagged tag by WithTableTags to writer instead of the
+// output of Dump, in the same pass, eg: to restore the reference data everywhere but
+// the transactional data only where needed. A bundle is loaded with foreign key checks
+// off, the tables it references may be in another bundle. Views, routines and triggers
+// stay in the output of Dump, and so do its compression, encryption and signature.
+func WithTagBundle(tag string, writer io.Writer) DumpOption {
+	return func(o *dumpOption) {
+		if o.bundles == nil {
+			o.bundles = make(map[string]*tagBundle)
+		}
+		o.bundles[tag] = &tagBundle{tag: tag, writer: writer}
+	}
+}
+
+type tagBundle struct {
+	tag    string
+	writer io.Writer
+	buf    *SafeWriter
+	// the database of the last USE of the bundle
+	db string
+}
+
+// start write the header of the bundle once
+func (b *tagBundle) start(o *dumpOption) *SafeWriter {
+	if b.buf == nil {
+		b.buf = NewSafeWriterWithSize(b.writer, BufferSize)
+		format := o.dumpFormat()
+		format.Features = append(format.Features, FeatureBundle)
+		_, _ = b.buf.WriteString(format.String() + "\n")
+		_, _ = b.buf.WriteString("-- ----------------------------\n")
+		_, _ = b.buf.WriteString("-- MySQL Database Dump, bundle " + b.tag + "\n")
+		_, _ = b.buf.WriteString("-- Start Time: " + time.Now().Format("2006-01-02 15:04:05") + "\n")
+		_, _ = b.buf.WriteString("-- ----------------------------\n")
+		_, _ = b.buf.WriteString("\n\n")
+		_, _ = b.buf.WriteString(o.profile.header())
+		_, _ = b.buf.WriteString("SET FOREIGN_KEY_CHECKS=0;\n")
+	}
+	return b.buf
+}
+
+// tableWriter the writer of the statements of dbName.table, the one of the bundle of
+// its tag or buf
+func (o *dumpOption) tableWriter(buf *SafeWriter, dbName, table string) *SafeWriter {
+	tag, ok := o.tableTags[dbName+"."+table]
+	if !ok {
+		tag, ok = o.tableTags[table]
+	}
+	bundle := o.bundles[tag]
+	if !ok || bundle == nil {
+		return buf
+	}
+
+	bundleBuf := bundle.start(o)
+	if bundle.db != dbName {
+		_, _ = bundleBuf.WriteString(o.annotation(dbName, "", "", 0))
+		_, _ = bundleBuf.WriteString(fmt.Sprintf("USE %s;\n", sqlutil.QuoteIdentifier(o.template(dbName))))
+		bundle.db = dbName
+	}
+	return bundleBuf
+}
+
+// finishBundles write the trailer of the bundles, a bundle without tables is written too
+func (o *dumpOption) finishBundles(start time.Time) {
+	for _, bundle := range o.bundles {
+		buf := bundle.start(o)
+		_, _ = buf.WriteString("SET FOREIGN_KEY_CHECKS=1;\n")
+		_, _ = buf.WriteString("-- ----------------------------\n")
+		_, _ = buf.WriteString("-- Dump completed\n")
+		_, _ = buf.WriteString("-- Cost Time: " + time.Since(start).String() + "\n")
+		_, _ = buf.WriteString("-- ----------------------------\n")
+	}
+}
+
+// flushBundles flush the bundles, the first error is returned
+func (o *dumpOption) flushBundles() error {
+	var err error
+	for _, bundle := range o.bundles {
+		if bundle.buf == nil {
+			continue
+		}
+		if flushErr := bundle.buf.Flush(); err == nil {
+			err = flushErr
+		}
+	}
+	return err
+}
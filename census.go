@@ -0,0 +1,96 @@
+package mysqldump
+
+import (
+	"log"
+	"strconv"
+	"time"
+)
+
+// CensusReport the size of the selected databases, see Census
+type CensusReport struct {
+	Databases []DatabaseCensus
+	// DataBytes, IndexBytes and Rows the totals of the databases
+	DataBytes  int64
+	IndexBytes int64
+	Rows       int64
+}
+
+// DatabaseCensus the tables of a database and their totals
+type DatabaseCensus struct {
+	Name       string
+	Tables     []TableCensus
+	DataBytes  int64
+	IndexBytes int64
+	Rows       int64
+}
+
+// TableCensus the size of a table as reported by information_schema.TABLES
+type TableCensus struct {
+	Name   string
+	Engine string
+	// Rows an estimate for InnoDB tables
+	Rows       int64
+	DataBytes  int64
+	IndexBytes int64
+	// UpdateTime the last change of the table, zero when the server does not know it,
+	// eg: InnoDB before MySQL 5.7 or since the last restart
+	UpdateTime time.Time
+}
+
+// Census the databases and tables a Dump with opts would export, with their engine,
+// estimated rows, data and index sizes and last update, without exporting anything, eg: to
+// plan the capacity of backups. WithDBs, WithAllDatabases, WithTables and WithAllTables
+// select the tables.
+func Census(dns string, opts ...DumpOption) (*CensusReport, error) {
+	var o dumpOption
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	census := &CensusReport{}
+	err := forEachTable(dns, &o, func(db dbConn, dbName, table string) error {
+		if n := len(census.Databases); n == 0 || census.Databases[n-1].Name != dbName {
+			census.Databases = append(census.Databases, DatabaseCensus{Name: dbName})
+		}
+		database := &census.Databases[len(census.Databases)-1]
+
+		t := TableCensus{Name: table}
+		err := queryRows(db, "SELECT ENGINE, TABLE_ROWS, DATA_LENGTH, INDEX_LENGTH, UPDATE_TIME FROM information_schema.TABLES "+
+			"WHERE TABLE_SCHEMA = ? AND TABLE_NAME = ?",
+			[]interface{}{dbName, table}, func(values []string) {
+				t.Engine = values[0]
+				t.Rows, _ = strconv.ParseInt(values[1], 10, 64)
+				t.DataBytes, _ = strconv.ParseInt(values[2], 10, 64)
+				t.IndexBytes, _ = strconv.ParseInt(values[3], 10, 64)
+				t.UpdateTime = parseServerTime(values[4])
+			})
+		if err != nil {
+			return err
+		}
+
+		database.Tables = append(database.Tables, t)
+		database.Rows += t.Rows
+		database.DataBytes += t.DataBytes
+		database.IndexBytes += t.IndexBytes
+		census.Rows += t.Rows
+		census.DataBytes += t.DataBytes
+		census.IndexBytes += t.IndexBytes
+		return nil
+	})
+	if err != nil {
+		log.Printf("[error] %v \n", err)
+		return nil, err
+	}
+	return census, nil
+}
+
+// parseServerTime a DATETIME scanned as a string, with or without parseTime in the dsn,
+// zero when s is empty or unknown
+func parseServerTime(s string) time.Time {
+	for _, layout := range []string{"2006-01-02 15:04:05", time.RFC3339Nano} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
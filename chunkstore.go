@@ -0,0 +1,226 @@
+package mysqldump
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// content defined chunking, the boundaries follow the content so that an insertion only
+// changes the chunks around it
+const (
+	chunkMinSize = 64 << 10
+	chunkMaxSize = 1 << 20
+	// chunkMask 18 bits, about 256KiB chunks on average
+	chunkMask = 1<<18 - 1
+)
+
+// recipeHeader the first line of a recipe, then one chunk hash per line
+const recipeHeader = "mysqldump-recipe-v1"
+
+// gearTable random values of the rolling hash, derived from a fixed seed: changing them
+// would change every boundary and defeat the deduplication with older dumps
+var gearTable = func() (table [256]uint64) {
+	for i := range table {
+		sum := sha256.Sum256([]byte(fmt.Sprintf("mysqldump-gear-%d", i)))
+		table[i] = binary.BigEndian.Uint64(sum[:8])
+	}
+	return table
+}()
+
+// ChunkStore a content addressed storage, chunks are keyed by the hex SHA-256 of their data
+type ChunkStore interface {
+	Has(hash string) (bool, error)
+	Put(hash string, data []byte) error
+	Get(hash string) ([]byte, error)
+}
+
+// WithChunkStore (experimental) split the dump into chunks stored in store, the chunks a
+// previous dump already stored are not stored again, and write the recipe listing the
+// chunks instead of the dump. Load it with WithSourceChunkStore or NewChunkReader.
+// Combined with WithEncryption nothing is deduplicated, the data key differs in each dump.
+func WithChunkStore(store ChunkStore) DumpOption {
+	return func(option *dumpOption) {
+		option.chunkStore = store
+	}
+}
+
+// WithSourceChunkStore read the input as a recipe of WithChunkStore and load its chunks
+func WithSourceChunkStore(store ChunkStore) SourceOption {
+	return func(o *sourceOption) {
+		o.chunkStore = store
+	}
+}
+
+// NewChunkWriter split what is written into chunks stored in store and write their hashes
+// into recipe, Close stores the last chunk
+func NewChunkWriter(store ChunkStore, recipe io.Writer) (io.WriteCloser, error) {
+	_, err := io.WriteString(recipe, recipeHeader+"\n")
+	if err != nil {
+		return nil, err
+	}
+	return &chunkWriter{store: store, recipe: recipe}, nil
+}
+
+type chunkWriter struct {
+	store  ChunkStore
+	recipe io.Writer
+	buf    []byte
+	hash   uint64
+}
+
+func (c *chunkWriter) Write(p []byte) (int, error) {
+	start := 0
+	for i, b := range p {
+		c.hash = c.hash<<1 + gearTable[b]
+		size := len(c.buf) + i + 1 - start
+		if size >= chunkMinSize && c.hash&chunkMask == 0 || size >= chunkMaxSize {
+			c.buf = append(c.buf, p[start:i+1]...)
+			start = i + 1
+			err := c.flush()
+			if err != nil {
+				return 0, err
+			}
+		}
+	}
+	c.buf = append(c.buf, p[start:]...)
+	return len(p), nil
+}
+
+func (c *chunkWriter) flush() error {
+	if len(c.buf) == 0 {
+		return nil
+	}
+	sum := sha256.Sum256(c.buf)
+	hash := hex.EncodeToString(sum[:])
+
+	ok, err := c.store.Has(hash)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		err = c.store.Put(hash, c.buf)
+		if err != nil {
+			return err
+		}
+	}
+	_, err = io.WriteString(c.recipe, hash+"\n")
+	if err != nil {
+		return err
+	}
+
+	c.buf = make([]byte, 0, len(c.buf))
+	c.hash = 0
+	return nil
+}
+
+func (c *chunkWriter) Close() error {
+	return c.flush()
+}
+
+// NewChunkReader the content listed by a recipe of WithChunkStore or NewChunkWriter, each
+// chunk is checked against its hash
+func NewChunkReader(store ChunkStore, recipe io.Reader) (io.Reader, error) {
+	scanner := bufio.NewScanner(recipe)
+	if !scanner.Scan() || scanner.Text() != recipeHeader {
+		if err := scanner.Err(); err != nil {
+			return nil, err
+		}
+		return nil, errors.New("not a chunk recipe")
+	}
+	return &chunkReader{store: store, recipe: scanner}, nil
+}
+
+type chunkReader struct {
+	store  ChunkStore
+	recipe *bufio.Scanner
+	buf    []byte
+}
+
+func (c *chunkReader) Read(p []byte) (int, error) {
+	for len(c.buf) == 0 {
+		if !c.recipe.Scan() {
+			if err := c.recipe.Err(); err != nil {
+				return 0, err
+			}
+			return 0, io.EOF
+		}
+		hash := strings.TrimSpace(c.recipe.Text())
+		data, err := c.store.Get(hash)
+		if err != nil {
+			return 0, fmt.Errorf("chunk %s: %w", hash, err)
+		}
+		if sum := sha256.Sum256(data); hex.EncodeToString(sum[:]) != hash {
+			return 0, fmt.Errorf("chunk %s: corrupted", hash)
+		}
+		c.buf = data
+	}
+	n := copy(p, c.buf)
+	c.buf = c.buf[n:]
+	return n, nil
+}
+
+// DirChunkStore a ChunkStore in a local directory, chunks are files named by their hash in
+// subdirectories named by its first two characters
+type DirChunkStore string
+
+func (d DirChunkStore) path(hash string) (string, error) {
+	if len(hash) != 2*sha256.Size || strings.Trim(hash, "0123456789abcdef") != "" {
+		return "", fmt.Errorf("invalid chunk hash %q", hash)
+	}
+	return filepath.Join(string(d), hash[:2], hash), nil
+}
+
+func (d DirChunkStore) Has(hash string) (bool, error) {
+	path, err := d.path(hash)
+	if err != nil {
+		return false, err
+	}
+	_, err = os.Stat(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// Put write the chunk into a temporary file renamed once complete, a crash leaves no partial chunk
+func (d DirChunkStore) Put(hash string, data []byte) error {
+	path, err := d.path(hash)
+	if err != nil {
+		return err
+	}
+	err = os.MkdirAll(filepath.Dir(path), 0o755)
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+hash+".*")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = os.Remove(tmp.Name())
+	}()
+	_, err = tmp.Write(data)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+func (d DirChunkStore) Get(hash string) ([]byte, error) {
+	path, err := d.path(hash)
+	if err != nil {
+		return nil, err
+	}
+	return os.ReadFile(path)
+}
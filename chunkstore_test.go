@@ -0,0 +1,119 @@
+package mysqldump
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// countingStore count the chunks put into a DirChunkStore
+type countingStore struct {
+	DirChunkStore
+	puts int
+}
+
+func (c *countingStore) Put(hash string, data []byte) error {
+	c.puts++
+	return c.DirChunkStore.Put(hash, data)
+}
+
+// chunkDump the rows of a dump of n rows with random values, the same for a seed
+func chunkDump(seed int64, n int) []byte {
+	r := rand.New(rand.NewSource(seed))
+	var buf bytes.Buffer
+	for i := 0; i < n; i++ {
+		_, _ = fmt.Fprintf(&buf, "INSERT INTO `order` VALUES (%d,'%x',%d);\n", i, r.Int63(), r.Intn(1000))
+	}
+	return buf.Bytes()
+}
+
+// storeChunks write data in pieces of varied sizes through a chunk writer, it returns the recipe
+func storeChunks(t *testing.T, store ChunkStore, data []byte) []byte {
+	t.Helper()
+	var recipe bytes.Buffer
+	w, err := NewChunkWriter(store, &recipe)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for start, size := 0, 1; start < len(data); start, size = start+size, size*7%65521+1 {
+		end := start + size
+		if end > len(data) {
+			end = len(data)
+		}
+		_, err = w.Write(data[start:end])
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	err = w.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return recipe.Bytes()
+}
+
+func TestChunkStoreRoundTrip(t *testing.T) {
+	store := &countingStore{DirChunkStore: DirChunkStore(t.TempDir())}
+	dump := chunkDump(1, 100000)
+	recipe := storeChunks(t, store, dump)
+
+	chunks := strings.Count(string(recipe), "\n") - 1
+	if chunks < 3 {
+		t.Fatalf("%d chunks for %d bytes", chunks, len(dump))
+	}
+	r, err := NewChunkReader(store, bytes.NewReader(recipe))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, dump) {
+		t.Fatalf("the rebuilt dump differs, %d bytes for %d", len(got), len(dump))
+	}
+}
+
+func TestChunkStoreDeduplication(t *testing.T) {
+	store := &countingStore{DirChunkStore: DirChunkStore(t.TempDir())}
+	dump := chunkDump(1, 100000)
+	recipe := storeChunks(t, store, dump)
+	chunks := store.puts
+
+	// the same dump stores nothing
+	store.puts = 0
+	if again := storeChunks(t, store, dump); !bytes.Equal(again, recipe) {
+		t.Error("the recipe of the same dump differs")
+	}
+	if store.puts != 0 {
+		t.Errorf("%d chunks stored again for the same dump", store.puts)
+	}
+
+	// a row inserted in the middle only changes the chunks around it
+	store.puts = 0
+	middle := bytes.IndexByte(dump[len(dump)/2:], '\n') + len(dump)/2 + 1
+	changed := append(append(append([]byte{}, dump[:middle]...), "INSERT INTO `order` VALUES (0,'new',0);\n"...), dump[middle:]...)
+	storeChunks(t, store, changed)
+	if store.puts > 2 {
+		t.Errorf("%d of %d chunks stored after one inserted row", store.puts, chunks)
+	}
+
+	var files int
+	err := filepath.Walk(string(store.DirChunkStore), func(path string, info os.FileInfo, err error) error {
+		if err == nil && !info.IsDir() {
+			files++
+		}
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if files != chunks+store.puts {
+		t.Errorf("%d files in the store, want %d", files, chunks+store.puts)
+	}
+}
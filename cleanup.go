@@ -0,0 +1,73 @@
+package mysqldump
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"log"
+	"time"
+)
+
+// cleanupTimeout the time left to the statements releasing the server-side state of a
+// cancelled or failed run
+const cleanupTimeout = 10 * time.Second
+
+// releaseExec run a statement releasing server-side state, eg: UNLOCK TABLES, even when the
+// context of the run is cancelled
+func releaseExec(db dbConn, query string) error {
+	if c, ok := db.(*ctxConn); ok {
+		return releaseExecContext(c.conn, query)
+	}
+	_, err := db.Exec(query)
+	return err
+}
+
+func releaseExecContext(conn QueryerExecer, query string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), cleanupTimeout)
+	defer cancel()
+	_, err := conn.ExecContext(ctx, query)
+	return err
+}
+
+// discardConn close conn instead of returning it to the pool, its session state is unknown
+func discardConn(conn *sql.Conn) {
+	_ = conn.Raw(func(interface{}) error {
+		return driver.ErrBadConn
+	})
+}
+
+// killOnCancel kill the running query of conn from another connection of pool when ctx is
+// cancelled: closing the client side of a connection does not stop the server from running
+// its query, or from holding its locks meanwhile. stop must be called before conn is
+// released.
+func killOnCancel(ctx context.Context, pool *sql.DB, conn QueryerExecer) (stop func()) {
+	var id int64
+	err := conn.QueryRowContext(ctx, "SELECT CONNECTION_ID()").Scan(&id)
+	if err != nil {
+		log.Printf("[warn] connection id unknown, queries are not killed on cancel: %v \n", err)
+		return func() {}
+	}
+
+	done := make(chan struct{})
+	finished := make(chan struct{})
+	go func() {
+		defer close(finished)
+		select {
+		case <-done:
+			return
+		case <-ctx.Done():
+		}
+
+		killCtx, cancel := context.WithTimeout(context.Background(), cleanupTimeout)
+		defer cancel()
+		_, err := pool.ExecContext(killCtx, fmt.Sprintf("KILL QUERY %d", id))
+		if err != nil {
+			log.Printf("[warn] KILL QUERY %d: %v \n", id, err)
+		}
+	}()
+	return func() {
+		close(done)
+		<-finished
+	}
+}
@@ -0,0 +1,220 @@
+package mysqldump
+
+import (
+	"database/sql"
+	"fmt"
+	"io"
+	"log"
+	"strings"
+)
+
+// clickHouseBatchSize rows per INSERT, ClickHouse prefers few large inserts
+const clickHouseBatchSize = 1000
+
+// DumpClickHouse write the selected tables as ClickHouse SQL: CREATE TABLE with the MySQL
+// types mapped to ClickHouse ones and a MergeTree engine ordered by the primary key, then
+// batched INSERT statements. WithDBs, WithAllDatabases, WithTables, WithAllTables,
+// WithWhere, WithDumpTable, WithDropTable and WithData apply like for Dump.
+func DumpClickHouse(dns string, writer io.Writer, opts ...DumpOption) error {
+	var o dumpOption
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	buf := NewSafeWriterWithSize(writer, BufferSize)
+	defer func() {
+		_ = buf.Flush()
+	}()
+
+	err := forEachTable(dns, &o, func(db dbConn, dbName, table string) error {
+		name := clickHouseIdentifier(dbName) + "." + clickHouseIdentifier(table)
+
+		if o.isDropTable {
+			_, _ = buf.WriteString(fmt.Sprintf("DROP TABLE IF EXISTS %s;\n", name))
+		}
+
+		if o.isDumpTable {
+			ddl, err := clickHouseCreateTable(db, dbName, table)
+			if err != nil {
+				return err
+			}
+			_, _ = buf.WriteString(ddl)
+		}
+
+		if !o.isData {
+			return nil
+		}
+
+		var columns string
+		var values []string
+		flush := func() {
+			if len(values) == 0 {
+				return
+			}
+			_, _ = buf.WriteString(fmt.Sprintf("INSERT INTO %s (%s) VALUES\n%s;\n", name, columns, strings.Join(values, ",\n")))
+			values = values[:0]
+		}
+
+		err := scanRows(db, table, &o, func(columnTypes []*sql.ColumnType, row []interface{}) error {
+			if columns == "" {
+				names := make([]string, len(columnTypes))
+				for i, columnType := range columnTypes {
+					names[i] = clickHouseIdentifier(columnType.Name())
+				}
+				columns = strings.Join(names, ", ")
+			}
+
+			literals := make([]string, len(row))
+			for i, col := range row {
+				literals[i] = clickHouseValue(columnTypes[i], col)
+			}
+			values = append(values, "("+strings.Join(literals, ", ")+")")
+			if len(values) >= clickHouseBatchSize {
+				flush()
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		flush()
+		_, _ = buf.WriteString("\n")
+		return nil
+	})
+	if err != nil {
+		log.Printf("[error] %v \n", err)
+		return err
+	}
+	return buf.Flush()
+}
+
+func clickHouseCreateTable(db dbConn, dbName, table string) (string, error) {
+	var columns []string
+	err := queryRows(db, "SELECT COLUMN_NAME, DATA_TYPE, COLUMN_TYPE, IS_NULLABLE, NUMERIC_PRECISION, NUMERIC_SCALE "+
+		"FROM information_schema.COLUMNS WHERE TABLE_SCHEMA = ? AND TABLE_NAME = ? ORDER BY ORDINAL_POSITION",
+		[]interface{}{dbName, table}, func(values []string) {
+			typ := clickHouseType(values[1], values[2], values[4], values[5])
+			if values[3] == "YES" {
+				typ = "Nullable(" + typ + ")"
+			}
+			columns = append(columns, fmt.Sprintf("    %s %s", clickHouseIdentifier(values[0]), typ))
+		})
+	if err != nil {
+		return "", err
+	}
+
+	primaryKey, err := getPrimaryKeyColumns(db, dbName, table)
+	if err != nil {
+		return "", err
+	}
+	orderBy := "tuple()"
+	if len(primaryKey) > 0 {
+		keys := make([]string, len(primaryKey))
+		for i, column := range primaryKey {
+			keys[i] = clickHouseIdentifier(column)
+		}
+		orderBy = "(" + strings.Join(keys, ", ") + ")"
+	}
+
+	return fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s.%s\n(\n%s\n)\nENGINE = MergeTree\nORDER BY %s;\n\n",
+		clickHouseIdentifier(dbName), clickHouseIdentifier(table), strings.Join(columns, ",\n"), orderBy), nil
+}
+
+// clickHouseType map a MySQL column type to a ClickHouse type
+func clickHouseType(dataType, columnType, precision, scale string) string {
+	unsigned := strings.Contains(columnType, "unsigned")
+	integer := func(bits string) string {
+		if unsigned {
+			return "UInt" + bits
+		}
+		return "Int" + bits
+	}
+
+	switch strings.ToLower(dataType) {
+	case "tinyint":
+		if columnType == "tinyint(1)" {
+			return "Bool"
+		}
+		return integer("8")
+	case "smallint":
+		return integer("16")
+	case "mediumint", "int", "integer":
+		return integer("32")
+	case "bigint":
+		return integer("64")
+	case "float":
+		return "Float32"
+	case "double", "real":
+		return "Float64"
+	case "decimal", "numeric":
+		return fmt.Sprintf("Decimal(%s, %s)", precision, scale)
+	case "date":
+		return "Date32"
+	case "datetime", "timestamp":
+		return "DateTime64(6)"
+	case "year":
+		return "UInt16"
+	case "bit":
+		return "UInt64"
+	case "bool", "boolean":
+		return "Bool"
+	default:
+		// char, varchar, text, blob, binary, enum, set, json and time are kept as strings
+		return "String"
+	}
+}
+
+func clickHouseIdentifier(name string) string {
+	return "`" + strings.Replace(strings.Replace(name, `\`, `\\`, -1), "`", "\\`", -1) + "`"
+}
+
+func clickHouseValue(columnType *sql.ColumnType, col interface{}) string {
+	if col == nil {
+		return "NULL"
+	}
+
+	switch goType(columnType) {
+	case "int64", "uint64", "float64":
+		return rawString(col)
+	case "bool":
+		s := rawString(col)
+		return fmt.Sprintf("%t", s == "1" || s == "true")
+	}
+	if isDecimalType(columnType.DatabaseTypeName()) {
+		return rawString(col)
+	}
+	if columnType.DatabaseTypeName() == "BIT" {
+		return fmt.Sprintf("%d", bitValue(col))
+	}
+
+	// ClickHouse string literals use backslash escapes, any byte can be written as \xHH
+	s := rawString(col)
+	var builder strings.Builder
+	builder.WriteByte('\'')
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case c == '\'' || c == '\\':
+			builder.WriteByte('\\')
+			builder.WriteByte(c)
+		case c < 0x20 || c == 0x7f:
+			builder.WriteString(fmt.Sprintf("\\x%02X", c))
+		default:
+			builder.WriteByte(c)
+		}
+	}
+	builder.WriteByte('\'')
+	return builder.String()
+}
+
+// bitValue the integer value of a BIT column delivered as big endian bytes
+func bitValue(col interface{}) uint64 {
+	bs, ok := col.([]byte)
+	if !ok {
+		return 0
+	}
+	var v uint64
+	for _, b := range bs {
+		v = v<<8 | uint64(b)
+	}
+	return v
+}
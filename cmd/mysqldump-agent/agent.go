@@ -0,0 +1,326 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"mysqldump"
+)
+
+// runHistory runs kept per job for the status endpoints
+const runHistory = 20
+
+const (
+	stateRunning   = "running"
+	stateSucceeded = "succeeded"
+	stateFailed    = "failed"
+	stateCanceled  = "canceled"
+)
+
+type Run struct {
+	ID       string     `json:"id"`
+	Job      string     `json:"job"`
+	Artifact string     `json:"artifact"`
+	State    string     `json:"state"`
+	Error    string     `json:"error,omitempty"`
+	Warnings []string   `json:"warnings,omitempty"`
+	Started  time.Time  `json:"started"`
+	Ended    *time.Time `json:"ended,omitempty"`
+
+	cancel context.CancelFunc
+}
+
+type job struct {
+	config  JobConfig
+	every   time.Duration
+	backend Backend
+	runs    []*Run
+}
+
+type agent struct {
+	mu     sync.Mutex
+	jobs   map[string]*job
+	order  []string
+	runs   map[string]*Run
+	nextID int
+}
+
+func newAgent(config *Config) (*agent, error) {
+	a := &agent{
+		jobs: make(map[string]*job),
+		runs: make(map[string]*Run),
+	}
+	for _, jobConfig := range config.Jobs {
+		if jobConfig.Name == "" || jobConfig.DSN == "" {
+			return nil, errors.New("a job needs a name and a dsn")
+		}
+		if _, ok := a.jobs[jobConfig.Name]; ok {
+			return nil, fmt.Errorf("duplicate job %s", jobConfig.Name)
+		}
+
+		j := &job{config: jobConfig}
+		if jobConfig.Every != "" {
+			every, err := time.ParseDuration(jobConfig.Every)
+			if err != nil {
+				return nil, fmt.Errorf("job %s: %v", jobConfig.Name, err)
+			}
+			j.every = every
+		}
+		backend, err := newBackend(jobConfig.Backend)
+		if err != nil {
+			return nil, fmt.Errorf("job %s: %v", jobConfig.Name, err)
+		}
+		j.backend = backend
+
+		a.jobs[jobConfig.Name] = j
+		a.order = append(a.order, jobConfig.Name)
+	}
+	return a, nil
+}
+
+func (a *agent) startSchedules() {
+	for _, name := range a.order {
+		j := a.jobs[name]
+		if j.every <= 0 {
+			continue
+		}
+		go func(name string, every time.Duration) {
+			for range time.Tick(every) {
+				_, err := a.trigger(name)
+				if err != nil {
+					log.Printf("[warn] [agent] scheduled run of %s: %v \n", name, err)
+				}
+			}
+		}(name, j.every)
+	}
+}
+
+// errRunning a run of the job is in progress
+var errRunning = errors.New("a run of the job is in progress")
+
+// trigger start a run of the job
+func (a *agent) trigger(name string) (*Run, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	j, ok := a.jobs[name]
+	if !ok {
+		return nil, fmt.Errorf("job %s not found", name)
+	}
+	if len(j.runs) > 0 && j.runs[len(j.runs)-1].State == stateRunning {
+		return nil, errRunning
+	}
+
+	a.nextID++
+	ctx, cancel := context.WithCancel(context.Background())
+	started := time.Now().UTC()
+	run := &Run{
+		ID:       fmt.Sprintf("%d", a.nextID),
+		Job:      name,
+		Artifact: fmt.Sprintf("%s-%s.sql", name, started.Format("20060102T150405Z")),
+		State:    stateRunning,
+		Started:  started,
+		cancel:   cancel,
+	}
+	a.runs[run.ID] = run
+	j.runs = append(j.runs, run)
+	if len(j.runs) > runHistory {
+		delete(a.runs, j.runs[0].ID)
+		j.runs = j.runs[1:]
+	}
+
+	go a.execute(ctx, j, run)
+	return run, nil
+}
+
+func (a *agent) execute(ctx context.Context, j *job, run *Run) {
+	log.Printf("[info] [agent] run %s of %s started\n", run.ID, run.Job)
+
+	var result mysqldump.DumpResult
+	err := dumpTo(ctx, j, run.Artifact, &result)
+	if err == nil {
+		err = applyRetention(j.backend, j.config.Name, j.config.Keep)
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	ended := time.Now().UTC()
+	run.Ended = &ended
+	run.Warnings = result.Warnings
+	switch {
+	case err == nil:
+		run.State = stateSucceeded
+	case ctx.Err() != nil:
+		run.State = stateCanceled
+	default:
+		run.State = stateFailed
+		run.Error = err.Error()
+	}
+	run.cancel()
+	log.Printf("[info] [agent] run %s of %s %s\n", run.ID, run.Job, run.State)
+}
+
+// dumpTo stream a dump of the job into its backend as name
+func dumpTo(ctx context.Context, j *job, name string, result *mysqldump.DumpResult) error {
+	reader, err := mysqldump.DumpReader(j.config.DSN, dumpOptions(j.config, result)...)
+	if err != nil {
+		return err
+	}
+	stop := context.AfterFunc(ctx, func() {
+		_ = reader.Close()
+	})
+	defer func() {
+		stop()
+		_ = reader.Close()
+	}()
+
+	err = j.backend.Put(name, reader)
+	if err != nil && ctx.Err() != nil {
+		return ctx.Err()
+	}
+	return err
+}
+
+func dumpOptions(config JobConfig, result *mysqldump.DumpResult) []mysqldump.DumpOption {
+	opts := []mysqldump.DumpOption{mysqldump.WithDumpResult(result)}
+	if len(config.Databases) > 0 {
+		opts = append(opts, mysqldump.WithDBs(config.Databases...))
+	} else if config.AllDatabases {
+		opts = append(opts, mysqldump.WithAllDatabases())
+	}
+	if len(config.Tables) > 0 {
+		opts = append(opts, mysqldump.WithTables(config.Tables...))
+	} else {
+		opts = append(opts, mysqldump.WithAllTables())
+	}
+	if config.Schema {
+		opts = append(opts, mysqldump.WithDumpTable())
+	}
+	if config.Data {
+		opts = append(opts, mysqldump.WithData())
+	}
+	if config.DropTable {
+		opts = append(opts, mysqldump.WithDropTable())
+	}
+	if config.Triggers {
+		opts = append(opts, mysqldump.WithTriggers())
+	}
+	if config.Routines {
+		opts = append(opts, mysqldump.WithRoutines())
+	}
+	if config.Where != "" {
+		opts = append(opts, mysqldump.WithWhere(config.Where))
+	}
+	return opts
+}
+
+func (a *agent) handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/jobs", a.handleJobs)
+	mux.HandleFunc("/jobs/", a.handleTrigger)
+	mux.HandleFunc("/runs/", a.handleRun)
+	return mux
+}
+
+type jobStatus struct {
+	Name  string `json:"name"`
+	Every string `json:"every,omitempty"`
+	Runs  []Run  `json:"runs"`
+}
+
+func (a *agent) handleJobs(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	a.mu.Lock()
+	statuses := make([]jobStatus, 0, len(a.order))
+	for _, name := range a.order {
+		j := a.jobs[name]
+		status := jobStatus{Name: name, Every: j.config.Every, Runs: make([]Run, 0, len(j.runs))}
+		for _, run := range j.runs {
+			status.Runs = append(status.Runs, *run)
+		}
+		statuses = append(statuses, status)
+	}
+	a.mu.Unlock()
+
+	writeJSON(w, http.StatusOK, statuses)
+}
+
+// handleTrigger POST /jobs/{name}/trigger
+func (a *agent) handleTrigger(w http.ResponseWriter, r *http.Request) {
+	name, ok := strings.CutSuffix(strings.TrimPrefix(r.URL.Path, "/jobs/"), "/trigger")
+	if !ok || name == "" || strings.Contains(name, "/") {
+		http.NotFound(w, r)
+		return
+	}
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	run, err := a.trigger(name)
+	switch {
+	case errors.Is(err, errRunning):
+		http.Error(w, err.Error(), http.StatusConflict)
+	case err != nil:
+		http.Error(w, err.Error(), http.StatusNotFound)
+	default:
+		a.mu.Lock()
+		snapshot := *run
+		a.mu.Unlock()
+		writeJSON(w, http.StatusAccepted, snapshot)
+	}
+}
+
+// handleRun GET /runs/{id} and POST /runs/{id}/cancel
+func (a *agent) handleRun(w http.ResponseWriter, r *http.Request) {
+	id, cancel := strings.CutSuffix(strings.TrimPrefix(r.URL.Path, "/runs/"), "/cancel")
+	if id == "" || strings.Contains(id, "/") {
+		http.NotFound(w, r)
+		return
+	}
+	if cancel && r.Method != http.MethodPost || !cancel && r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	a.mu.Lock()
+	run, ok := a.runs[id]
+	var snapshot Run
+	if ok {
+		if cancel {
+			run.cancel()
+		}
+		snapshot = *run
+	}
+	a.mu.Unlock()
+
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	if cancel {
+		writeJSON(w, http.StatusAccepted, snapshot)
+		return
+	}
+	writeJSON(w, http.StatusOK, snapshot)
+}
+
+func writeJSON(w http.ResponseWriter, code int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	err := json.NewEncoder(w).Encode(v)
+	if err != nil {
+		log.Printf("[warn] [agent] %v \n", err)
+	}
+}
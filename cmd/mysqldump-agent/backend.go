@@ -0,0 +1,163 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Backend the storage of the dumps
+type Backend interface {
+	Put(name string, r io.Reader) error
+	// List the names of the stored dumps
+	List() ([]string, error)
+	Delete(name string) error
+}
+
+func newBackend(config BackendConfig) (Backend, error) {
+	switch config.Type {
+	case "dir":
+		err := os.MkdirAll(config.Path, 0o755)
+		if err != nil {
+			return nil, err
+		}
+		return dirBackend(config.Path), nil
+	case "http":
+		return &httpBackend{url: strings.TrimSuffix(config.URL, "/"), headers: config.Headers}, nil
+	default:
+		return nil, fmt.Errorf("unknown backend type %q", config.Type)
+	}
+}
+
+// dirBackend a local directory, the dump is written to a temporary file renamed once complete
+type dirBackend string
+
+func (d dirBackend) Put(name string, r io.Reader) error {
+	tmp, err := os.CreateTemp(string(d), "."+name+".*")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = os.Remove(tmp.Name())
+	}()
+
+	_, err = io.Copy(tmp, r)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), filepath.Join(string(d), name))
+}
+
+func (d dirBackend) List() ([]string, error) {
+	entries, err := os.ReadDir(string(d))
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, entry := range entries {
+		if !entry.IsDir() && !strings.HasPrefix(entry.Name(), ".") {
+			names = append(names, entry.Name())
+		}
+	}
+	return names, nil
+}
+
+func (d dirBackend) Delete(name string) error {
+	return os.Remove(filepath.Join(string(d), name))
+}
+
+// httpBackend PUT and DELETE on a base url, eg: a WebDAV server or a presigning proxy.
+// Listing is not part of plain HTTP, List returns the dumps put by this process.
+type httpBackend struct {
+	url     string
+	headers map[string]string
+
+	mu   sync.Mutex
+	puts []string
+}
+
+func (h *httpBackend) do(method, name string, body io.Reader) error {
+	req, err := http.NewRequest(method, h.url+"/"+name, body)
+	if err != nil {
+		return err
+	}
+	for key, value := range h.headers {
+		req.Header.Set(key, value)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("%s %s: %s", method, req.URL, resp.Status)
+	}
+	return nil
+}
+
+func (h *httpBackend) Put(name string, r io.Reader) error {
+	err := h.do(http.MethodPut, name, r)
+	if err != nil {
+		return err
+	}
+	h.mu.Lock()
+	h.puts = append(h.puts, name)
+	h.mu.Unlock()
+	return nil
+}
+
+func (h *httpBackend) List() ([]string, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return append([]string(nil), h.puts...), nil
+}
+
+func (h *httpBackend) Delete(name string) error {
+	err := h.do(http.MethodDelete, name, nil)
+	if err != nil {
+		return err
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for i, put := range h.puts {
+		if put == name {
+			h.puts = append(h.puts[:i], h.puts[i+1:]...)
+			break
+		}
+	}
+	return nil
+}
+
+// applyRetention delete the oldest dumps of job beyond keep, the names sort by time
+func applyRetention(backend Backend, job string, keep int) error {
+	if keep <= 0 {
+		return nil
+	}
+	names, err := backend.List()
+	if err != nil {
+		return err
+	}
+	var dumps []string
+	for _, name := range names {
+		if strings.HasPrefix(name, job+"-") && strings.HasSuffix(name, ".sql") {
+			dumps = append(dumps, name)
+		}
+	}
+	sort.Strings(dumps)
+	for len(dumps) > keep {
+		err = backend.Delete(dumps[0])
+		if err != nil {
+			return err
+		}
+		dumps = dumps[1:]
+	}
+	return nil
+}
@@ -0,0 +1,100 @@
+// Command mysqldump-agent a resident backup agent: it dumps the configured jobs on their
+// schedule, pushes the dumps to a backend, keeps the last ones and exposes a REST control
+// plane:
+//
+//	GET  /jobs                  the jobs and their last runs
+//	POST /jobs/{name}/trigger   start a run of the job now
+//	GET  /runs/{id}             the state of a run
+//	POST /runs/{id}/cancel      abort a running run
+//
+// Usage: mysqldump-agent -config agent.json, eg:
+//
+//	{
+//		"listen": ":8080",
+//		"jobs": [{
+//			"name": "shop",
+//			"dsn": "user:password@tcp(127.0.0.1:3306)/shop",
+//			"schema": true,
+//			"data": true,
+//			"every": "24h",
+//			"keep": 7,
+//			"backend": {"type": "dir", "path": "/var/backups/shop"}
+//		}]
+//	}
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"log"
+	"net/http"
+	"os"
+)
+
+func main() {
+	configPath := flag.String("config", "agent.json", "the configuration file")
+	flag.Parse()
+
+	config, err := loadConfig(*configPath)
+	if err != nil {
+		log.Fatalf("[error] %v \n", err)
+	}
+
+	agent, err := newAgent(config)
+	if err != nil {
+		log.Fatalf("[error] %v \n", err)
+	}
+	agent.startSchedules()
+
+	log.Printf("[info] [agent] listen on %s\n", config.Listen)
+	log.Fatalf("[error] %v \n", http.ListenAndServe(config.Listen, agent.handler()))
+}
+
+type Config struct {
+	// Listen the address of the REST control plane, ":8080" by default
+	Listen string      `json:"listen"`
+	Jobs   []JobConfig `json:"jobs"`
+}
+
+type JobConfig struct {
+	Name         string   `json:"name"`
+	DSN          string   `json:"dsn"`
+	Databases    []string `json:"databases"`
+	AllDatabases bool     `json:"all_databases"`
+	Tables       []string `json:"tables"`
+	Schema       bool     `json:"schema"`
+	Data         bool     `json:"data"`
+	DropTable    bool     `json:"drop_table"`
+	Triggers     bool     `json:"triggers"`
+	Routines     bool     `json:"routines"`
+	Where        string   `json:"where"`
+	// Every the period of the scheduled runs as a Go duration, eg: "6h", no schedule when empty
+	Every string `json:"every"`
+	// Keep the number of dumps kept in the backend, all when 0
+	Keep    int           `json:"keep"`
+	Backend BackendConfig `json:"backend"`
+}
+
+type BackendConfig struct {
+	// Type "dir" or "http"
+	Type string `json:"type"`
+	// Path the directory of "dir"
+	Path string `json:"path"`
+	// URL the base url of "http", the dumps are PUT to URL/name and deleted with DELETE
+	URL string `json:"url"`
+	// Headers added to the requests of "http", eg: Authorization
+	Headers map[string]string `json:"headers"`
+}
+
+func loadConfig(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	config := &Config{Listen: ":8080"}
+	err = json.Unmarshal(data, config)
+	if err != nil {
+		return nil, err
+	}
+	return config, nil
+}
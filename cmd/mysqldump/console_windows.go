@@ -0,0 +1,11 @@
+package main
+
+import "syscall"
+
+// the dump and the logs are UTF-8, the console of Windows uses the code page of the
+// locale by default
+func init() {
+	const codePageUTF8 = 65001
+	kernel32 := syscall.NewLazyDLL("kernel32.dll")
+	_, _, _ = kernel32.NewProc("SetConsoleOutputCP").Call(codePageUTF8)
+}
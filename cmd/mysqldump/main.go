@@ -0,0 +1,243 @@
+// Command mysqldump dump a database to stdout, eg:
+//
+//	mysqldump -dsn 'user:password@tcp(127.0.0.1:3306)/shop' -data -status-file status.json > shop.sql
+//
+// -result-file writes the dump to a file instead, only once the dump succeeded, and the
+// bytes are written as is: on Windows the redirection of PowerShell re-encodes the dump.
+//
+// The exit code tells wrappers and Kubernetes jobs what happened, -status-file and -json
+// write the same result as a JSON object:
+//
+//	0 success
+//	1 failure
+//	2 usage error
+//	3 connection failure
+//	4 partial success, objects were skipped with -best-effort
+//	5 verification failure, see -verify
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"mysqldump"
+)
+
+const (
+	exitSuccess            = 0
+	exitFailure            = 1
+	exitUsage              = 2
+	exitConnectionFailure  = 3
+	exitPartialSuccess     = 4
+	exitVerificationFailed = 5
+)
+
+var statuses = map[int]string{
+	exitSuccess:            "success",
+	exitFailure:            "failure",
+	exitUsage:              "usage_error",
+	exitConnectionFailure:  "connection_failure",
+	exitPartialSuccess:     "partial_success",
+	exitVerificationFailed: "verification_failure",
+}
+
+// Status the JSON result of a run
+type Status struct {
+	Status         string                      `json:"status"`
+	ExitCode       int                         `json:"exit_code"`
+	Error          string                      `json:"error,omitempty"`
+	Started        time.Time                   `json:"started"`
+	Ended          time.Time                   `json:"ended"`
+	DurationMs     int64                       `json:"duration_ms"`
+	Bytes          int64                       `json:"bytes"`
+	Warnings       []string                    `json:"warnings,omitempty"`
+	BinlogPosition *mysqldump.BinlogPosition   `json:"binlog_position,omitempty"`
+	Differences    []mysqldump.TableComparison `json:"differences,omitempty"`
+}
+
+type flags struct {
+	dsn          string
+	databases    string
+	allDatabases bool
+	tables       string
+	schema       bool
+	data         bool
+	dropTable    bool
+	triggers     bool
+	routines     bool
+	where        string
+	bestEffort   bool
+	flushLogs    bool
+	verify       bool
+	statusFile   string
+	resultFile   string
+	json         bool
+}
+
+func main() {
+	var f flags
+	flag.StringVar(&f.dsn, "dsn", "", "the dsn of the database, required")
+	flag.StringVar(&f.databases, "databases", "", "comma separated databases, the database of the dsn by default")
+	flag.BoolVar(&f.allDatabases, "all-databases", false, "dump all databases")
+	flag.StringVar(&f.tables, "tables", "", "comma separated tables, all tables by default")
+	flag.BoolVar(&f.schema, "schema", true, "dump CREATE TABLE statements")
+	flag.BoolVar(&f.data, "data", false, "dump the rows")
+	flag.BoolVar(&f.dropTable, "drop-table", false, "add DROP TABLE statements")
+	flag.BoolVar(&f.triggers, "triggers", false, "dump triggers")
+	flag.BoolVar(&f.routines, "routines", false, "dump procedures and functions")
+	flag.StringVar(&f.where, "where", "", "WHERE condition of the rows")
+	flag.BoolVar(&f.bestEffort, "best-effort", false, "skip the objects that can not be read, exit with 4")
+	flag.BoolVar(&f.flushLogs, "flush-logs", false, "rotate the binlog and record the position the dump is consistent with")
+	flag.BoolVar(&f.verify, "verify", false, "restore the dump into a scratch database and compare the tables, exit with 5 on differences")
+	flag.StringVar(&f.statusFile, "status-file", "", "write the JSON result to this file")
+	flag.StringVar(&f.resultFile, "result-file", "", "write the dump to this file instead of stdout, replaced only by a successful dump")
+	flag.BoolVar(&f.json, "json", false, "write the JSON result to stderr")
+	flag.Parse()
+
+	status := run(f, os.Stdout)
+	status.Status = statuses[status.ExitCode]
+
+	if f.statusFile != "" {
+		err := writeStatusFile(f.statusFile, status)
+		if err != nil {
+			log.Printf("[error] %v \n", err)
+		}
+	}
+	if f.json {
+		_ = json.NewEncoder(os.Stderr).Encode(status)
+	}
+	os.Exit(status.ExitCode)
+}
+
+func run(f flags, stdout io.Writer) (status Status) {
+	status.Started = time.Now().UTC()
+	defer func() {
+		status.Ended = time.Now().UTC()
+		status.DurationMs = status.Ended.Sub(status.Started).Milliseconds()
+	}()
+	fail := func(code int, err error) Status {
+		status.ExitCode = code
+		status.Error = err.Error()
+		return status
+	}
+
+	if f.dsn == "" || flag.NArg() > 0 {
+		flag.Usage()
+		return fail(exitUsage, errors.New("-dsn is required and no argument is expected"))
+	}
+
+	// tell a connection failure from a dump failure
+	db, err := sql.Open("mysql", f.dsn)
+	if err != nil {
+		return fail(exitUsage, err)
+	}
+	err = db.Ping()
+	_ = db.Close()
+	if err != nil {
+		return fail(exitConnectionFailure, err)
+	}
+
+	var result mysqldump.DumpResult
+	opts := dumpOptions(f)
+	counter := &countingWriter{}
+	output := mysqldump.WithWriter(stdout)
+	if f.resultFile != "" {
+		output = mysqldump.WithOutputFile(f.resultFile)
+	}
+	err = mysqldump.Dump(f.dsn, append(opts, mysqldump.WithDumpResult(&result), output,
+		mysqldump.WithWriterMiddleware(counter.wrap))...)
+	status.Bytes = counter.n
+	status.Warnings = result.Warnings
+	status.BinlogPosition = result.BinlogPosition
+	if err != nil {
+		return fail(exitFailure, err)
+	}
+
+	if f.verify {
+		report, err := mysqldump.RoundTripVerify(f.dsn, opts...)
+		if err != nil {
+			return fail(exitVerificationFailed, err)
+		}
+		if status.Differences = report.Differences(); len(status.Differences) > 0 {
+			return fail(exitVerificationFailed, fmt.Errorf("%d tables differ after a round trip", len(status.Differences)))
+		}
+	}
+
+	if len(result.Warnings) > 0 {
+		status.ExitCode = exitPartialSuccess
+	}
+	return status
+}
+
+func dumpOptions(f flags) []mysqldump.DumpOption {
+	var opts []mysqldump.DumpOption
+	if f.databases != "" {
+		opts = append(opts, mysqldump.WithDBs(strings.Split(f.databases, ",")...))
+	} else if f.allDatabases {
+		opts = append(opts, mysqldump.WithAllDatabases())
+	}
+	if f.tables != "" {
+		opts = append(opts, mysqldump.WithTables(strings.Split(f.tables, ",")...))
+	} else {
+		opts = append(opts, mysqldump.WithAllTables())
+	}
+	if f.schema {
+		opts = append(opts, mysqldump.WithDumpTable())
+	}
+	if f.data {
+		opts = append(opts, mysqldump.WithData())
+	}
+	if f.dropTable {
+		opts = append(opts, mysqldump.WithDropTable())
+	}
+	if f.triggers {
+		opts = append(opts, mysqldump.WithTriggers())
+	}
+	if f.routines {
+		opts = append(opts, mysqldump.WithRoutines())
+	}
+	if f.where != "" {
+		opts = append(opts, mysqldump.WithWhere(f.where))
+	}
+	if f.bestEffort {
+		opts = append(opts, mysqldump.WithBestEffort())
+	}
+	if f.flushLogs {
+		opts = append(opts, mysqldump.WithFlushLogs())
+	}
+	return opts
+}
+
+func writeStatusFile(path string, status Status) error {
+	data, err := json.MarshalIndent(status, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.FromSlash(path), append(data, '\n'), 0o644)
+}
+
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+// wrap count the bytes written to w
+func (c *countingWriter) wrap(w io.Writer) io.Writer {
+	c.w = w
+	return c
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
@@ -0,0 +1,127 @@
+package mysqldump
+
+import (
+	"log"
+
+	"mysqldump/sqlutil"
+)
+
+// WithColumnMap load the columns of table into other columns, eg: an older dump into a
+// table whose columns were renamed or removed since: {"name": "full_name", "fax": ""}
+// loads name into full_name and drops fax. The INSERT and REPLACE statements of table
+// get a column list, the columns of the dump are read from its CREATE TABLE statement,
+// which is left as is. ON DUPLICATE KEY UPDATE clauses are not rewritten. table is the
+// name in the dump, before WithTableMap.
+func WithColumnMap(table string, columns map[string]string) SourceOption {
+	mapping := make(map[string]string, len(columns))
+	for from, to := range columns {
+		mapping[from] = to
+	}
+	return func(o *sourceOption) {
+		if o.columnMaps == nil {
+			o.columnMaps = make(map[string]map[string]string)
+		}
+		o.columnMaps[table] = mapping
+	}
+}
+
+// recordDumpColumns the columns of the tables of the dump, from their CREATE TABLE statements
+func (o *sourceOption) recordDumpColumns(dml string) {
+	if sqlutil.StatementVerb(dml) != "CREATE" {
+		return
+	}
+	table := sqlutil.StatementTable(dml)
+	if table == "" {
+		return
+	}
+	if o.dumpColumns == nil {
+		o.dumpColumns = make(map[string][]string)
+	}
+	o.dumpColumns[table] = sqlutil.CreateTableColumns(dml)
+}
+
+// insertValues the parts of an INSERT or REPLACE statement of table with its column list,
+// from the CREATE TABLE of the dump when the statement has none, nil if it is not known
+func (o *sourceOption) insertValues(dml, table string) *sqlutil.InsertValues {
+	values, err := o.syntax().SplitInsertValues(dml)
+	if err != nil {
+		return nil
+	}
+	if values.Columns == nil {
+		values.Columns = o.dumpColumns[table]
+	}
+	if len(values.Columns) == 0 {
+		return nil
+	}
+	for _, row := range values.Rows {
+		if len(row) != len(values.Columns) {
+			return nil
+		}
+	}
+	return values
+}
+
+// mapColumns rewrite the INSERT and REPLACE statements of the tables of WithColumnMap and
+// WithValueRewriter, and with WithSchemaReconciliation give them the column list of the dump
+func (o *sourceOption) mapColumns(dml string) string {
+	reconcile := o.reconcile && o.shadow == nil && o.targetTableLoader != nil
+	if len(o.columnMaps) == 0 && len(o.valueRewriters) == 0 && !reconcile {
+		return dml
+	}
+	o.recordDumpColumns(dml)
+
+	verb := sqlutil.StatementVerb(dml)
+	if verb != "INSERT" && verb != "REPLACE" {
+		return dml
+	}
+	table := sqlutil.StatementTable(dml)
+	mapping, ok := o.columnMaps[table]
+	rewriters := o.valueRewriters[table]
+	if !ok && len(rewriters) == 0 && !reconcile {
+		return dml
+	}
+
+	values := o.insertValues(dml, table)
+	if values == nil {
+		log.Printf("[warn] columns of %s unknown, the statement is not rewritten\n", table)
+		return dml
+	}
+	rewriteValues(values, rewriters, o.syntax())
+
+	var keep []int
+	var columns []string
+	for i, column := range values.Columns {
+		target, mapped := mapping[column]
+		if mapped && target == "" {
+			continue
+		}
+		if mapped {
+			column = target
+		}
+		keep = append(keep, i)
+		columns = append(columns, column)
+	}
+
+	values.Columns = columns
+	for r, row := range values.Rows {
+		kept := make([]string, len(keep))
+		for i, idx := range keep {
+			kept[i] = row[idx]
+		}
+		values.Rows[r] = kept
+	}
+
+	reconciled := reconcile && o.reconcileColumns(o.targetTableName(table), columns)
+	if !ok && len(rewriters) == 0 && !reconciled {
+		return dml
+	}
+	return values.String()
+}
+
+// targetTableName the name of table of the dump in the target database
+func (o *sourceOption) targetTableName(table string) string {
+	if target, ok := o.tableMap[table]; ok {
+		return target
+	}
+	return table
+}
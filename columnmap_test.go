@@ -0,0 +1,31 @@
+package mysqldump
+
+import "testing"
+
+func TestMapColumns(t *testing.T) {
+	o := &sourceOption{}
+	WithColumnMap("order", map[string]string{"name": "full_name", "fax": "", "a`b": "c`d"})(o)
+
+	tests := []struct {
+		dml  string
+		want string
+	}{
+		// the columns of the dump are known from its CREATE TABLE
+		{"CREATE TABLE `order` (\n  `id` int NOT NULL,\n  `name` varchar(10),\n  `fax` varchar(10),\n  `a``b` int\n)",
+			"CREATE TABLE `order` (\n  `id` int NOT NULL,\n  `name` varchar(10),\n  `fax` varchar(10),\n  `a``b` int\n)"},
+		{"INSERT INTO `order` VALUES (1,'it''s','555',1),(2,'a,b',NULL,2)",
+			"INSERT INTO `order` (`id`,`full_name`,`c``d`) VALUES (1,'it''s',1),(2,'a,b',2)"},
+		{"REPLACE INTO `order` (`fax`,`id`) VALUES ('555',1),('556',2)",
+			"REPLACE INTO `order` (`id`) VALUES (1),(2)"},
+		{"INSERT INTO `order` (`name`) VALUES ('x') ON DUPLICATE KEY UPDATE `name` = 'y'",
+			"INSERT INTO `order` (`full_name`) VALUES ('x') ON DUPLICATE KEY UPDATE `name` = 'y'"},
+		{"INSERT INTO `other` VALUES (1,'name')", "INSERT INTO `other` VALUES (1,'name')"},
+		// a row that does not match the columns leaves the statement as is
+		{"INSERT INTO `order` VALUES (1,'a')", "INSERT INTO `order` VALUES (1,'a')"},
+	}
+	for _, test := range tests {
+		if got := o.mapColumns(test.dml); got != test.want {
+			t.Errorf("mapColumns(%s) = %s, want %s", test.dml, got, test.want)
+		}
+	}
+}
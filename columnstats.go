@@ -0,0 +1,143 @@
+package mysqldump
+
+import (
+	"database/sql"
+	"hash/maphash"
+	"math"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// distinctSketchSize hashes kept to estimate the distinct values of a column (k minimum
+// values), the estimate is within about 6% for k = 256
+const distinctSketchSize = 256
+
+// ColumnStats the summary of the dumped values of a column
+type ColumnStats struct {
+	Column string `json:"column"`
+	Type   string `json:"type"`
+	Rows   int64  `json:"rows"`
+	Nulls  int64  `json:"nulls"`
+	// Min and Max as text, numbers compare by value, binary columns have none
+	Min string `json:"min,omitempty"`
+	Max string `json:"max,omitempty"`
+	// DistinctEstimate the estimated number of distinct non-NULL values
+	DistinctEstimate int64 `json:"distinct_estimate"`
+}
+
+// WithColumnStats record the ColumnStats of the dumped rows of each table into the
+// Manifest of WithManifest, keyed "db.table", eg: to monitor the data quality with the
+// scan of the backup
+func WithColumnStats() DumpOption {
+	return func(option *dumpOption) {
+		option.isColumnStats = true
+	}
+}
+
+// columnStatsCollector the stats of one table while its rows are dumped
+type columnStatsCollector struct {
+	seed     maphash.Seed
+	stats    []ColumnStats
+	numeric  []bool
+	minValue []float64
+	maxValue []float64
+	sketches [][]uint64
+}
+
+func newColumnStatsCollector(columnTypes []*sql.ColumnType) *columnStatsCollector {
+	c := &columnStatsCollector{
+		seed:     maphash.MakeSeed(),
+		stats:    make([]ColumnStats, len(columnTypes)),
+		numeric:  make([]bool, len(columnTypes)),
+		minValue: make([]float64, len(columnTypes)),
+		maxValue: make([]float64, len(columnTypes)),
+		sketches: make([][]uint64, len(columnTypes)),
+	}
+	for i, columnType := range columnTypes {
+		c.stats[i].Column = columnType.Name()
+		c.stats[i].Type = columnType.DatabaseTypeName()
+		switch goType(columnType) {
+		case "int64", "uint64", "float64":
+			c.numeric[i] = true
+		default:
+			c.numeric[i] = isDecimalType(columnType.DatabaseTypeName())
+		}
+	}
+	return c
+}
+
+func (c *columnStatsCollector) observe(row []interface{}) {
+	for i, col := range row {
+		stats := &c.stats[i]
+		stats.Rows++
+		if col == nil {
+			stats.Nulls++
+			continue
+		}
+
+		var text string
+		if t, ok := col.(time.Time); ok {
+			text = t.Format("2006-01-02 15:04:05.999999")
+		} else {
+			text = rawString(col)
+		}
+		c.addDistinct(i, text)
+
+		binary := isBinaryType(normalizeTypeName(stats.Type))
+		first := stats.Rows-stats.Nulls == 1
+		switch {
+		case binary:
+		case c.numeric[i]:
+			v, err := strconv.ParseFloat(text, 64)
+			if err != nil {
+				continue
+			}
+			if first || v < c.minValue[i] {
+				c.minValue[i], stats.Min = v, text
+			}
+			if first || v > c.maxValue[i] {
+				c.maxValue[i], stats.Max = v, text
+			}
+		default:
+			if first || text < stats.Min {
+				stats.Min = text
+			}
+			if first || text > stats.Max {
+				stats.Max = text
+			}
+		}
+	}
+}
+
+// addDistinct keep the distinctSketchSize smallest hashes of the values of column i
+func (c *columnStatsCollector) addDistinct(i int, text string) {
+	h := maphash.String(c.seed, text)
+	sketch := c.sketches[i]
+	if len(sketch) == distinctSketchSize && h >= sketch[len(sketch)-1] {
+		return
+	}
+	idx := sort.Search(len(sketch), func(j int) bool { return sketch[j] >= h })
+	if idx < len(sketch) && sketch[idx] == h {
+		return
+	}
+	if len(sketch) < distinctSketchSize {
+		sketch = append(sketch, 0)
+	}
+	copy(sketch[idx+1:], sketch[idx:])
+	sketch[idx] = h
+	c.sketches[i] = sketch
+}
+
+func (c *columnStatsCollector) result() []ColumnStats {
+	for i := range c.stats {
+		sketch := c.sketches[i]
+		if len(sketch) < distinctSketchSize {
+			c.stats[i].DistinctEstimate = int64(len(sketch))
+			continue
+		}
+		kth := float64(sketch[len(sketch)-1]) / math.MaxUint64
+		c.stats[i].DistinctEstimate = int64(float64(distinctSketchSize-1) / kth)
+	}
+	return c.stats
+}
@@ -0,0 +1,191 @@
+package mysqldump
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"runtime"
+	"sync"
+)
+
+// gzipBlockSize input bytes per gzip member when compressing in parallel
+const gzipBlockSize = 1 << 20
+
+// CompressionOptions the tuning of a codec, zero values are the codec defaults
+type CompressionOptions struct {
+	// Level eg: 1 (fast) to 9 (small) for gzip, 1 to 22 for zstd
+	Level int
+	// Workers parallel compression goroutines, eg: for gzip or pgzip
+	Workers int
+	// WindowSize the match window in bytes, eg: for zstd
+	WindowSize int
+}
+
+// Codec a compression format, see RegisterCodec
+type Codec struct {
+	NewWriter func(w io.Writer, options CompressionOptions) (io.WriteCloser, error)
+	NewReader func(r io.Reader) (io.ReadCloser, error)
+}
+
+var (
+	codecsMu sync.RWMutex
+	codecs   = map[string]Codec{
+		"gzip": {NewWriter: newGzipWriter, NewReader: newGzipReader},
+	}
+)
+
+// RegisterCodec make a compression format available to WithCompression under name, eg: "zstd"
+// with github.com/klauspost/compress/zstd, honoring the level and window of the options.
+// gzip is built in. It replaces a previous codec of name and is safe for concurrent use.
+func RegisterCodec(name string, codec Codec) {
+	codecsMu.Lock()
+	defer codecsMu.Unlock()
+	codecs[name] = codec
+}
+
+func getCodec(name string) (Codec, error) {
+	codecsMu.RLock()
+	defer codecsMu.RUnlock()
+	codec, ok := codecs[name]
+	if !ok {
+		return Codec{}, fmt.Errorf("unknown compression codec %s, see RegisterCodec", name)
+	}
+	return codec, nil
+}
+
+// WithCompression compress the dump with codec, eg: "gzip", load it with WithSourceCompression
+func WithCompression(codec string) DumpOption {
+	return func(option *dumpOption) {
+		option.compression = codec
+	}
+}
+
+// WithCompressionLevel the level of WithCompression, eg: 1 to keep up with a fast dump
+func WithCompressionLevel(level int) DumpOption {
+	return func(option *dumpOption) {
+		option.compressionOptions.Level = level
+	}
+}
+
+// WithCompressionWorkers compress on n goroutines, 0 is one per CPU, eg: for many-core
+// machines where a single gzip stream is slower than the dump. Parallel gzip writes one
+// gzip member per MiB, any gzip reader decompresses it.
+func WithCompressionWorkers(n int) DumpOption {
+	return func(option *dumpOption) {
+		if n <= 0 {
+			n = runtime.NumCPU()
+		}
+		option.compressionOptions.Workers = n
+	}
+}
+
+// WithCompressionWindow the window size of codecs having one, eg: zstd
+func WithCompressionWindow(size int) DumpOption {
+	return func(option *dumpOption) {
+		option.compressionOptions.WindowSize = size
+	}
+}
+
+// WithSourceCompression decompress a dump written with WithCompression(codec)
+func WithSourceCompression(codec string) SourceOption {
+	return func(o *sourceOption) {
+		o.compression = codec
+	}
+}
+
+func newGzipReader(r io.Reader) (io.ReadCloser, error) {
+	return gzip.NewReader(r)
+}
+
+func newGzipWriter(w io.Writer, options CompressionOptions) (io.WriteCloser, error) {
+	level := options.Level
+	if level == 0 {
+		level = gzip.DefaultCompression
+	}
+	if options.Workers <= 1 {
+		return gzip.NewWriterLevel(w, level)
+	}
+	// fail on an invalid level now rather than in a worker
+	_, err := gzip.NewWriterLevel(io.Discard, level)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &parallelGzipWriter{
+		w:       w,
+		level:   level,
+		pending: make(chan chan []byte, options.Workers),
+		done:    make(chan error, 1),
+	}
+	go p.writeMembers()
+	return p, nil
+}
+
+// parallelGzipWriter compress blocks concurrently into gzip members written in order,
+// concatenated members are a valid gzip stream
+type parallelGzipWriter struct {
+	w     io.Writer
+	level int
+	buf   []byte
+	// pending the results of the blocks in order, its capacity bounds the blocks in flight
+	pending chan chan []byte
+	done    chan error
+	members int
+	err     error
+	closed  bool
+}
+
+func (p *parallelGzipWriter) Write(data []byte) (int, error) {
+	if p.closed {
+		return 0, errors.New("write to a closed gzip writer")
+	}
+	p.buf = append(p.buf, data...)
+	for len(p.buf) >= gzipBlockSize {
+		p.compress(p.buf[:gzipBlockSize])
+		p.buf = p.buf[gzipBlockSize:]
+	}
+	return len(data), nil
+}
+
+func (p *parallelGzipWriter) compress(block []byte) {
+	p.members++
+	block = append([]byte(nil), block...)
+	result := make(chan []byte, 1)
+	p.pending <- result
+	go func() {
+		var member bytes.Buffer
+		gz, _ := gzip.NewWriterLevel(&member, p.level)
+		_, _ = gz.Write(block)
+		_ = gz.Close()
+		result <- member.Bytes()
+	}()
+}
+
+// writeMembers write the members in order, after a write error the members are discarded
+func (p *parallelGzipWriter) writeMembers() {
+	var err error
+	for result := range p.pending {
+		member := <-result
+		if err == nil {
+			_, err = p.w.Write(member)
+		}
+	}
+	p.done <- err
+}
+
+func (p *parallelGzipWriter) Close() error {
+	if p.closed {
+		return p.err
+	}
+	p.closed = true
+	// an empty stream is one empty member
+	if len(p.buf) > 0 || p.members == 0 {
+		p.compress(p.buf)
+		p.buf = nil
+	}
+	close(p.pending)
+	p.err = <-p.done
+	return p.err
+}
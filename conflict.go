@@ -0,0 +1,117 @@
+package mysqldump
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/go-sql-driver/mysql"
+
+	"mysqldump/sqlutil"
+)
+
+// ConflictKind the kind of a ConflictError
+type ConflictKind int
+
+const (
+	// ConflictDuplicateKey a row with the same primary or unique key exists
+	ConflictDuplicateKey ConflictKind = iota + 1
+	// ConflictAlreadyExists the table, view, trigger, routine or database exists
+	ConflictAlreadyExists
+)
+
+// ConflictError a statement of Source that failed on existing data
+type ConflictError struct {
+	Kind ConflictKind
+	// Table the target of an INSERT or CREATE TABLE, "" for other statements
+	Table     string
+	Statement string
+	Line      int
+	Err       error
+}
+
+func (e ConflictError) Error() string {
+	return fmt.Sprintf("conflict at line %d: %v", e.Line, e.Err)
+}
+
+func (e ConflictError) Unwrap() error {
+	return e.Err
+}
+
+// ConflictAction how a conflict is resolved
+type ConflictAction int
+
+const (
+	// ConflictAbort fail the restore
+	ConflictAbort ConflictAction = iota
+	// ConflictSkip ignore the statement
+	ConflictSkip
+	// ConflictReplace overwrite the existing data: an INSERT is executed as REPLACE, an
+	// existing table is dropped before its CREATE TABLE is executed again. Other
+	// statements fail the restore.
+	ConflictReplace
+)
+
+// WithConflictHandler decide per statement what to do with the duplicate key and already
+// exists errors of the restore, eg: skip the rows loaded by a previous attempt. With
+// WithSavepoints the failed statements are rolled back instead.
+func WithConflictHandler(handler func(err ConflictError) ConflictAction) SourceOption {
+	return func(o *sourceOption) {
+		o.conflictHandler = handler
+	}
+}
+
+// conflictKind the kind of conflict of err, false for other errors
+func conflictKind(err error) (ConflictKind, bool) {
+	var mysqlErr *mysql.MySQLError
+	if !errors.As(err, &mysqlErr) {
+		return 0, false
+	}
+	switch mysqlErr.Number {
+	case 1062, 1586:
+		return ConflictDuplicateKey, true
+	case 1007, 1050, 1304, 1359:
+		return ConflictAlreadyExists, true
+	}
+	return 0, false
+}
+
+// resolveConflict apply the WithConflictHandler decision to a failed statement
+func (o *sourceOption) resolveConflict(db *dbWrapper, dml string, line int, err error) error {
+	kind, ok := conflictKind(err)
+	if !ok {
+		return err
+	}
+	conflict := ConflictError{
+		Kind:      kind,
+		Table:     sqlutil.StatementTable(dml),
+		Statement: dml,
+		Line:      line,
+		Err:       err,
+	}
+
+	switch o.conflictHandler(conflict) {
+	case ConflictSkip:
+		log.Printf("[warn] [source] skip the statement at line %d: %v\n", line, err)
+		return nil
+	case ConflictReplace:
+		verb := sqlutil.StatementVerb(dml)
+		switch {
+		case kind == ConflictDuplicateKey && verb == "INSERT":
+			idx := strings.Index(strings.ToUpper(dml), "INSERT")
+			_, err = db.Exec(dml[:idx] + "REPLACE" + dml[idx+len("INSERT"):])
+			return err
+		case kind == ConflictAlreadyExists && verb == "CREATE" && conflict.Table != "":
+			_, err = db.Exec("DROP TABLE IF EXISTS " + sqlutil.QuoteIdentifier(conflict.Table))
+			if err != nil {
+				return err
+			}
+			_, err = db.Exec(dml)
+			return err
+		}
+		return fmt.Errorf("%w, the statement can not be replaced", err)
+	default:
+		return err
+	}
+}
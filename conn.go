@@ -0,0 +1,40 @@
+package mysqldump
+
+import (
+	"context"
+	"database/sql"
+)
+
+// QueryerExecer the database access of Dump and Source, implemented by *sql.DB, *sql.Conn
+// and *sql.Tx. See NewDumperWithConn and NewSourcerWithConn, eg: to test with go-sqlmock.
+type QueryerExecer interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// dbConn the database access of the dump, a *sql.DB or a ctxConn
+type dbConn interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+// ctxConn a connection bound to the context of a run, session state like USE applies to
+// all its queries
+type ctxConn struct {
+	ctx  context.Context
+	conn QueryerExecer
+}
+
+func (c *ctxConn) Exec(query string, args ...interface{}) (sql.Result, error) {
+	return c.conn.ExecContext(c.ctx, query, args...)
+}
+
+func (c *ctxConn) Query(query string, args ...interface{}) (*sql.Rows, error) {
+	return c.conn.QueryContext(c.ctx, query, args...)
+}
+
+func (c *ctxConn) QueryRow(query string, args ...interface{}) *sql.Row {
+	return c.conn.QueryRowContext(c.ctx, query, args...)
+}
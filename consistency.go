@@ -0,0 +1,149 @@
+package mysqldump
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// IsolationLevel the isolation level of the dump transaction, see WithIsolationLevel
+type IsolationLevel string
+
+const (
+	IsolationRepeatableRead  IsolationLevel = "REPEATABLE READ"
+	IsolationReadCommitted   IsolationLevel = "READ COMMITTED"
+	IsolationReadUncommitted IsolationLevel = "READ UNCOMMITTED"
+	IsolationSerializable    IsolationLevel = "SERIALIZABLE"
+)
+
+// WithSingleTransaction read all the tables in one transaction started WITH CONSISTENT
+// SNAPSHOT, so that the dump of InnoDB tables matches a single point in time without
+// locking them. DDL run during the dump is not isolated. WithFlushLogs starts the same
+// transaction under a read lock.
+func WithSingleTransaction() DumpOption {
+	return func(option *dumpOption) {
+		option.isSingleTransaction = true
+	}
+}
+
+// WithIsolationLevel the isolation level of the dump session, REPEATABLE READ by default.
+// Only REPEATABLE READ gives WithSingleTransaction and WithFlushLogs a consistent snapshot:
+// with READ COMMITTED each query sees the rows committed when it starts, each table is
+// consistent but the tables may not match each other, in exchange for shorter lived read
+// views on busy servers. SERIALIZABLE locks the rows read and blocks the writers, and READ
+// UNCOMMITTED reads uncommitted changes, both are recorded as a warning.
+func WithIsolationLevel(level IsolationLevel) DumpOption {
+	return func(option *dumpOption) {
+		option.isolationLevel = level
+	}
+}
+
+// checkIsolationLevel validate WithIsolationLevel against the transaction of the dump
+func (o *dumpOption) checkIsolationLevel() error {
+	switch o.isolationLevel {
+	case "", IsolationRepeatableRead:
+		return nil
+	case IsolationReadCommitted:
+		if o.isSingleTransaction || o.isFlushLogs {
+			o.warnf("isolation level %s: the tables are consistent one by one, not with each other", o.isolationLevel)
+		}
+	case IsolationReadUncommitted:
+		o.warnf("isolation level %s: uncommitted changes may be dumped", o.isolationLevel)
+	case IsolationSerializable:
+		o.warnf("isolation level %s: the rows read are locked, writes wait for the dump", o.isolationLevel)
+	default:
+		return fmt.Errorf("unknown isolation level: %s", o.isolationLevel)
+	}
+	return nil
+}
+
+// WithFlushLogs rotate the binary logs at the start of the dump like the classic backup
+// recipes: FLUSH TABLES WITH READ LOCK and FLUSH LOGS, the binlog coordinates are read into
+// DumpResult.BinlogPosition, a consistent snapshot transaction is started and the lock is
+// released, so that the whole dump matches the start of the new binary log. Requires the
+// RELOAD privilege, without it a warning is recorded and the dump goes on unlocked. See
+// WithBackupLock to keep the writes going on MySQL 8.0.
+func WithFlushLogs() DumpOption {
+	return func(option *dumpOption) {
+		option.isFlushLogs = true
+	}
+}
+
+// flushLogs the WithFlushLogs sequence, it reports whether a snapshot transaction was started
+func flushLogs(db dbConn, o *dumpOption) (bool, error) {
+	if o.backupLocked {
+		return flushLogsUnderBackupLock(db, o)
+	}
+
+	_, err := db.Exec("FLUSH TABLES WITH READ LOCK")
+	if err != nil {
+		if isPrivilegeError(err) {
+			o.warnf("FLUSH TABLES WITH READ LOCK denied, the binary logs are not flushed: %v", err)
+			return false, nil
+		}
+		return false, err
+	}
+	defer func() {
+		_ = releaseExec(db, "UNLOCK TABLES")
+	}()
+
+	_, err = db.Exec("FLUSH LOGS")
+	if err != nil {
+		return false, err
+	}
+
+	position, err := readBinlogPosition(db)
+	if err != nil {
+		// REPLICATION CLIENT is missing, the snapshot is still consistent
+		o.warnf("binlog position: %v", err)
+	} else if position != nil && o.result != nil {
+		o.result.BinlogPosition = position
+	}
+
+	err = startSnapshot(db, o.isolationLevel)
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// startSnapshot start the read only transaction all the tables are read in
+func startSnapshot(db dbConn, level IsolationLevel) error {
+	if level == "" {
+		level = IsolationRepeatableRead
+	}
+	_, err := db.Exec("SET SESSION TRANSACTION ISOLATION LEVEL " + string(level))
+	if err != nil {
+		return err
+	}
+	_, err = db.Exec("START TRANSACTION WITH CONSISTENT SNAPSHOT")
+	return err
+}
+
+// readBinlogPosition the current binlog coordinates, nil when the binary log is disabled
+func readBinlogPosition(db dbConn) (*BinlogPosition, error) {
+	var values []string
+	collect := func(row []string) {
+		values = row
+	}
+	// SHOW MASTER STATUS is removed from MySQL 8.4
+	err := queryRows(db, "SHOW BINARY LOG STATUS", nil, collect)
+	if err != nil {
+		err = queryRows(db, "SHOW MASTER STATUS", nil, collect)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(values) < 2 {
+		return nil, nil
+	}
+
+	position, err := strconv.ParseUint(values[1], 10, 64)
+	if err != nil {
+		return nil, err
+	}
+	binlog := &BinlogPosition{File: values[0], Position: position}
+	if len(values) >= 5 {
+		binlog.GTIDSet = values[4]
+	}
+	return binlog, nil
+}
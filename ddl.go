@@ -0,0 +1,145 @@
+package mysqldump
+
+import (
+	"regexp"
+	"strings"
+)
+
+// columnDefRe a column definition line of SHOW CREATE TABLE: indentation, name, type
+var columnDefRe = regexp.MustCompile("(?m)^(\\s+`(?:[^`]|``)+`\\s+)([a-zA-Z]+)(\\([^)]*\\))?")
+
+// WithTypeMapping rewrite the column types of the dumped CREATE TABLE statements,
+// keys are matched case-insensitively either against the type name (the length or
+// precision is kept unless the replacement has its own), eg: "DATETIME": "TIMESTAMP"
+// turns datetime(3) into TIMESTAMP(3), or against the full type, eg: "int(11)": "BIGINT".
+func WithTypeMapping(mapping map[string]string) DumpOption {
+	return func(option *dumpOption) {
+		option.typeMapping = make(map[string]string, len(mapping))
+		for from, to := range mapping {
+			option.typeMapping[strings.ToLower(strings.Replace(from, " ", "", -1))] = to
+		}
+	}
+}
+
+// mapColumnTypes apply a WithTypeMapping mapping, keys are lower case
+func mapColumnTypes(ddl string, mapping map[string]string) string {
+	return columnDefRe.ReplaceAllStringFunc(ddl, func(def string) string {
+		m := columnDefRe.FindStringSubmatch(def)
+		prefix, name, params := m[1], m[2], m[3]
+
+		if to, ok := mapping[strings.ToLower(name+strings.Replace(params, " ", "", -1))]; ok && params != "" {
+			return prefix + to
+		}
+		if to, ok := mapping[strings.ToLower(name)]; ok {
+			if strings.Contains(to, "(") {
+				return prefix + to
+			}
+			return prefix + to + params
+		}
+		return def
+	})
+}
+
+// charsetRe a CHARACTER SET, CHARSET or COLLATE clause of a table or column
+var charsetRe = regexp.MustCompile(`(?i)\b(CHARACTER\s+SET|CHARSET|COLLATE)(\s*=\s*|\s+)([a-z0-9_]+)`)
+
+// WithConvertCharset rewrite the character sets of the dumped CREATE TABLE statements,
+// both the table defaults and the column clauses, eg: WithConvertCharset("utf8", "utf8mb4").
+// The collations of from are renamed accordingly, utf8_general_ci becomes utf8mb4_general_ci,
+// those without a counterpart in to get its default collation, eg: latin1_german1_ci
+// becomes utf8mb4_general_ci.
+// "utf8" also matches its "utf8mb3" alias of MySQL 8. Can be given several times.
+func WithConvertCharset(from, to string) DumpOption {
+	from = strings.ToLower(from)
+	return func(option *dumpOption) {
+		if option.charsetMapping == nil {
+			option.charsetMapping = make(map[string]string)
+		}
+		option.charsetMapping[from] = to
+		if from == "utf8" {
+			option.charsetMapping["utf8mb3"] = to
+		}
+	}
+}
+
+// convertCharsets apply a WithConvertCharset mapping, keys are lower case
+func convertCharsets(ddl string, mapping map[string]string) string {
+	return charsetRe.ReplaceAllStringFunc(ddl, func(clause string) string {
+		m := charsetRe.FindStringSubmatch(clause)
+		keyword, sep, name := m[1], m[2], strings.ToLower(m[3])
+
+		if strings.EqualFold(keyword, "COLLATE") {
+			// collations are named after their charset, eg: latin1_swedish_ci
+			for from, to := range mapping {
+				if strings.HasPrefix(name, from+"_") {
+					return keyword + sep + convertCollation(name[len(from):], strings.ToLower(to))
+				}
+			}
+			return clause
+		}
+		if to, ok := mapping[name]; ok {
+			return keyword + sep + to
+		}
+		return clause
+	})
+}
+
+// unicodeCollations the collations of utf8mb3 and utf8mb4 without their charset
+var unicodeCollations = collationSet("_bin", "_general_ci", "_unicode_ci", "_unicode_520_ci",
+	"_icelandic_ci", "_latvian_ci", "_romanian_ci", "_slovenian_ci", "_polish_ci", "_estonian_ci",
+	"_spanish_ci", "_swedish_ci", "_turkish_ci", "_czech_ci", "_danish_ci", "_lithuanian_ci",
+	"_slovak_ci", "_spanish2_ci", "_roman_ci", "_persian_ci", "_esperanto_ci", "_hungarian_ci",
+	"_sinhala_ci", "_german2_ci", "_croatian_ci", "_vietnamese_ci")
+
+// charsetCollations the collations of the usual target charsets without their charset
+var charsetCollations = map[string]map[string]bool{
+	"utf8mb4": unicodeCollations,
+	"utf8mb3": unicodeCollations,
+	"utf8":    unicodeCollations,
+	"latin1": collationSet("_bin", "_general_ci", "_general_cs", "_swedish_ci", "_danish_ci",
+		"_german1_ci", "_german2_ci", "_spanish_ci"),
+	"ascii": collationSet("_bin", "_general_ci"),
+}
+
+// charsetDefaultCollations the collation of a target charset when the converted one does
+// not exist, the defaults of MySQL 5.7 and MariaDB, which MySQL 8 also has
+var charsetDefaultCollations = map[string]string{
+	"utf8mb4": "utf8mb4_general_ci",
+	"utf8mb3": "utf8mb3_general_ci",
+	"utf8":    "utf8_general_ci",
+	"latin1":  "latin1_swedish_ci",
+	"ascii":   "ascii_general_ci",
+}
+
+func collationSet(suffixes ...string) map[string]bool {
+	set := make(map[string]bool, len(suffixes))
+	for _, suffix := range suffixes {
+		set[suffix] = true
+	}
+	return set
+}
+
+// convertCollation the collation of charset to for the collation suffix of another
+// charset, eg: "_unicode_ci" of latin1_unicode_ci
+func convertCollation(suffix, to string) string {
+	collations, ok := charsetCollations[to]
+	if !ok {
+		// an unknown charset, trust the name
+		return to + suffix
+	}
+	if collations[suffix] || to == "utf8mb4" && strings.HasPrefix(suffix, "_0900_") {
+		return to + suffix
+	}
+	return charsetDefaultCollations[to]
+}
+
+// rewriteTableDDL apply the DDL options to a CREATE TABLE statement
+func (o *dumpOption) rewriteTableDDL(ddl string) string {
+	if len(o.typeMapping) > 0 {
+		ddl = mapColumnTypes(ddl, o.typeMapping)
+	}
+	if len(o.charsetMapping) > 0 {
+		ddl = convertCharsets(ddl, o.charsetMapping)
+	}
+	return o.escapeTemplate(ddl)
+}
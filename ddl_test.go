@@ -0,0 +1,30 @@
+package mysqldump
+
+import "testing"
+
+func TestConvertCharsets(t *testing.T) {
+	mapping := map[string]string{"latin1": "utf8mb4", "utf8": "utf8mb4", "utf8mb3": "utf8mb4"}
+	tests := []struct {
+		ddl  string
+		want string
+	}{
+		{"DEFAULT CHARSET=latin1 COLLATE=latin1_swedish_ci", "DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_swedish_ci"},
+		{"CHARACTER SET latin1 COLLATE latin1_german1_ci", "CHARACTER SET utf8mb4 COLLATE utf8mb4_general_ci"},
+		{"CHARACTER SET latin1 COLLATE latin1_general_cs", "CHARACTER SET utf8mb4 COLLATE utf8mb4_general_ci"},
+		{"CHARACTER SET latin1 COLLATE latin1_bin", "CHARACTER SET utf8mb4 COLLATE utf8mb4_bin"},
+		{"CHARSET=utf8 COLLATE=utf8_unicode_ci", "CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci"},
+		{"CHARSET=utf8mb3 COLLATE=utf8mb3_german2_ci", "CHARSET=utf8mb4 COLLATE=utf8mb4_german2_ci"},
+		{"CHARSET=ascii COLLATE=ascii_bin", "CHARSET=ascii COLLATE=ascii_bin"},
+	}
+	for _, test := range tests {
+		if got := convertCharsets(test.ddl, mapping); got != test.want {
+			t.Errorf("convertCharsets(%q) = %q, want %q", test.ddl, got, test.want)
+		}
+	}
+
+	// a charset without a collation table keeps the converted name
+	got := convertCharsets("COLLATE latin1_swedish_ci", map[string]string{"latin1": "cp1250"})
+	if want := "COLLATE cp1250_swedish_ci"; got != want {
+		t.Errorf("convertCharsets to cp1250 = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,53 @@
+package mysqldump
+
+import (
+	"fmt"
+
+	"mysqldump/sqlutil"
+)
+
+// ddlRedumpAttempts times a table whose DDL changed during its dump is dumped again
+const ddlRedumpAttempts = 1
+
+// WithDDLChangeCheck compare the DDL of each table before and after the export of its data.
+// A table altered in between is recorded into DumpResult.InconsistentTables, its rows may
+// not match its CREATE TABLE. With redump the table is dumped again once after its first
+// dump, DROP TABLE and CREATE TABLE included, so that the restore replaces the inconsistent
+// copy, and it is only recorded if its DDL changed again.
+func WithDDLChangeCheck(redump bool) DumpOption {
+	return func(option *dumpOption) {
+		option.isDDLCheck = true
+		option.isDDLRedump = redump
+	}
+}
+
+// recheckDDL compare the DDL of table with its fingerprint before the dump, rows is
+// the count of the rows written so far, the count of the last dump is returned
+func (o *dumpOption) recheckDDL(db dbConn, dbName, table, where, fingerprint string, buf *SafeWriter, rows int) (int, error) {
+	for attempt := 0; ; attempt++ {
+		current, err := getTableFingerprint(db, table, false)
+		if err == nil && current == fingerprint {
+			return rows, nil
+		}
+		if err != nil || !o.isDDLRedump || attempt >= ddlRedumpAttempts {
+			o.warnf("DDL of %s.%s changed during its dump, the dump of the table is inconsistent", dbName, table)
+			if o.result != nil {
+				o.result.InconsistentTables = append(o.result.InconsistentTables, dbName+"."+table)
+			}
+			return rows, nil
+		}
+
+		o.warnf("DDL of %s.%s changed during its dump, the table is dumped again", dbName, table)
+		fingerprint = current
+		_, _ = buf.WriteString(o.annotation(dbName, ObjectTable, table, 0))
+		_, _ = buf.WriteString(fmt.Sprintf("DROP TABLE IF EXISTS %s;\n", sqlutil.QuoteIdentifier(table)))
+		err = writeTableStruct(db, dbName, table, buf, o)
+		if err != nil {
+			return rows, err
+		}
+		rows, err = writeTableData(db, dbName, table, where, buf, o)
+		if err != nil {
+			return rows, err
+		}
+	}
+}
@@ -3,15 +3,23 @@ package mysqldump
 
 import (
 	"bufio"
+	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
 	"database/sql"
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"io"
 	"log"
 	"os"
+	"strconv"
 	"strings"
 	"time"
 
 	_ "github.com/go-sql-driver/mysql"
+
+	"mysqldump/sqlutil"
 )
 
 const BufferSize = 1 << 20
@@ -54,6 +62,140 @@ type dumpOption struct {
 	writer io.Writer
 	// export primary key ID
 	withoutPrimaryID bool
+	// export triggers
+	isDumpTriggers bool
+	// export procedures and functions
+	isDumpRoutines bool
+	// emit DDL that can be applied more than once
+	isIdempotentDDL bool
+	// truncate table before the data of the table
+	isTruncateTable bool
+	// only dump the tables whose fingerprint changed, keyed by db.table
+	schemaSnapshot map[string]string
+	// all schemas before any data
+	isSchemaFirst bool
+	// skip objects that can not be read for lack of privileges
+	isBestEffort bool
+	result       *DumpResult
+	// target platform
+	profile Profile
+	// dump a Vitess keyspace shard by shard, vitessShard is the shard being dumped
+	vitessKeyspace string
+	vitessShards   []string
+	vitessShard    string
+	// TiDB historical read, tidb_snapshot value
+	tidbSnapshot string
+	// rewrite column types of CREATE TABLE, lower case keys
+	typeMapping map[string]string
+	// rewrite character sets of CREATE TABLE, lower case keys
+	charsetMapping map[string]string
+	// applied in order to each exported row
+	rowTransformers []RowTransformer
+	// prefix each statement with a comment naming its db and object
+	isAnnotations bool
+	// referentially intact subset, WHERE condition of the data by db.table
+	subsetSeeds []subsetSeed
+	subset      map[string]string
+	// FLUSH LOGS under FLUSH TABLES WITH READ LOCK, then a consistent snapshot
+	isFlushLogs bool
+	// column histograms after the data of each table
+	isHistograms bool
+	// only dump these partitions of the tables
+	partitions map[string][]string
+	// envelope encryption of the output
+	kms KMS
+	// detached signature of the output
+	signingKey ed25519.PrivateKey
+	signature  io.Writer
+	// differential dumps, see WithManifest and WithChangedSince
+	manifest         *Manifest
+	previousManifest *Manifest
+	changeColumn     string
+	// the output is stored as chunks, the recipe is written instead
+	chunkStore ChunkStore
+	// the codec and tuning of WithCompression
+	compression        string
+	compressionOptions CompressionOptions
+	// stats of the dumped columns into the manifest
+	isColumnStats bool
+	// rows above maxRowBytes are split, skipped or fail the dump
+	maxRowBytes   int
+	rowSizePolicy RowSizePolicy
+	// lifecycle events POSTed to a url
+	webhook *webhook
+	// executed on the dump connection before and after the dump
+	preSQL  []string
+	postSQL []string
+	// schema issues of the dumped tables into the warnings
+	isSchemaLint bool
+	// the data of these tables is dumped by parallel connections
+	splitTables map[string]int
+	// a further connection of the run, nil when the run has a single connection
+	acquire func() (dbConn, func(), error)
+	// wrap the output, see WithWriterMiddleware
+	writerMiddlewares []func(io.Writer) io.Writer
+	// limit of the queries of the dump
+	statementTimeout time.Duration
+	// all the tables are read in one transaction
+	isSingleTransaction bool
+	isolationLevel      IsolationLevel
+	// LOCK INSTANCE FOR BACKUP for the whole dump, see WithBackupLock
+	isBackupLock bool
+	backupLocked bool
+	// compare the DDL of each table before and after its data
+	isDDLCheck  bool
+	isDDLRedump bool
+	// tables failing on a lock are dumped again at the end
+	lockRetryAttempts int
+	lockRetries       []lockRetry
+	// optimizer hint of the SELECTs reading the rows
+	selectHint string
+	// resource group of the connections of the dump
+	resourceGroup string
+	// literals written as placeholders, see WithTemplateVars
+	templateVars *strings.Replacer
+	// the tables of a tag are written to its bundle, see WithTagBundle
+	tableTags map[string]string
+	bundles   map[string]*tagBundle
+	// the destination of each database of DumpEach
+	eachWriter func(db string) (io.WriteCloser, error)
+	// the file the dump is renamed to once done, see WithOutputFile
+	outputFile string
+}
+
+// DumpResult the report of a Dump run, see WithDumpResult
+type DumpResult struct {
+	Warnings []string
+	// BinlogPosition the binlog coordinates the dump is consistent with, see WithFlushLogs
+	BinlogPosition *BinlogPosition
+	// InconsistentTables db.table of the tables whose DDL changed during their dump, see
+	// WithDDLChangeCheck
+	InconsistentTables []string
+	// Retries the tables dumped again after a lock error, see WithLockRetry
+	Retries []TableRetry
+	// WriterStalls the times the rows waited for a full write queue, the writer is slower
+	// than the reads, and how long they waited in total
+	WriterStalls    int
+	WriterStallTime time.Duration
+}
+
+// warnf log a warning and record it into the result
+func (o *dumpOption) warnf(format string, args ...interface{}) {
+	msg := fmt.Sprintf(format, args...)
+	log.Printf("[warn] %s \n", msg)
+	if o.result != nil {
+		o.result.Warnings = append(o.result.Warnings, msg)
+	}
+}
+
+// skipOnPrivilegeError with WithBestEffort a privilege error is turned into a warning and
+// the object is skipped
+func (o *dumpOption) skipOnPrivilegeError(err error, object string) bool {
+	if !o.isBestEffort || !isPrivilegeError(err) {
+		return false
+	}
+	o.warnf("skip %s: %v", object, err)
+	return true
 }
 
 type DumpOption func(*dumpOption)
@@ -102,6 +244,58 @@ func WithDumpTable() DumpOption {
 	}
 }
 
+func WithTriggers() DumpOption {
+	return func(option *dumpOption) {
+		option.isDumpTriggers = true
+	}
+}
+
+func WithRoutines() DumpOption {
+	return func(option *dumpOption) {
+		option.isDumpRoutines = true
+	}
+}
+
+// WithIdempotentDDL emit DDL that can be re-applied safely, eg: CREATE OR REPLACE VIEW,
+// DROP TRIGGER IF EXISTS and DROP PROCEDURE IF EXISTS before the CREATE statements
+func WithIdempotentDDL() DumpOption {
+	return func(option *dumpOption) {
+		option.isIdempotentDDL = true
+	}
+}
+
+// WithTruncateTables emit TRUNCATE TABLE before the data of each table,
+// so that the dump can be loaded repeatedly into the same tables
+func WithTruncateTables() DumpOption {
+	return func(option *dumpOption) {
+		option.isTruncateTable = true
+	}
+}
+
+// WithSchemaFirst write the schemas of all databases before any data, so that foreign key
+// checks can stay on during the restore and a partial restore has every table
+func WithSchemaFirst() DumpOption {
+	return func(option *dumpOption) {
+		option.isSchemaFirst = true
+	}
+}
+
+// WithDumpResult fill result with the report of the run
+func WithDumpResult(result *DumpResult) DumpOption {
+	return func(option *dumpOption) {
+		option.result = result
+	}
+}
+
+// WithBestEffort skip the views, triggers and routines that can not be read for lack of
+// privileges (SHOW VIEW, TRIGGER, ...) with a warning instead of failing, eg: for managed
+// databases with restricted accounts
+func WithBestEffort() DumpOption {
+	return func(option *dumpOption) {
+		option.isBestEffort = true
+	}
+}
+
 func WithWhere(where string) DumpOption {
 	return func(option *dumpOption) {
 		option.where = where
@@ -114,6 +308,16 @@ func WithWriter(writer io.Writer) DumpOption {
 	}
 }
 
+// WithWriterMiddleware wrap the output with fn, eg: to tee it to a second destination,
+// hash it or count its bytes. fn sees the bytes as written, after the compression, the
+// encryption and the chunking. The middlewares wrap each other in order, the last one
+// receives the writes first. A wrapper that is an io.Closer is closed at the end of the dump.
+func WithWriterMiddleware(fn func(io.Writer) io.Writer) DumpOption {
+	return func(option *dumpOption) {
+		option.writerMiddlewares = append(option.writerMiddlewares, fn)
+	}
+}
+
 func WithoutPrimaryID(withoutPrimaryID bool) DumpOption {
 	return func(option *dumpOption) {
 		option.withoutPrimaryID = withoutPrimaryID
@@ -121,6 +325,108 @@ func WithoutPrimaryID(withoutPrimaryID bool) DumpOption {
 }
 
 func Dump(dns string, opts ...DumpOption) error {
+	dumper, err := NewDumper(dns, opts...)
+	if err != nil {
+		log.Printf("[error] %v \n", err)
+		return err
+	}
+	defer func() {
+		_ = dumper.Close()
+	}()
+
+	return dumper.Run(context.Background())
+}
+
+// Dumper a Dump configuration and its connection pool, reusable for many runs. Run may be
+// called concurrently, each run uses a connection of its own.
+type Dumper struct {
+	dns  string
+	opts []DumpOption
+	db   *sql.DB
+	// the connection of every run, see NewDumperWithConn
+	conn QueryerExecer
+}
+
+// NewDumper a Dumper of the database of dns, Close it once done
+func NewDumper(dns string, opts ...DumpOption) (*Dumper, error) {
+	db, err := sql.Open("mysql", dns)
+	if err != nil {
+		return nil, err
+	}
+	return &Dumper{
+		dns:  dns,
+		opts: opts,
+		db:   db,
+	}, nil
+}
+
+// NewDumperWithConn a Dumper running on conn, eg: a *sql.DB of go-sqlmock in tests. The
+// dump changes the session with USE, conn must be a *sql.Conn or a *sql.DB limited to one
+// connection, and Run must not be called concurrently. The current database of conn is
+// dumped by default.
+func NewDumperWithConn(conn QueryerExecer, opts ...DumpOption) *Dumper {
+	return &Dumper{
+		opts: opts,
+		conn: conn,
+	}
+}
+
+// DSN the dsn of the dumped database, "" for NewDumperWithConn
+func (d *Dumper) DSN() string {
+	return d.dns
+}
+
+// Options the options of the Dumper, eg: to create a Dumper with more options
+func (d *Dumper) Options() []DumpOption {
+	return append([]DumpOption(nil), d.opts...)
+}
+
+// Close the connections of the Dumper, the conn of NewDumperWithConn is left open
+func (d *Dumper) Close() error {
+	if d.db == nil {
+		return nil
+	}
+	return d.db.Close()
+}
+
+// acquire the connection of a run, release it once done
+func (d *Dumper) acquire(ctx context.Context) (dbConn, func(), error) {
+	if d.conn != nil {
+		return &ctxConn{ctx: ctx, conn: d.conn}, func() {}, nil
+	}
+
+	conn, err := d.db.Conn(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	stop := killOnCancel(ctx, d.db, conn)
+	return &ctxConn{ctx: ctx, conn: conn}, func() {
+		stop()
+		_ = conn.Close()
+	}, nil
+}
+
+// currentDB the database of dns, or the current database of the connection
+func currentDB(db dbConn, dns string) (string, error) {
+	if dns != "" {
+		return GetDBNameFromDNS(dns)
+	}
+
+	var dbName sql.NullString
+	err := db.QueryRow("SELECT DATABASE()").Scan(&dbName)
+	if err != nil {
+		return "", err
+	}
+	if dbName.String == "" {
+		return "", errors.New("no database selected")
+	}
+	return dbName.String, nil
+}
+
+// Run dump the database, opts are applied after the options of the Dumper for this run
+// only, eg: WithWriter to write each run to its own destination. Cancelling ctx aborts
+// the running query.
+func (d *Dumper) Run(ctx context.Context, opts ...DumpOption) (err error) {
 
 	start := time.Now()
 	log.Printf("[info] [dump] start at %s\n", start.Format("2006-01-02 15:04:05"))
@@ -130,17 +436,40 @@ func Dump(dns string, opts ...DumpOption) error {
 		log.Printf("[info] [dump] end at %s, cost %s\n", end.Format("2006-01-02 15:04:05"), end.Sub(start))
 	}()
 
-	var err error
-
 	var o dumpOption
 
+	for _, opt := range d.opts {
+		opt(&o)
+	}
 	for _, opt := range opts {
 		opt(&o)
 	}
 
+	defer func() {
+		event := WebhookEvent{Event: EventDumpFinished, DurationMs: time.Since(start).Milliseconds()}
+		if err != nil {
+			event.Error = err.Error()
+		}
+		o.notify(event)
+	}()
+
+	// USE must apply to the following queries
+	db, release, err := d.acquire(ctx)
+	if err != nil {
+		log.Printf("[error] %v \n", err)
+		return err
+	}
+	defer release()
+
+	if d.conn == nil {
+		o.acquire = func() (dbConn, func(), error) {
+			return d.acquire(ctx)
+		}
+	}
+
 	// db in dsn by default
 	if len(o.dbs) == 0 {
-		dbName, err := GetDBNameFromDNS(dns)
+		dbName, err := currentDB(db, d.dns)
 		if err != nil {
 			log.Printf("[error] %v \n", err)
 			return err
@@ -155,29 +484,191 @@ func Dump(dns string, opts ...DumpOption) error {
 		o.isAllTable = true
 	}
 
+	if o.outputFile != "" {
+		var file *atomicFile
+		file, err = createAtomicFile(o.outputFile)
+		if err != nil {
+			log.Printf("[error] %v \n", err)
+			return err
+		}
+		// the last defer, once the other writers are closed and flushed
+		defer func() {
+			if err == nil {
+				err = file.commit()
+			}
+			file.abort()
+		}()
+		o.writer = file
+	}
+
 	// output to the console by default
 	if o.writer == nil {
 		o.writer = os.Stdout
 	}
 
+	for _, middleware := range o.writerMiddlewares {
+		wrapped := middleware(o.writer)
+		// a middleware handing back its writer does not own it
+		closer, ok := wrapped.(io.Closer)
+		ok = ok && wrapped != o.writer
+		o.writer = wrapped
+		if ok {
+			// after the output is flushed, closed whether the dump succeeds or not
+			defer func() {
+				if closeErr := closer.Close(); err == nil {
+					err = closeErr
+				}
+			}()
+		}
+	}
+
+	if o.signingKey != nil {
+		digest := sha256.New()
+		// after the encryption, the signature covers the written bytes
+		defer func() {
+			if err == nil {
+				_, err = o.signature.Write(signDigest(digest, o.signingKey))
+			}
+		}()
+		o.writer = io.MultiWriter(o.writer, digest)
+	}
+
+	if o.chunkStore != nil {
+		var chunker io.WriteCloser
+		chunker, err = NewChunkWriter(o.chunkStore, o.writer)
+		if err != nil {
+			log.Printf("[error] %v \n", err)
+			return err
+		}
+		// the recipe of a failed dump misses its last chunk
+		defer func() {
+			if err == nil {
+				err = chunker.Close()
+			}
+		}()
+		o.writer = chunker
+	}
+
+	if o.kms != nil {
+		var encrypter io.WriteCloser
+		encrypter, err = NewEncryptWriter(o.writer, o.kms)
+		if err != nil {
+			log.Printf("[error] %v \n", err)
+			return err
+		}
+		// after the flush of buf, a failed dump has no last chunk and is rejected as truncated
+		defer func() {
+			if err == nil {
+				err = encrypter.Close()
+			}
+		}()
+		o.writer = encrypter
+	}
+
+	if o.compression != "" {
+		var codec Codec
+		codec, err = getCodec(o.compression)
+		if err != nil {
+			log.Printf("[error] %v \n", err)
+			return err
+		}
+		var compressor io.WriteCloser
+		compressor, err = codec.NewWriter(o.writer, o.compressionOptions)
+		if err != nil {
+			log.Printf("[error] %v \n", err)
+			return err
+		}
+		// before the encryption is closed, a failed dump has no compression trailer
+		defer func() {
+			if err == nil {
+				err = compressor.Close()
+			}
+		}()
+		o.writer = compressor
+	}
+
 	buf := NewSafeWriterWithSize(o.writer, BufferSize)
+	// the writes of buf fail on the first error, the last flush returns it before the
+	// output is committed
+	defer func() {
+		if flushErr := buf.Flush(); err == nil {
+			err = flushErr
+		}
+	}()
 	defer func() {
-		_ = buf.Flush()
+		if flushErr := o.flushBundles(); err == nil {
+			err = flushErr
+		}
 	}()
 
+	_, _ = buf.WriteString(o.dumpFormat().String() + "\n")
 	_, _ = buf.WriteString("-- ----------------------------\n")
 	_, _ = buf.WriteString("-- MySQL Database Dump\n")
 	_, _ = buf.WriteString("-- Start Time: " + start.Format("2006-01-02 15:04:05") + "\n")
 	_, _ = buf.WriteString("-- ----------------------------\n")
 	_, _ = buf.WriteString("\n\n")
+	_, _ = buf.WriteString(o.profile.header())
 
-	db, err := sql.Open("mysql", dns)
+	if o.tidbSnapshot != "" {
+		err = setTiDBSnapshot(db, o.tidbSnapshot)
+		if err != nil {
+			log.Printf("[error] %v \n", err)
+			return err
+		}
+	}
+
+	setStatementTimeout(db, &o)
+	setResourceGroup(db, &o)
+
+	err = execStatements(db, o.preSQL)
+	if err != nil {
+		log.Printf("[error] %v \n", err)
+		return err
+	}
+
+	err = o.checkIsolationLevel()
 	if err != nil {
 		log.Printf("[error] %v \n", err)
 		return err
 	}
+
+	if o.isBackupLock && lockInstanceForBackup(db, &o) {
+		defer func() {
+			_ = releaseExec(db, "UNLOCK INSTANCE")
+		}()
+	}
+
+	snapshot := false
+	if o.isFlushLogs {
+		snapshot, err = flushLogs(db, &o)
+		if err != nil {
+			log.Printf("[error] %v \n", err)
+			return err
+		}
+	}
+	if o.isSingleTransaction && !snapshot {
+		err = startSnapshot(db, o.isolationLevel)
+		if err != nil {
+			log.Printf("[error] %v \n", err)
+			return err
+		}
+		snapshot = true
+	} else if !snapshot && o.isolationLevel != "" {
+		_, err = db.Exec("SET SESSION TRANSACTION ISOLATION LEVEL " + string(o.isolationLevel))
+		if err != nil {
+			log.Printf("[error] %v \n", err)
+			return err
+		}
+	}
+	if snapshot {
+		defer func() {
+			_ = releaseExec(db, "COMMIT")
+		}()
+	}
 	defer func() {
-		_ = db.Close()
+		if postErr := execStatements(db, o.postSQL); postErr != nil {
+			log.Printf("[error] %v \n", postErr)
+		}
 	}()
 
 	var dbs []string
@@ -191,65 +682,249 @@ func Dump(dns string, opts ...DumpOption) error {
 		dbs = o.dbs
 	}
 
-	for _, dbStr := range dbs {
-		_, err = db.Exec(fmt.Sprintf("USE `%s`", dbStr))
+	if len(o.subsetSeeds) > 0 {
+		o.subset, err = loadSubset(db, dbs, o.subsetSeeds)
 		if err != nil {
 			log.Printf("[error] %v \n", err)
 			return err
 		}
+	}
+
+	// all schemas before any data, so that a partial restore has every table
+	phases := []dumpPhase{phaseAll}
+	if o.isSchemaFirst {
+		phases = []dumpPhase{phaseSchema, phaseData}
+	}
 
-		var tables []string
-		if o.isAllTable {
-			tmp, err := getAllTables(db)
+	// views may select from tables of any database, they are written after all tables
+	var views []viewDef
+	if o.vitessKeyspace != "" {
+		err = dumpVitessKeyspace(db, &o, buf, &views)
+	} else {
+		err = dumpDBs(db, dbs, &o, buf, phases, &views)
+	}
+	if err != nil {
+		log.Printf("[error] %v \n", err)
+		return err
+	}
+
+	err = o.retryLockedTables(ctx, db, buf)
+	if err != nil {
+		log.Printf("[error] %v \n", err)
+		return err
+	}
+
+	writeViews(sortViews(views), buf, &o)
+	o.finishBundles(start)
+
+	_, _ = buf.WriteString("-- ----------------------------\n")
+	_, _ = buf.WriteString("-- Dump completed\n")
+	_, _ = buf.WriteString("-- Cost Time: " + time.Since(start).String() + "\n")
+	_, _ = buf.WriteString("-- ----------------------------\n")
+	err = buf.Flush()
+	if err != nil {
+		log.Printf("[error] %v \n", err)
+		return err
+	}
+
+	return nil
+}
+
+type dumpPhase int
+
+const (
+	phaseAll dumpPhase = iota
+	// tables, views and routines
+	phaseSchema
+	// table data and triggers, triggers come last so that they do not fire while loading
+	phaseData
+	// table data only, for the rows of a further Vitess shard
+	phaseShardData
+)
+
+func dumpDBs(db dbConn, dbs []string, o *dumpOption, buf *SafeWriter, phases []dumpPhase, pendingViews *[]viewDef) error {
+	for _, phase := range phases {
+		for _, dbStr := range dbs {
+			err := dumpDB(db, dbStr, o, buf, phase, pendingViews)
 			if err != nil {
-				log.Printf("[error] %v \n", err)
 				return err
 			}
-			tables = tmp
-		} else {
-			tables = o.tables
 		}
+	}
+	return nil
+}
 
-		_, _ = buf.WriteString(fmt.Sprintf("USE `%s`;\n", dbStr))
+// dumpDB write the objects of database dbStr, views are appended to pendingViews
+// and written once the tables of every database are written
+func dumpDB(db dbConn, dbStr string, o *dumpOption, buf *SafeWriter, phase dumpPhase, pendingViews *[]viewDef) error {
+	target := dbStr
+	if o.vitessShard != "" {
+		target = dbStr + ":" + o.vitessShard
+	}
+	_, err := db.Exec("USE " + sqlutil.QuoteIdentifier(target))
+	if err != nil {
+		return err
+	}
 
-		for _, table := range tables {
+	tables := o.tables
+	if o.isAllTable {
+		tables, err = getAllTables(db)
+		if err != nil {
+			return err
+		}
+	}
+
+	views, err := getViews(db)
+	if err != nil {
+		return err
+	}
+
+	if o.schemaSnapshot != nil {
+		tables, err = filterChangedTables(db, dbStr, tables, views, o.schemaSnapshot)
+		if err != nil {
+			return err
+		}
+	}
+
+	if o.manifest != nil || o.previousManifest != nil {
+		tables, err = o.filterUnchangedTables(db, dbStr, tables, views)
+		if err != nil {
+			return err
+		}
+	}
+
+	// referenced tables first, so that foreign key checks can stay on during the restore
+	tables, cyclic, err := sortTablesByForeignKeys(db, dbStr, tables)
+	if err != nil {
+		return err
+	}
+
+	_, _ = buf.WriteString(o.annotation(dbStr, "", "", 0))
+	_, _ = buf.WriteString(fmt.Sprintf("USE %s;\n", sqlutil.QuoteIdentifier(o.template(dbStr))))
+
+	schema := phase == phaseAll || phase == phaseSchema
+	data := phase != phaseSchema
+	// the rows of every shard go into the same tables
+	shardData := phase == phaseShardData
+
+	fkChecksOff := len(cyclic) > 0 && (schema && o.isDumpTable || data && o.isData)
+	if fkChecksOff {
+		o.warnf("foreign key cycle between tables %s of %s, foreign key checks are off while loading them", strings.Join(cyclic, ", "), dbStr)
+		_, _ = buf.WriteString(o.annotation(dbStr, "", "", 0))
+		_, _ = buf.WriteString("SET FOREIGN_KEY_CHECKS=0;\n")
+	}
 
-			if o.isDropTable {
-				_, _ = buf.WriteString(fmt.Sprintf("DROP TABLE IF EXISTS `%s`;\n", table))
+	var viewNames []string
+	for _, table := range tables {
+		if views[table] {
+			viewNames = append(viewNames, table)
+			continue
+		}
+
+		tableStart := time.Now()
+		tableBuf := o.tableWriter(buf, dbStr, table)
+		o.notify(WebhookEvent{Event: EventTableStarted, DB: dbStr, Table: table})
+		var rows int
+
+		where, hasData := o.dataWhere(dbStr, table)
+		checkDDL := o.isDDLCheck && data && o.isData && hasData
+		var fingerprint string
+		if checkDDL {
+			fingerprint, err = getTableFingerprint(db, table, false)
+			if err != nil {
+				return err
+			}
+		}
+
+		if schema && o.isDropTable {
+			_, _ = tableBuf.WriteString(o.annotation(dbStr, ObjectTable, table, 0))
+			_, _ = tableBuf.WriteString(fmt.Sprintf("DROP TABLE IF EXISTS %s;\n", o.escapeTemplate(sqlutil.QuoteIdentifier(table))))
+		}
+
+		if schema && o.isDumpTable {
+			err = writeTableStruct(db, dbStr, table, tableBuf, o)
+			if err != nil {
+				return err
 			}
+		}
 
-			if o.isDumpTable {
-				err = writeTableStruct(db, table, buf)
+		if data && o.isData && hasData {
+			if o.isTruncateTable && !shardData {
+				_, _ = tableBuf.WriteString(o.annotation(dbStr, ObjectTable, table, 0))
+				_, _ = tableBuf.WriteString(fmt.Sprintf("TRUNCATE TABLE %s;\n", o.escapeTemplate(sqlutil.QuoteIdentifier(table))))
+			}
+
+			split := false
+			if o.splitTables[table] > 1 {
+				rows, split, err = writeSplitTableData(db, dbStr, table, where, tableBuf, o)
+			}
+			if err == nil && !split {
+				rows, err = writeTableData(db, dbStr, table, where, tableBuf, o)
+			}
+			retried := err != nil && o.queueLockRetry(dbStr, table, where, err)
+			if err != nil && !retried {
+				return err
+			}
+			err = nil
+
+			if o.isHistograms && !retried {
+				err = writeHistograms(db, dbStr, table, tableBuf, o)
 				if err != nil {
-					log.Printf("[error] %v \n", err)
 					return err
 				}
 			}
+		}
 
-			if o.isData {
-				where := o.where
-				withoutPrimaryID := o.withoutPrimaryID
-				err = writeTableData(db, table, where, buf, withoutPrimaryID)
-				if err != nil {
-					log.Printf("[error] %v \n", err)
-					return err
+		if checkDDL {
+			rows, err = o.recheckDDL(db, dbStr, table, where, fingerprint, tableBuf, rows)
+			if err != nil {
+				return err
+			}
+		}
+
+		o.notify(WebhookEvent{Event: EventTableFinished, DB: dbStr, Table: table, Rows: rows, DurationMs: time.Since(tableStart).Milliseconds()})
+	}
+
+	if fkChecksOff {
+		_, _ = buf.WriteString(o.annotation(dbStr, "", "", 0))
+		_, _ = buf.WriteString("SET FOREIGN_KEY_CHECKS=1;\n")
+	}
+
+	if schema && (o.isDumpTable || o.isDropTable) {
+		for _, view := range viewNames {
+			createViewSQL, err := getCreateViewSQL(db, view)
+			if err != nil {
+				if o.skipOnPrivilegeError(err, fmt.Sprintf("view %s.%s", dbStr, view)) {
+					continue
 				}
+				return err
 			}
+			*pendingViews = append(*pendingViews, viewDef{db: dbStr, name: view, ddl: createViewSQL})
 		}
 	}
 
-	_, _ = buf.WriteString("-- ----------------------------\n")
-	_, _ = buf.WriteString("-- Dump completed\n")
-	_, _ = buf.WriteString("-- Cost Time: " + time.Since(start).String() + "\n")
-	_, _ = buf.WriteString("-- ----------------------------\n")
-	_ = buf.Flush()
+	if schema {
+		if o.isDumpRoutines && o.profile.supportsRoutines() {
+			err = writeRoutines(db, dbStr, buf, o)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	if data && !shardData && o.isDumpTriggers && o.profile.supportsRoutines() {
+		err = writeTriggers(db, dbStr, buf, o)
+		if err != nil {
+			return err
+		}
+	}
 
 	return nil
 }
 
-func getCreateTableSQL(db *sql.DB, table string) (string, error) {
+func getCreateTableSQL(db dbConn, table string) (string, error) {
 	var createTableSQL string
-	err := db.QueryRow(fmt.Sprintf("SHOW CREATE TABLE `%s`", table)).Scan(&table, &createTableSQL) // ignore_security_alert_wait_for_fix SQL
+	err := db.QueryRow("SHOW CREATE TABLE "+sqlutil.QuoteIdentifier(table)).Scan(&table, &createTableSQL) // ignore_security_alert_wait_for_fix SQL
 	if err != nil {
 		return "", err
 	}
@@ -258,7 +933,7 @@ func getCreateTableSQL(db *sql.DB, table string) (string, error) {
 	return createTableSQL, nil
 }
 
-func getDBs(db *sql.DB) ([]string, error) {
+func getDBs(db dbConn) ([]string, error) {
 	var dbs []string
 	rows, err := db.Query("SHOW DATABASES")
 	if err != nil {
@@ -279,7 +954,7 @@ func getDBs(db *sql.DB) ([]string, error) {
 	return dbs, nil
 }
 
-func getAllTables(db *sql.DB) ([]string, error) {
+func getAllTables(db dbConn) ([]string, error) {
 	var tables []string
 	rows, err := db.Query("SHOW TABLES")
 	if err != nil {
@@ -300,7 +975,119 @@ func getAllTables(db *sql.DB) ([]string, error) {
 	return tables, nil
 }
 
-func writeTableStruct(db *sql.DB, table string, buf *SafeWriter) error {
+// forEachTable open dns and call fn for each table selected by o, views are skipped
+func forEachTable(dns string, o *dumpOption, fn func(db dbConn, dbName, table string) error) error {
+	dbs := o.dbs
+	// db in dsn by default
+	if len(dbs) == 0 {
+		dbName, err := GetDBNameFromDNS(dns)
+		if err != nil {
+			return err
+		}
+		dbs = []string{dbName}
+	}
+
+	db, err := sql.Open("mysql", dns)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = db.Close()
+	}()
+	// USE must apply to the following queries
+	db.SetMaxOpenConns(1)
+
+	if o.isAllDB {
+		dbs, err = getDBs(db)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, dbStr := range dbs {
+		_, err = db.Exec("USE " + sqlutil.QuoteIdentifier(dbStr))
+		if err != nil {
+			return err
+		}
+
+		tables := o.tables
+		if o.isAllTable || len(o.tables) == 0 {
+			tables, err = getAllTables(db)
+			if err != nil {
+				return err
+			}
+		}
+
+		views, err := getViews(db)
+		if err != nil {
+			return err
+		}
+
+		for _, table := range tables {
+			if views[table] {
+				continue
+			}
+			err = fn(db, dbStr, table)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// scanRows select the rows of table matching the where of o and call fn for each of them
+func scanRows(db dbConn, table string, o *dumpOption, fn func(columnTypes []*sql.ColumnType, row []interface{}) error) error {
+	dml := fmt.Sprintf("%s * FROM %s", o.selectKeyword(), sqlutil.QuoteIdentifier(table))
+	if strings.TrimSpace(o.where) != "" {
+		dml = fmt.Sprintf("%s where %s", dml, o.where)
+	}
+
+	rows, err := db.Query(dml) // ignore_security_alert_wait_for_fix SQL
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	columnTypes, err := rows.ColumnTypes()
+	if err != nil {
+		return err
+	}
+
+	for rows.Next() {
+		row := make([]interface{}, len(columnTypes))
+		rowPointers := make([]interface{}, len(columnTypes))
+		for i := range row {
+			rowPointers[i] = &row[i]
+		}
+		err = rows.Scan(rowPointers...)
+		if err != nil {
+			return err
+		}
+
+		err = fn(columnTypes, row)
+		if err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// queryColumnTypes the column types of the rows of scanRows, eg: to write the schema of an empty table
+func queryColumnTypes(db dbConn, table string, o *dumpOption) ([]*sql.ColumnType, error) {
+	rows, err := db.Query(fmt.Sprintf("%s * FROM %s LIMIT 0", o.selectKeyword(), sqlutil.QuoteIdentifier(table))) // ignore_security_alert_wait_for_fix SQL
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+	return rows.ColumnTypes()
+}
+
+func writeTableStruct(db dbConn, dbName, table string, buf *SafeWriter, o *dumpOption) error {
 	_, _ = buf.WriteString("-- ----------------------------\n")
 	_, _ = buf.WriteString(fmt.Sprintf("-- Table structure for %s\n", table))
 	_, _ = buf.WriteString("-- ----------------------------\n")
@@ -310,7 +1097,11 @@ func writeTableStruct(db *sql.DB, table string, buf *SafeWriter) error {
 		log.Printf("[error] %v \n", err)
 		return err
 	}
-	_, _ = buf.WriteString(createTableSQL)
+	if o.isSchemaLint {
+		o.lintTable(db, dbName, table)
+	}
+	_, _ = buf.WriteString(o.annotation(dbName, ObjectTable, table, 0))
+	_, _ = buf.WriteString(o.rewriteTableDDL(createTableSQL))
 	_, _ = buf.WriteString(";")
 
 	_, _ = buf.WriteString("\n\n")
@@ -318,18 +1109,22 @@ func writeTableStruct(db *sql.DB, table string, buf *SafeWriter) error {
 	return nil
 }
 
-func writeTableData(db *sql.DB, table, where string, buf *SafeWriter, withoutPrimaryID bool) error {
-	var (
-		writeCh = make(chan string, 1)
-		done    = make(chan struct{}, 1)
-	)
+func writeTableData(db dbConn, dbName, table, where string, buf *SafeWriter, o *dumpOption) (int, error) {
+	partitions := o.partitions[table]
 
 	_, _ = buf.WriteString("-- ----------------------------\n")
-	_, _ = buf.WriteString(fmt.Sprintf("-- Records of %s\n", table))
+	if len(partitions) > 0 {
+		_, _ = buf.WriteString(fmt.Sprintf("-- Records of %s, partitions %s\n", table, strings.Join(partitions, ", ")))
+	} else {
+		_, _ = buf.WriteString(fmt.Sprintf("-- Records of %s\n", table))
+	}
 	_, _ = buf.WriteString("-- ----------------------------\n")
 
 	lineRows, err := db.Query(func(table, where string) string {
-		dml := fmt.Sprintf("SELECT * FROM `%s`", table)
+		dml := fmt.Sprintf("%s * FROM %s", o.selectKeyword(), sqlutil.QuoteIdentifier(table))
+		if len(partitions) > 0 {
+			dml += " PARTITION (" + sqlutil.QuoteIdentifiers(partitions) + ")"
+		}
 		if strings.TrimSpace(where) != "" {
 			dml = fmt.Sprintf("%s where %s", dml, where)
 		}
@@ -337,7 +1132,7 @@ func writeTableData(db *sql.DB, table, where string, buf *SafeWriter, withoutPri
 	}(table, where)) // ignore_security_alert_wait_for_fix SQL
 	if err != nil {
 		log.Printf("[error] %v \n", err)
-		return err
+		return 0, err
 	}
 	defer func() {
 		_ = lineRows.Close()
@@ -347,19 +1142,36 @@ func writeTableData(db *sql.DB, table, where string, buf *SafeWriter, withoutPri
 	columns, err = lineRows.Columns()
 	if err != nil {
 		log.Printf("[error] %v \n", err)
-		return err
+		return 0, err
 	}
 	columnTypes, err := lineRows.ColumnTypes()
 	if err != nil {
 		log.Printf("[error] %v \n", err)
-		return err
+		return 0, err
+	}
+
+	// the rows above WithMaxRowBytes are split on the primary key
+	var primaryKey []string
+	if o.maxRowBytes > 0 && o.rowSizePolicy == RowSizeSplit {
+		primaryKey, err = getPrimaryKeyColumns(db, dbName, table)
+		if err != nil {
+			log.Printf("[error] %v \n", err)
+			return 0, err
+		}
+	}
+
+	var stats *columnStatsCollector
+	if o.isColumnStats && o.manifest != nil {
+		stats = newColumnStatsCollector(columnTypes)
 	}
 
-	go writeViaBuf(buf, writeCh, done)
+	queue := newWriteQueue(buf)
+	defer queue.close(o)
 
 	var row []interface{}
 	var rowPointers []interface{}
 	var dml string
+	var chunk int
 
 	for lineRows.Next() {
 		row = make([]interface{}, len(columns))
@@ -370,118 +1182,197 @@ func writeTableData(db *sql.DB, table, where string, buf *SafeWriter, withoutPri
 		err = lineRows.Scan(rowPointers...)
 		if err != nil {
 			log.Printf("[error] %v \n", err)
-			return err
+			return 0, err
 		}
 
-		dml = "INSERT INTO `" + table + "` VALUES ("
+		values, err := o.transformRow(table, columns, row)
+		if err != nil {
+			log.Printf("[error] %v \n", err)
+			return 0, err
+		}
 
-		for i, col := range row {
-			if col == nil {
-				dml += "NULL"
-			} else {
-				Type := columnTypes[i].DatabaseTypeName()
-				columnName := columnTypes[i].Name()
-				Type = strings.Replace(Type, "UNSIGNED", "", -1)
-				Type = strings.Replace(Type, " ", "", -1)
+		for _, row := range values {
+			if stats != nil {
+				stats.observe(row)
+			}
 
-				switch Type {
-				case "TINYINT", "SMALLINT", "MEDIUMINT", "INT", "INTEGER", "BIGINT":
-					if bs, ok := col.([]byte); ok {
-						if withoutPrimaryID && columnName == "id" {
+			var appends []string
+			if o.maxRowBytes > 0 {
+				var skip bool
+				row, appends, skip, err = o.limitRow(table, columnTypes, row, primaryKey)
+				if err != nil {
+					log.Printf("[error] %v \n", err)
+					return 0, err
+				}
+				if skip {
+					continue
+				}
+			}
+
+			chunk++
+			dml = o.partitionAnnotation(o.annotation(dbName, ObjectTable, table, chunk), partitions) + "INSERT INTO " + o.escapeTemplate(sqlutil.QuoteIdentifier(table)) + " VALUES ("
+
+			for i, col := range row {
+				if col == nil {
+					dml += "NULL"
+				} else if serialize, ok := columnSerializer(columnTypes[i].DatabaseTypeName()); ok {
+					literal, err := serialize(col)
+					if err != nil {
+						log.Printf("[error] %v \n", err)
+						return 0, err
+					}
+					dml += o.escapeTemplate(literal)
+				} else {
+					Type := normalizeTypeName(columnTypes[i].DatabaseTypeName())
+					columnName := columnTypes[i].Name()
+
+					switch Type {
+					case "TINYINT", "SMALLINT", "MEDIUMINT", "INT", "INTEGER", "BIGINT":
+						if o.withoutPrimaryID && columnName == "id" {
 							dml += "0"
 							break
 						}
-						dml += string(bs)
-					} else {
-						dml += fmt.Sprintf("%d", col)
-					}
-				case "FLOAT", "DOUBLE":
-					if bs, ok := col.([]byte); ok {
-						dml += string(bs)
-					} else {
-						dml += fmt.Sprintf("%f", col)
-					}
-				case "DECIMAL", "DEC":
-					dml += fmt.Sprintf("%s", col)
-
-				case "DATE":
-					t, ok := col.(time.Time)
-					if !ok {
-						log.Println("DATE type conversion error")
-						return err
-					}
-					dml += fmt.Sprintf("'%s'", t.Format("2006-01-02"))
-				case "DATETIME":
-					t, ok := col.(time.Time)
-					if !ok {
-						log.Println("DATETIME type conversion error")
-						return err
-					}
-					dml += fmt.Sprintf("'%s'", t.Format("2006-01-02 15:04:05"))
-				case "TIMESTAMP":
-					t, ok := col.(time.Time)
-					if !ok {
-						log.Println("TIMESTAMP type conversion error")
-						return err
-					}
-					dml += fmt.Sprintf("'%s'", t.Format("2006-01-02 15:04:05"))
-				case "TIME":
-					t, ok := col.([]byte)
-					if !ok {
-						log.Println("TIME type conversion error")
-						return err
-					}
-					dml += fmt.Sprintf("'%s'", string(t))
-				case "YEAR":
-					t, ok := col.([]byte)
-					if !ok {
-						log.Println("YEAR type conversion error")
-						return err
-					}
-					dml += string(t)
-				case "CHAR", "VARCHAR", "TINYTEXT", "TEXT", "MEDIUMTEXT", "LONGTEXT":
-					dml += fmt.Sprintf("'%s'", strings.Replace(fmt.Sprintf("%s", col), "'", "''", -1))
-				case "BIT", "BINARY", "VARBINARY", "TINYBLOB", "BLOB", "MEDIUMBLOB", "LONGBLOB":
-					dml += fmt.Sprintf("0x%X", col)
-				case "ENUM", "SET":
-					dml += fmt.Sprintf("'%s'", col)
-				case "BOOL", "BOOLEAN":
-					if col.(bool) {
-						dml += "true"
-					} else {
-						dml += "false"
+						// unsigned BIGINT values above math.MaxInt64 only fit in a uint64
+						switch v := col.(type) {
+						case []byte:
+							dml += string(v)
+						case uint64:
+							dml += strconv.FormatUint(v, 10)
+						case int64:
+							dml += strconv.FormatInt(v, 10)
+						default:
+							dml += fmt.Sprintf("%d", col)
+						}
+					case "FLOAT", "DOUBLE":
+						if bs, ok := col.([]byte); ok {
+							dml += string(bs)
+						} else {
+							dml += fmt.Sprintf("%f", col)
+						}
+					case "DECIMAL", "DEC", "NUMERIC", "FIXED":
+						// the exact text sent by the server, never through a float
+						dml += rawString(col)
+
+					case "DATE":
+						t, ok := col.(time.Time)
+						if !ok {
+							log.Println("DATE type conversion error")
+							return 0, err
+						}
+						dml += fmt.Sprintf("'%s'", t.Format("2006-01-02"))
+					case "DATETIME":
+						t, ok := col.(time.Time)
+						if !ok {
+							log.Println("DATETIME type conversion error")
+							return 0, err
+						}
+						dml += fmt.Sprintf("'%s'", t.Format("2006-01-02 15:04:05"))
+					case "TIMESTAMP":
+						t, ok := col.(time.Time)
+						if !ok {
+							log.Println("TIMESTAMP type conversion error")
+							return 0, err
+						}
+						dml += fmt.Sprintf("'%s'", t.Format("2006-01-02 15:04:05"))
+					case "TIME":
+						t, ok := col.([]byte)
+						if !ok {
+							log.Println("TIME type conversion error")
+							return 0, err
+						}
+						dml += fmt.Sprintf("'%s'", string(t))
+					case "YEAR":
+						t, ok := col.([]byte)
+						if !ok {
+							log.Println("YEAR type conversion error")
+							return 0, err
+						}
+						dml += string(t)
+					case "CHAR", "VARCHAR", "TINYTEXT", "TEXT", "MEDIUMTEXT", "LONGTEXT":
+						dml += sqlutil.QuoteString(o.template(rawString(col)))
+					case "BIT", "BINARY", "VARBINARY", "TINYBLOB", "BLOB", "MEDIUMBLOB", "LONGBLOB":
+						if bs, ok := col.([]byte); ok && len(bs) == 0 {
+							// 0x is not a valid literal, x'' is the empty binary string
+							dml += "x''"
+						} else if ok && len(bs) > largeValueSize {
+							// no giant string of the whole row, the hex goes out in pieces
+							queue.send(dml + "0x")
+							writeHexChunks(queue, bs)
+							dml = ""
+						} else {
+							dml += fmt.Sprintf("0x%X", col)
+						}
+					case "ENUM", "SET":
+						dml += sqlutil.QuoteString(o.escapeTemplate(rawString(col)))
+					case "BOOL", "BOOLEAN":
+						if col.(bool) {
+							dml += "true"
+						} else {
+							dml += "false"
+						}
+					case "JSON":
+						// quotes, backslashes and \u escapes of the document must survive
+						dml += sqlutil.QuoteString(o.escapeTemplate(rawString(col)))
+					default:
+						literal, err := typeHandlerValue(Type, columnTypes[i], col)
+						if err != nil {
+							log.Printf("[error] %v \n", err)
+							return 0, err
+						}
+						dml += o.escapeTemplate(literal)
 					}
-				case "JSON":
-					dml += fmt.Sprintf("'%s'", col)
-				default:
-					log.Printf("unsupported type: %s", Type)
-					return fmt.Errorf("unsupported type: %s", Type)
+				}
+				if i < len(row)-1 {
+					dml += ","
 				}
 			}
-			if i < len(row)-1 {
-				dml += ","
+
+			dml += ");\n"
+			queue.send(dml)
+			for _, update := range appends {
+				queue.send(o.escapeTemplate(update))
 			}
 		}
-
-		dml += ");\n"
-		writeCh <- dml
+	}
+	// some rows are written, the error is not retried by WithLockRetry
+	if err = lineRows.Err(); err != nil {
+		err = fmt.Errorf("read %s after %d rows: %v", table, chunk, err)
+		log.Printf("[error] %v \n", err)
+		return 0, err
 	}
 
+	queue.close(o)
 	_, _ = buf.WriteString("\n\n")
 
-	done <- struct{}{}
-
-	return nil
+	if stats != nil {
+		if o.manifest.ColumnStats == nil {
+			o.manifest.ColumnStats = make(map[string][]ColumnStats)
+		}
+		o.manifest.ColumnStats[dbName+"."+table] = stats.result()
+	}
+	return chunk, nil
 }
 
-func writeViaBuf(writer *SafeWriter, writeCh chan string, done chan struct{}) {
-	for {
-		select {
-		case data := <-writeCh:
-			_, _ = writer.WriteString(data)
-		case <-done:
-			_ = writer.Flush()
-			return
+// largeValueSize binary values above this size are written in hex chunks
+const largeValueSize = 1 << 20
+
+// writeHexChunks send the upper case hex of bs in chunks of largeValueSize hex digits
+func writeHexChunks(queue *writeQueue, bs []byte) {
+	for len(bs) > 0 {
+		n := largeValueSize / 2
+		if n > len(bs) {
+			n = len(bs)
 		}
+		queue.send(strings.ToUpper(hex.EncodeToString(bs[:n])))
+		bs = bs[n:]
+	}
+}
+
+// isDecimalType DECIMAL and its aliases, some servers and drivers report NUMERIC
+func isDecimalType(databaseTypeName string) bool {
+	switch strings.Replace(databaseTypeName, "UNSIGNED ", "", 1) {
+	case "DECIMAL", "DEC", "NUMERIC", "FIXED":
+		return true
 	}
+	return false
 }
@@ -0,0 +1,235 @@
+package mysqldump
+
+import (
+	"bytes"
+	"context"
+	"database/sql/driver"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+
+	"mysqldump/sqlutil"
+)
+
+// mockTable a table or view of the database mocked by mockDump
+type mockTable struct {
+	name    string
+	view    bool
+	ddl     string
+	columns []*sqlmock.Column
+	rows    [][]driver.Value
+}
+
+// mockQuote the identifier quoting the server expects, written out independently of
+// sqlutil.QuoteIdentifier
+func mockQuote(name string) string {
+	return "`" + strings.Replace(name, "`", "``", -1) + "`"
+}
+
+// mockDump dump the schema and the data of tables of database db on a go-sqlmock
+// connection, the dump must send exactly the expected queries
+func mockDump(t *testing.T, db string, tables []mockTable, opts ...DumpOption) string {
+	t.Helper()
+	var buf bytes.Buffer
+	err := mockRun(t, db, tables, append([]DumpOption{WithWriter(&buf)}, opts...)...)
+	if err != nil {
+		t.Fatalf("dump: %v", err)
+	}
+	return buf.String()
+}
+
+// mockRun the run of mockDump, the error of the run is returned
+func mockRun(t *testing.T, db string, tables []mockTable, opts ...DumpOption) error {
+	t.Helper()
+	conn, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = conn.Close()
+	}()
+
+	names := make([]string, len(tables))
+	views := sqlmock.NewRows([]string{"Tables_in_" + db, "Table_type"})
+	for i, table := range tables {
+		names[i] = table.name
+		if table.view {
+			views.AddRow(table.name, "VIEW")
+		}
+	}
+
+	mock.ExpectExec("USE " + mockQuote(db)).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery("SHOW FULL TABLES WHERE Table_type = 'VIEW'").WillReturnRows(views)
+	mock.ExpectQuery("SELECT TABLE_SCHEMA, TABLE_NAME, CONSTRAINT_NAME, COLUMN_NAME, REFERENCED_TABLE_SCHEMA, " +
+		"REFERENCED_TABLE_NAME, REFERENCED_COLUMN_NAME FROM information_schema.KEY_COLUMN_USAGE " +
+		"WHERE REFERENCED_TABLE_NAME IS NOT NULL AND TABLE_SCHEMA IN (?) " +
+		"ORDER BY TABLE_SCHEMA, TABLE_NAME, CONSTRAINT_NAME, ORDINAL_POSITION").
+		WithArgs(db).
+		WillReturnRows(sqlmock.NewRows([]string{"TABLE_SCHEMA", "TABLE_NAME", "CONSTRAINT_NAME", "COLUMN_NAME",
+			"REFERENCED_TABLE_SCHEMA", "REFERENCED_TABLE_NAME", "REFERENCED_COLUMN_NAME"}))
+	for _, table := range tables {
+		if table.view {
+			continue
+		}
+		mock.ExpectQuery("SHOW CREATE TABLE " + mockQuote(table.name)).
+			WillReturnRows(sqlmock.NewRows([]string{"Table", "Create Table"}).AddRow(table.name, table.ddl))
+		rows := sqlmock.NewRowsWithColumnDefinition(table.columns...)
+		for _, row := range table.rows {
+			rows.AddRow(row...)
+		}
+		mock.ExpectQuery("SELECT * FROM " + mockQuote(table.name)).WillReturnRows(rows)
+	}
+	for _, table := range tables {
+		if table.view {
+			mock.ExpectQuery("SHOW CREATE VIEW " + mockQuote(table.name)).
+				WillReturnRows(sqlmock.NewRows([]string{"View", "Create View", "character_set_client", "collation_connection"}).
+					AddRow(table.name, table.ddl, "utf8mb4", "utf8mb4_general_ci"))
+		}
+	}
+
+	opts = append([]DumpOption{WithDBs(db), WithTables(names...), WithDropTable(), WithDumpTable(), WithData()}, opts...)
+	runErr := NewDumperWithConn(conn, opts...).Run(context.Background())
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+	return runErr
+}
+
+// dumpedRows the rows of the INSERT statements of dump
+func dumpedRows(t *testing.T, dump string) [][]interface{} {
+	t.Helper()
+	stmts, err := sqlutil.SplitStatements(dump)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var rows [][]interface{}
+	for _, stmt := range stmts {
+		if sqlutil.StatementVerb(stmt) != "INSERT" {
+			continue
+		}
+		_, values, err := sqlutil.ParseInsertValues(stmt)
+		if err != nil {
+			t.Fatalf("parse %s: %v", stmt, err)
+		}
+		rows = append(rows, values...)
+	}
+	return rows
+}
+
+func TestDumpJSONValues(t *testing.T) {
+	docs := []string{
+		`{"quote": "it's \"quoted\""}`,
+		`{"path": "C:\\new\\table"}`,
+		`{"unicode": "\u00e9\u4e2d", "escape": "\\u0041"}`,
+		`{"nul": "\u0000", "control": "\n\t"}`,
+		`["\\", "\\\\", "'", "''", "\\'"]`,
+		"{\"raw\": \"a\x00b\"}",
+	}
+
+	table := mockTable{
+		name:    "doc",
+		ddl:     "CREATE TABLE `doc` (`doc` json DEFAULT NULL)",
+		columns: []*sqlmock.Column{sqlmock.NewColumn("doc").OfType("JSON", []byte{}).Nullable(true)},
+	}
+	for _, doc := range docs {
+		table.rows = append(table.rows, []driver.Value{[]byte(doc)})
+	}
+
+	rows := dumpedRows(t, mockDump(t, "shop", []mockTable{table}))
+	if len(rows) != len(docs) {
+		t.Fatalf("%d rows dumped, want %d", len(rows), len(docs))
+	}
+	for i, doc := range docs {
+		if rows[i][0] != doc {
+			t.Errorf("row %d = %q, want %q", i, rows[i][0], doc)
+		}
+	}
+}
+
+// dumpedValues the SQL of the values of the INSERT statements of dump
+func dumpedValues(t *testing.T, dump string) [][]string {
+	t.Helper()
+	stmts, err := sqlutil.SplitStatements(dump)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var rows [][]string
+	for _, stmt := range stmts {
+		if sqlutil.StatementVerb(stmt) != "INSERT" {
+			continue
+		}
+		values, err := sqlutil.SplitInsertValues(stmt)
+		if err != nil {
+			t.Fatalf("split %s: %v", stmt, err)
+		}
+		rows = append(rows, values.Rows...)
+	}
+	return rows
+}
+
+func TestDumpBinaryValues(t *testing.T) {
+	tests := []struct {
+		value driver.Value
+		want  string
+	}{
+		{[]byte{}, "x''"},
+		{nil, "NULL"},
+		{[]byte{0x00, 0xff, 0x27, 0x5c}, "0x00FF275C"},
+	}
+
+	table := mockTable{
+		name:    "blob",
+		ddl:     "CREATE TABLE `blob` (`data` varbinary(16) DEFAULT NULL)",
+		columns: []*sqlmock.Column{sqlmock.NewColumn("data").OfType("VARBINARY", []byte{}).Nullable(true)},
+	}
+	for _, test := range tests {
+		table.rows = append(table.rows, []driver.Value{test.value})
+	}
+
+	rows := dumpedValues(t, mockDump(t, "shop", []mockTable{table}))
+	if len(rows) != len(tests) {
+		t.Fatalf("%d rows dumped, want %d", len(rows), len(tests))
+	}
+	for i, test := range tests {
+		if rows[i][0] != test.want {
+			t.Errorf("%v dumped as %s, want %s", test.value, rows[i][0], test.want)
+		}
+	}
+}
+
+func TestDumpDecimalValues(t *testing.T) {
+	values := []string{
+		"12345678901234567890.123456789012345678901234567890",
+		"-0.000000000000000000000000000001",
+		"99999999999999999999999999999999999.999999999999999999999999999999",
+		"0.10",
+		"-1.000000000000000000000000000000",
+	}
+
+	// NUMERIC is a synonym of DECIMAL, the dump must not stop on it
+	for _, typeName := range []string{"DECIMAL", "NUMERIC"} {
+		t.Run(typeName, func(t *testing.T) {
+			table := mockTable{
+				name:    "amount",
+				ddl:     "CREATE TABLE `amount` (`amount` decimal(65,30) DEFAULT NULL)",
+				columns: []*sqlmock.Column{sqlmock.NewColumn("amount").OfType(typeName, []byte{}).Nullable(true)},
+			}
+			for _, value := range values {
+				table.rows = append(table.rows, []driver.Value{[]byte(value)})
+			}
+
+			rows := dumpedValues(t, mockDump(t, "shop", []mockTable{table}))
+			if len(rows) != len(values) {
+				t.Fatalf("%d rows dumped, want %d", len(rows), len(values))
+			}
+			for i, value := range values {
+				if rows[i][0] != value {
+					t.Errorf("%s dumped as %s", value, rows[i][0])
+				}
+			}
+		})
+	}
+}
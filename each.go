@@ -0,0 +1,93 @@
+package mysqldump
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"strings"
+	"time"
+)
+
+// EachReport the outcome of DumpEach, one entry per database in the order of the list
+type EachReport struct {
+	Databases []EachDatabase
+	// Failed the number of databases whose dump failed
+	Failed int
+}
+
+// EachDatabase the dump of a database of DumpEach
+type EachDatabase struct {
+	DB       string
+	Err      error
+	Duration time.Duration
+	// Warnings the warnings of the dump, see DumpResult
+	Warnings []string
+}
+
+// WithEachWriter the destination of the dump of each database of DumpEach, eg: a file
+// named after db. The writer is closed once the dump of db is done.
+func WithEachWriter(fn func(db string) (io.WriteCloser, error)) DumpOption {
+	return func(option *dumpOption) {
+		option.eachWriter = fn
+	}
+}
+
+// DumpEach dump each database of dbs to its own WithEachWriter writer, eg: the databases
+// of the tenants of a SaaS. The "{db}" of dsnPattern is replaced by the database, eg:
+// "user:pwd@tcp(127.0.0.1:3306)/{db}?charset=utf8mb4", a dsnPattern without it dumps
+// the databases through the same dsn. A failed database does not stop the others, the
+// report tells the outcome of each one and the error how many failed.
+func DumpEach(dsnPattern string, dbs []string, opts ...DumpOption) (*EachReport, error) {
+	var o dumpOption
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if o.eachWriter == nil {
+		err := errors.New("DumpEach needs WithEachWriter")
+		log.Printf("[error] %v \n", err)
+		return nil, err
+	}
+
+	report := &EachReport{}
+	for _, db := range dbs {
+		start := time.Now()
+		var result DumpResult
+		err := dumpOne(dsnPattern, db, o.eachWriter, &result, opts)
+		if err != nil {
+			log.Printf("[error] [each] %s: %v \n", db, err)
+			report.Failed++
+		}
+		report.Databases = append(report.Databases, EachDatabase{
+			DB:       db,
+			Err:      err,
+			Duration: time.Since(start),
+			Warnings: result.Warnings,
+		})
+	}
+
+	if report.Failed > 0 {
+		return report, fmt.Errorf("%d of %d databases failed", report.Failed, len(dbs))
+	}
+	return report, nil
+}
+
+// dumpOne the dump of db of DumpEach
+func dumpOne(dsnPattern, db string, eachWriter func(string) (io.WriteCloser, error), result *DumpResult, opts []DumpOption) (err error) {
+	writer, err := eachWriter(db)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if closeErr := writer.Close(); err == nil {
+			err = closeErr
+		}
+	}()
+
+	dns := strings.Replace(dsnPattern, "{db}", db, -1)
+	opts = append(opts[:len(opts):len(opts)], WithWriter(writer), WithDumpResult(result))
+	if dns == dsnPattern {
+		opts = append(opts, WithDBs(db))
+	}
+	return Dump(dns, opts...)
+}
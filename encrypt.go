@@ -0,0 +1,258 @@
+package mysqldump
+
+import (
+	"bufio"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// encryptMagic starts an encrypted dump, followed by the key id, the wrapped data key,
+// the nonce prefix and the sealed chunks
+const encryptMagic = "mysqldump-enc-v1\n"
+
+// encryptChunkSize plaintext bytes per sealed chunk
+const encryptChunkSize = 64 << 10
+
+// KMS wrap and unwrap the data keys of encrypted dumps, eg: implemented with the Encrypt and
+// Decrypt calls of AWS KMS or Google Cloud KMS, see NewVaultTransit for HashiCorp Vault.
+// Each dump has its own data key, wrapped by the key encryption key of the KMS: rotating
+// the key encryption key does not require re-encrypting the old dumps as long as the KMS
+// can unwrap with the previous versions.
+type KMS interface {
+	// WrapKey encrypt dataKey, keyID names the key encryption key and is given back to UnwrapKey
+	WrapKey(dataKey []byte) (wrapped []byte, keyID string, err error)
+	UnwrapKey(wrapped []byte, keyID string) ([]byte, error)
+}
+
+// WithEncryption encrypt the dump with AES-256-GCM under a data key wrapped by kms, load it
+// with WithSourceEncryption or NewDecryptReader
+func WithEncryption(kms KMS) DumpOption {
+	return func(option *dumpOption) {
+		option.kms = kms
+	}
+}
+
+// WithSourceEncryption decrypt a dump written with WithEncryption
+func WithSourceEncryption(kms KMS) SourceOption {
+	return func(o *sourceOption) {
+		o.kms = kms
+	}
+}
+
+// NewEncryptWriter encrypt what is written into w under a new data key wrapped by kms, Close
+// writes the last chunk, a dump without it is rejected as truncated
+func NewEncryptWriter(w io.Writer, kms KMS) (io.WriteCloser, error) {
+	dataKey := make([]byte, 32)
+	_, err := rand.Read(dataKey)
+	if err != nil {
+		return nil, err
+	}
+	wrapped, keyID, err := kms.WrapKey(dataKey)
+	if err != nil {
+		return nil, fmt.Errorf("wrap data key: %w", err)
+	}
+	if len(keyID) > 0xffff || len(wrapped) > 0xffff {
+		return nil, errors.New("wrapped data key too long")
+	}
+
+	aead, err := newAEAD(dataKey)
+	if err != nil {
+		return nil, err
+	}
+	e := &encryptWriter{w: w, aead: aead}
+	_, err = rand.Read(e.prefix[:])
+	if err != nil {
+		return nil, err
+	}
+
+	header := []byte(encryptMagic)
+	header = binary.BigEndian.AppendUint16(header, uint16(len(keyID)))
+	header = append(header, keyID...)
+	header = binary.BigEndian.AppendUint16(header, uint16(len(wrapped)))
+	header = append(header, wrapped...)
+	header = append(header, e.prefix[:]...)
+	_, err = w.Write(header)
+	if err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+type encryptWriter struct {
+	w       io.Writer
+	aead    cipher.AEAD
+	prefix  [4]byte
+	counter uint64
+	buf     []byte
+	closed  bool
+}
+
+// nonce the random prefix then the chunk counter, unique per data key
+func chunkNonce(prefix [4]byte, counter uint64) []byte {
+	nonce := make([]byte, 12)
+	copy(nonce, prefix[:])
+	binary.BigEndian.PutUint64(nonce[4:], counter)
+	return nonce
+}
+
+// seal write a chunk, the additional data marks the last one
+func (e *encryptWriter) seal(plaintext []byte, last bool) error {
+	ad := []byte{0}
+	if last {
+		ad[0] = 1
+	}
+	sealed := e.aead.Seal(nil, chunkNonce(e.prefix, e.counter), plaintext, ad)
+	e.counter++
+
+	_, err := e.w.Write(binary.BigEndian.AppendUint32(nil, uint32(len(sealed))))
+	if err != nil {
+		return err
+	}
+	_, err = e.w.Write(sealed)
+	return err
+}
+
+func (e *encryptWriter) Write(p []byte) (int, error) {
+	if e.closed {
+		return 0, errors.New("write to a closed encrypt writer")
+	}
+	e.buf = append(e.buf, p...)
+	// keep the tail buffered, the last chunk is sealed by Close
+	for len(e.buf) > encryptChunkSize {
+		err := e.seal(e.buf[:encryptChunkSize], false)
+		if err != nil {
+			return 0, err
+		}
+		e.buf = e.buf[encryptChunkSize:]
+	}
+	return len(p), nil
+}
+
+func (e *encryptWriter) Close() error {
+	if e.closed {
+		return nil
+	}
+	e.closed = true
+	return e.seal(e.buf, true)
+}
+
+// NewDecryptReader decrypt a dump written with WithEncryption or NewEncryptWriter, a
+// tampered or truncated dump is a read error
+func NewDecryptReader(r io.Reader, kms KMS) (io.Reader, error) {
+	br := bufio.NewReader(r)
+	magic := make([]byte, len(encryptMagic))
+	_, err := io.ReadFull(br, magic)
+	if err != nil || string(magic) != encryptMagic {
+		return nil, errors.New("not an encrypted dump")
+	}
+
+	readBytes := func() ([]byte, error) {
+		var n uint16
+		err := binary.Read(br, binary.BigEndian, &n)
+		if err != nil {
+			return nil, err
+		}
+		b := make([]byte, n)
+		_, err = io.ReadFull(br, b)
+		return b, err
+	}
+	keyID, err := readBytes()
+	if err != nil {
+		return nil, fmt.Errorf("read encryption header: %w", err)
+	}
+	wrapped, err := readBytes()
+	if err != nil {
+		return nil, fmt.Errorf("read encryption header: %w", err)
+	}
+	d := &decryptReader{r: br}
+	_, err = io.ReadFull(br, d.prefix[:])
+	if err != nil {
+		return nil, fmt.Errorf("read encryption header: %w", err)
+	}
+
+	dataKey, err := kms.UnwrapKey(wrapped, string(keyID))
+	if err != nil {
+		return nil, fmt.Errorf("unwrap data key: %w", err)
+	}
+	d.aead, err = newAEAD(dataKey)
+	if err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+type decryptReader struct {
+	r       io.Reader
+	aead    cipher.AEAD
+	prefix  [4]byte
+	counter uint64
+	buf     []byte
+	last    bool
+}
+
+func (d *decryptReader) Read(p []byte) (int, error) {
+	for len(d.buf) == 0 {
+		if d.last {
+			return 0, io.EOF
+		}
+		err := d.open()
+		if err != nil {
+			return 0, err
+		}
+	}
+	n := copy(p, d.buf)
+	d.buf = d.buf[n:]
+	return n, nil
+}
+
+// open read and authenticate the next chunk
+func (d *decryptReader) open() error {
+	var n uint32
+	err := binary.Read(d.r, binary.BigEndian, &n)
+	if err != nil {
+		return fmt.Errorf("encrypted dump truncated: %w", err)
+	}
+	if n > encryptChunkSize+uint32(d.aead.Overhead()) {
+		return errors.New("encrypted dump corrupted: chunk too large")
+	}
+	sealed := make([]byte, n)
+	_, err = io.ReadFull(d.r, sealed)
+	if err != nil {
+		return fmt.Errorf("encrypted dump truncated: %w", err)
+	}
+
+	nonce := chunkNonce(d.prefix, d.counter)
+	d.counter++
+	// a failed Open clears its destination, the ciphertext is kept for the second try
+	d.buf, err = d.aead.Open(nil, nonce, sealed, []byte{0})
+	if err != nil {
+		d.buf, err = d.aead.Open(nil, nonce, sealed, []byte{1})
+		if err != nil {
+			return errors.New("encrypted dump corrupted or tampered")
+		}
+		d.last = true
+
+		// nothing is authenticated after the last chunk
+		_, err = io.ReadFull(d.r, make([]byte, 1))
+		if err == nil {
+			return errors.New("encrypted dump corrupted: data after the last chunk")
+		}
+		if err != io.EOF {
+			return err
+		}
+	}
+	return nil
+}
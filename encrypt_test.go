@@ -0,0 +1,151 @@
+package mysqldump
+
+import (
+	"bytes"
+	"crypto/rand"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+// aeadKMS wrap the data keys with AES-GCM under kek
+type aeadKMS struct {
+	kek []byte
+}
+
+func (k aeadKMS) WrapKey(dataKey []byte) ([]byte, string, error) {
+	aead, err := newAEAD(k.kek)
+	if err != nil {
+		return nil, "", err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	_, err = rand.Read(nonce)
+	if err != nil {
+		return nil, "", err
+	}
+	return aead.Seal(nonce, nonce, dataKey, nil), "test-kek", nil
+}
+
+func (k aeadKMS) UnwrapKey(wrapped []byte, keyID string) ([]byte, error) {
+	if keyID != "test-kek" {
+		return nil, errors.New("unknown key " + keyID)
+	}
+	aead, err := newAEAD(k.kek)
+	if err != nil {
+		return nil, err
+	}
+	if len(wrapped) < aead.NonceSize() {
+		return nil, errors.New("wrapped key too short")
+	}
+	return aead.Open(nil, wrapped[:aead.NonceSize()], wrapped[aead.NonceSize():], nil)
+}
+
+func newAEADKMS(t *testing.T) aeadKMS {
+	t.Helper()
+	kek := make([]byte, 32)
+	_, err := rand.Read(kek)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return aeadKMS{kek: kek}
+}
+
+// encryptDump encrypt plaintext, it returns the offset of each sealed chunk too
+func encryptDump(t *testing.T, kms KMS, plaintext []byte) ([]byte, []int) {
+	t.Helper()
+	var buf bytes.Buffer
+	w, err := NewEncryptWriter(&buf, kms)
+	if err != nil {
+		t.Fatal(err)
+	}
+	headerSize := buf.Len()
+	_, err = w.Write(plaintext)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = w.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var offsets []int
+	sealedSize := encryptChunkSize + 16
+	for offset := headerSize; offset < buf.Len(); offset += 4 + sealedSize {
+		offsets = append(offsets, offset)
+	}
+	return buf.Bytes(), offsets
+}
+
+func decryptDump(kms KMS, data []byte) ([]byte, error) {
+	r, err := NewDecryptReader(bytes.NewReader(data), kms)
+	if err != nil {
+		return nil, err
+	}
+	return io.ReadAll(r)
+}
+
+func TestEncryptRoundTrip(t *testing.T) {
+	kms := newAEADKMS(t)
+	for _, size := range []int{0, 1, encryptChunkSize, 2*encryptChunkSize + 100} {
+		plaintext := bytes.Repeat([]byte("INSERT INTO `t` VALUES (1);\n"), size/28+1)[:size]
+		data, _ := encryptDump(t, kms, plaintext)
+		got, err := decryptDump(kms, data)
+		if err != nil {
+			t.Fatalf("%d bytes: %v", size, err)
+		}
+		if !bytes.Equal(got, plaintext) {
+			t.Errorf("%d bytes: the decrypted dump differs", size)
+		}
+	}
+}
+
+func TestDecryptTampered(t *testing.T) {
+	kms := newAEADKMS(t)
+	plaintext := bytes.Repeat([]byte("x"), 2*encryptChunkSize+100)
+	data, offsets := encryptDump(t, kms, plaintext)
+	if len(offsets) != 3 {
+		t.Fatalf("%d chunks, want 3", len(offsets))
+	}
+
+	tests := []struct {
+		name   string
+		tamper func(data []byte) []byte
+	}{
+		{"flipped byte", func(data []byte) []byte {
+			data[offsets[1]+100] ^= 0x01
+			return data
+		}},
+		{"reordered chunks", func(data []byte) []byte {
+			reordered := append([]byte{}, data[:offsets[0]]...)
+			reordered = append(reordered, data[offsets[1]:offsets[2]]...)
+			reordered = append(reordered, data[offsets[0]:offsets[1]]...)
+			return append(reordered, data[offsets[2]:]...)
+		}},
+		{"truncated chunk", func(data []byte) []byte {
+			return data[:len(data)-10]
+		}},
+		{"missing last chunk", func(data []byte) []byte {
+			return data[:offsets[2]]
+		}},
+		{"trailing bytes", func(data []byte) []byte {
+			return append(data, "x"...)
+		}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := decryptDump(kms, test.tamper(append([]byte{}, data...)))
+			if err == nil {
+				t.Fatalf("%d bytes decrypted, want an error", len(got))
+			}
+		})
+	}
+}
+
+func TestDecryptWrongKey(t *testing.T) {
+	data, _ := encryptDump(t, newAEADKMS(t), []byte("SELECT 1;\n"))
+	_, err := decryptDump(newAEADKMS(t), data)
+	if err == nil || !strings.Contains(err.Error(), "unwrap data key") {
+		t.Fatalf("err = %v, want the unwrap data key error", err)
+	}
+}
@@ -0,0 +1,210 @@
+package mysqldump
+
+import (
+	"fmt"
+	"html"
+	"io"
+	"log"
+	"regexp"
+	"strings"
+)
+
+// DiagramFormat the output format of GenerateERDiagram
+type DiagramFormat string
+
+const (
+	// DiagramDOT a Graphviz digraph, eg: render it with `dot -Tsvg`
+	DiagramDOT DiagramFormat = "dot"
+	// DiagramMermaid a Mermaid erDiagram, eg: embed it in a Markdown file
+	DiagramMermaid DiagramFormat = "mermaid"
+)
+
+// GenerateERDiagram write an entity relationship diagram of the selected tables: their
+// columns and the foreign keys between them. WithDBs, WithAllDatabases, WithTables and
+// WithAllTables select the tables, foreign keys to tables that are not selected are left out.
+func GenerateERDiagram(dns string, writer io.Writer, format DiagramFormat, opts ...DumpOption) error {
+	if format != DiagramDOT && format != DiagramMermaid {
+		return fmt.Errorf("unsupported diagram format: %s", format)
+	}
+
+	var o dumpOption
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	var loader *schemaLoader
+	var schemas []*tableSchema
+	var dbNames []string
+	err := forEachTable(dns, &o, func(db dbConn, dbName, table string) error {
+		if loader == nil {
+			loader = newSchemaLoader(db)
+		}
+		schema, err := loader.load(dbName, table)
+		if err != nil {
+			return err
+		}
+		schemas = append(schemas, schema)
+		dbNames = append(dbNames, dbName)
+		return nil
+	})
+	if err != nil {
+		log.Printf("[error] %v \n", err)
+		return err
+	}
+
+	// tables are named db.table as soon as more than one database is drawn
+	multiDB := false
+	for _, dbName := range dbNames {
+		if dbName != dbNames[0] {
+			multiDB = true
+		}
+	}
+	names := make(map[string]string, len(schemas))
+	for i, schema := range schemas {
+		names[dbNames[i]+"."+schema.name] = schema.name
+		if multiDB {
+			names[dbNames[i]+"."+schema.name] = dbNames[i] + "." + schema.name
+		}
+	}
+
+	buf := NewSafeWriterWithSize(writer, BufferSize)
+	if format == DiagramDOT {
+		writeDOTDiagram(buf, schemas, dbNames, names)
+	} else {
+		writeMermaidDiagram(buf, schemas, dbNames, names)
+	}
+	return buf.Flush()
+}
+
+func writeDOTDiagram(buf *SafeWriter, schemas []*tableSchema, dbNames []string, names map[string]string) {
+	_, _ = buf.WriteString("digraph schema {\n")
+	_, _ = buf.WriteString("    rankdir=LR;\n")
+	_, _ = buf.WriteString("    node [shape=plaintext];\n\n")
+	for i, schema := range schemas {
+		name := names[dbNames[i]+"."+schema.name]
+		keys := diagramKeys(schema)
+
+		var b strings.Builder
+		b.WriteString(`<table border="0" cellborder="1" cellspacing="0">`)
+		b.WriteString(fmt.Sprintf(`<tr><td bgcolor="lightgrey" colspan="2"><b>%s</b></td></tr>`, html.EscapeString(name)))
+		for _, column := range schema.columns {
+			columnName := html.EscapeString(column.name)
+			if strings.Contains(keys[column.name], "PK") {
+				columnName = "<u>" + columnName + "</u>"
+			}
+			b.WriteString(fmt.Sprintf(`<tr><td align="left">%s</td><td align="left">%s</td></tr>`,
+				columnName, html.EscapeString(column.columnType)))
+		}
+		b.WriteString(`</table>`)
+		_, _ = buf.WriteString(fmt.Sprintf("    %s [label=<%s>];\n", dotID(name), b.String()))
+	}
+
+	_, _ = buf.WriteString("\n")
+	for i, schema := range schemas {
+		for _, fk := range schema.foreignKeys {
+			parent, ok := names[fk.parent]
+			if !ok {
+				continue
+			}
+			_, _ = buf.WriteString(fmt.Sprintf("    %s -> %s [label=%s];\n", dotID(names[dbNames[i]+"."+schema.name]),
+				dotID(parent), dotID(strings.Join(fk.childColumns, ", "))))
+		}
+	}
+	_, _ = buf.WriteString("}\n")
+}
+
+func writeMermaidDiagram(buf *SafeWriter, schemas []*tableSchema, dbNames []string, names map[string]string) {
+	_, _ = buf.WriteString("erDiagram\n")
+	for i, schema := range schemas {
+		keys := diagramKeys(schema)
+		_, _ = buf.WriteString(fmt.Sprintf("    %s {\n", mermaidID(names[dbNames[i]+"."+schema.name])))
+		for _, column := range schema.columns {
+			line := fmt.Sprintf("        %s %s", mermaidID(column.columnType), mermaidID(column.name))
+			if keys[column.name] != "" {
+				line += " " + keys[column.name]
+			}
+			if column.comment != "" {
+				line += fmt.Sprintf(` "%s"`, strings.Replace(strings.Replace(column.comment, `"`, "'", -1), "\n", " ", -1))
+			}
+			_, _ = buf.WriteString(line + "\n")
+		}
+		_, _ = buf.WriteString("    }\n")
+	}
+
+	for i, schema := range schemas {
+		for _, fk := range schema.foreignKeys {
+			parent, ok := names[fk.parent]
+			if !ok {
+				continue
+			}
+			// a child row references at most one parent row, a parent has any number of children
+			cardinality := "||--o{"
+			if diagramNullable(schema, fk.childColumns) {
+				cardinality = "|o--o{"
+			}
+			_, _ = buf.WriteString(fmt.Sprintf("    %s %s %s : \"%s\"\n", mermaidID(parent), cardinality,
+				mermaidID(names[dbNames[i]+"."+schema.name]), strings.Join(fk.childColumns, ", ")))
+		}
+	}
+}
+
+// diagramKeys the PK, FK and UK markers of the columns of a table, eg: "PK, FK"
+func diagramKeys(schema *tableSchema) map[string]string {
+	markers := make(map[string][]string)
+	add := func(column, marker string) {
+		for _, m := range markers[column] {
+			if m == marker {
+				return
+			}
+		}
+		markers[column] = append(markers[column], marker)
+	}
+
+	for _, index := range schema.indexes {
+		for _, column := range index.columns {
+			if index.name == "PRIMARY" {
+				add(column, "PK")
+			} else if index.unique && len(index.columns) == 1 {
+				add(column, "UK")
+			}
+		}
+	}
+	for _, fk := range schema.foreignKeys {
+		for _, column := range fk.childColumns {
+			add(column, "FK")
+		}
+	}
+
+	keys := make(map[string]string, len(markers))
+	for column, m := range markers {
+		keys[column] = strings.Join(m, ", ")
+	}
+	return keys
+}
+
+func diagramNullable(schema *tableSchema, columns []string) bool {
+	for _, name := range columns {
+		for _, column := range schema.columns {
+			if column.name == name && column.nullable {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func dotID(s string) string {
+	return `"` + strings.Replace(strings.Replace(s, `\`, `\\`, -1), `"`, `\"`, -1) + `"`
+}
+
+var mermaidUnsafe = regexp.MustCompile(`[^A-Za-z0-9_()\[\]-]+`)
+
+// mermaidID Mermaid entity, attribute and type names only allow a few characters,
+// eg: "int unsigned" -> "int_unsigned"
+func mermaidID(s string) string {
+	s = mermaidUnsafe.ReplaceAllString(s, "_")
+	if s == "" {
+		return "_"
+	}
+	return s
+}
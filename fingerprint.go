@@ -0,0 +1,144 @@
+package mysqldump
+
+import (
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"log"
+	"regexp"
+	"strings"
+
+	"mysqldump/sqlutil"
+)
+
+var (
+	autoIncrementRe = regexp.MustCompile(`\s+AUTO_INCREMENT=\d+|\s*/\*T!\[auto_rand_base\] AUTO_RANDOM_BASE=\d+ \*/`)
+	whitespaceRe    = regexp.MustCompile(`\s+`)
+)
+
+// WithSchemaChangedSince only dump the tables whose fingerprint differs from snapshot,
+// snapshot is the result of a previous SchemaFingerprint call. Tables missing
+// from snapshot are considered changed.
+func WithSchemaChangedSince(snapshot map[string]string) DumpOption {
+	return func(option *dumpOption) {
+		option.schemaSnapshot = snapshot
+	}
+}
+
+// SchemaFingerprint returns a stable hash of the normalized DDL of each table and view,
+// keyed by "db.table". WithDBs, WithAllDatabases, WithTables and WithAllTables
+// select what is fingerprinted, like for Dump.
+func SchemaFingerprint(dns string, opts ...DumpOption) (map[string]string, error) {
+	var o dumpOption
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	// db in dsn by default
+	if len(o.dbs) == 0 {
+		dbName, err := GetDBNameFromDNS(dns)
+		if err != nil {
+			log.Printf("[error] %v \n", err)
+			return nil, err
+		}
+		o.dbs = []string{dbName}
+	}
+
+	db, err := sql.Open("mysql", dns)
+	if err != nil {
+		log.Printf("[error] %v \n", err)
+		return nil, err
+	}
+	defer func() {
+		_ = db.Close()
+	}()
+
+	fingerprints, err := schemaFingerprint(db, &o)
+	if err != nil {
+		log.Printf("[error] %v \n", err)
+		return nil, err
+	}
+	return fingerprints, nil
+}
+
+// schemaFingerprint the fingerprints of the tables of the databases of o, on one connection of db
+func schemaFingerprint(db *sql.DB, o *dumpOption) (map[string]string, error) {
+	// USE must apply to the following queries
+	db.SetMaxOpenConns(1)
+
+	var err error
+	dbs := o.dbs
+	if o.isAllDB {
+		dbs, err = getDBs(db)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	fingerprints := make(map[string]string)
+	for _, dbStr := range dbs {
+		_, err = db.Exec("USE " + sqlutil.QuoteIdentifier(dbStr))
+		if err != nil {
+			return nil, err
+		}
+
+		tables := o.tables
+		if o.isAllTable || len(o.tables) == 0 {
+			tables, err = getAllTables(db)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		views, err := getViews(db)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, table := range tables {
+			fingerprint, err := getTableFingerprint(db, table, views[table])
+			if err != nil {
+				return nil, err
+			}
+			fingerprints[dbStr+"."+table] = fingerprint
+		}
+	}
+
+	return fingerprints, nil
+}
+
+func getTableFingerprint(db dbConn, table string, isView bool) (string, error) {
+	var ddl string
+	var err error
+	if isView {
+		ddl, err = getCreateViewSQL(db, table)
+	} else {
+		ddl, err = getCreateTableSQL(db, table)
+	}
+	if err != nil {
+		return "", err
+	}
+	return fingerprintDDL(ddl), nil
+}
+
+// fingerprintDDL hash of ddl ignoring the AUTO_INCREMENT counter and whitespace
+func fingerprintDDL(ddl string) string {
+	ddl = autoIncrementRe.ReplaceAllString(ddl, "")
+	ddl = whitespaceRe.ReplaceAllString(strings.TrimSpace(ddl), " ")
+	sum := sha256.Sum256([]byte(ddl))
+	return hex.EncodeToString(sum[:])
+}
+
+func filterChangedTables(db dbConn, dbName string, tables []string, views map[string]bool, snapshot map[string]string) ([]string, error) {
+	var changed []string
+	for _, table := range tables {
+		fingerprint, err := getTableFingerprint(db, table, views[table])
+		if err != nil {
+			return nil, err
+		}
+		if snapshot[dbName+"."+table] != fingerprint {
+			changed = append(changed, table)
+		}
+	}
+	return changed, nil
+}
@@ -0,0 +1,67 @@
+package mysqldump
+
+import (
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestSchemaFingerprintOneConnection(t *testing.T) {
+	conn, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = conn.Close()
+	}()
+	mock.MatchExpectationsInOrder(true)
+
+	ddl := map[string]string{
+		"shop.order": "CREATE TABLE `order` (\n  `id` int NOT NULL\n) ENGINE=InnoDB AUTO_INCREMENT=42",
+		"shop.v":     "CREATE VIEW `v` AS select `id` from `order`",
+		"crm.user":   "CREATE TABLE `user` (`id` int)",
+	}
+	// USE is followed by the queries of its database
+	mock.ExpectExec("USE `shop`").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery("SHOW TABLES").WillReturnRows(sqlmock.NewRows([]string{"Tables_in_shop"}).AddRow("order").AddRow("v"))
+	mock.ExpectQuery("SHOW FULL TABLES WHERE Table_type = 'VIEW'").
+		WillReturnRows(sqlmock.NewRows([]string{"Tables_in_shop", "Table_type"}).AddRow("v", "VIEW"))
+	mock.ExpectQuery("SHOW CREATE TABLE `order`").
+		WillReturnRows(sqlmock.NewRows([]string{"Table", "Create Table"}).AddRow("order", ddl["shop.order"]))
+	mock.ExpectQuery("SHOW CREATE VIEW `v`").
+		WillReturnRows(sqlmock.NewRows([]string{"View", "Create View", "character_set_client", "collation_connection"}).
+			AddRow("v", ddl["shop.v"], "utf8mb4", "utf8mb4_general_ci"))
+	mock.ExpectExec("USE `crm`").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery("SHOW TABLES").WillReturnRows(sqlmock.NewRows([]string{"Tables_in_crm"}).AddRow("user"))
+	mock.ExpectQuery("SHOW FULL TABLES WHERE Table_type = 'VIEW'").
+		WillReturnRows(sqlmock.NewRows([]string{"Tables_in_crm", "Table_type"}))
+	mock.ExpectQuery("SHOW CREATE TABLE `user`").
+		WillReturnRows(sqlmock.NewRows([]string{"Table", "Create Table"}).AddRow("user", ddl["crm.user"]))
+
+	fingerprints, err := schemaFingerprint(conn, &dumpOption{dbs: []string{"shop", "crm"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+	if max := conn.Stats().MaxOpenConnections; max != 1 {
+		t.Errorf("%d connections allowed, USE only applies to its own", max)
+	}
+
+	if len(fingerprints) != len(ddl) {
+		t.Fatalf("fingerprints = %v, want the ones of %d tables", fingerprints, len(ddl))
+	}
+	// the tables are fingerprinted as dumped, with IF NOT EXISTS, without the AUTO_INCREMENT
+	// counter and the whitespace
+	want := map[string]string{
+		"shop.order": "CREATE TABLE IF NOT EXISTS `order` ( `id` int NOT NULL ) ENGINE=InnoDB",
+		"shop.v":     ddl["shop.v"],
+		"crm.user":   "CREATE TABLE IF NOT EXISTS `user` (`id` int)",
+	}
+	for table, create := range want {
+		if fingerprints[table] != fingerprintDDL(create) {
+			t.Errorf("%s: fingerprint %s, want the one of %s", table, fingerprints[table], create)
+		}
+	}
+}
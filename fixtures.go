@@ -0,0 +1,228 @@
+package mysqldump
+
+import (
+	"database/sql"
+	"fmt"
+	"io"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"mysqldump/sqlutil"
+)
+
+// GenerateGoFixtures write the rows of the selected tables as Go source of package pkg,
+// one struct type and one slice of it per table, eg: for table user
+//
+//	type User struct {
+//		ID   int64
+//		Name string
+//	}
+//
+//	var UserFixtures = []User{
+//		{ID: 1, Name: "andrew"},
+//	}
+//
+// WithDBs, WithAllDatabases, WithTables, WithAllTables and WithWhere select the rows.
+func GenerateGoFixtures(dns string, writer io.Writer, pkg string, opts ...DumpOption) error {
+	var o dumpOption
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	buf := NewSafeWriterWithSize(writer, BufferSize)
+	defer func() {
+		_ = buf.Flush()
+	}()
+
+	_, _ = buf.WriteString("// Code generated by mysqldump. DO NOT EDIT.\n\n")
+	_, _ = buf.WriteString(fmt.Sprintf("package %s\n\n", pkg))
+
+	// prefix the names with the database when several databases are exported
+	prefixDB := o.isAllDB || len(o.dbs) > 1
+
+	err := forEachTable(dns, &o, func(db dbConn, dbName, table string) error {
+		name := goIdentifier(table)
+		if prefixDB {
+			name = goIdentifier(dbName) + name
+		}
+
+		columnTypes, err := getColumnTypes(db, table)
+		if err != nil {
+			return err
+		}
+		fields := goFieldNames(columnTypes)
+		_, _ = buf.WriteString(goStruct(name, fields, columnTypes))
+
+		var lines []string
+		err = scanRows(db, table, &o, func(columnTypes []*sql.ColumnType, row []interface{}) error {
+			var values []string
+			for i, col := range row {
+				if col == nil {
+					continue
+				}
+				value, err := goValue(columnTypes[i], col)
+				if err != nil {
+					return err
+				}
+				values = append(values, fmt.Sprintf("%s: %s", fields[i], value))
+			}
+			lines = append(lines, fmt.Sprintf("\t{%s},\n", strings.Join(values, ", ")))
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		_, _ = buf.WriteString(fmt.Sprintf("var %sFixtures = []%s{\n", name, name))
+		for _, line := range lines {
+			_, _ = buf.WriteString(line)
+		}
+		_, _ = buf.WriteString("}\n\n")
+		return nil
+	})
+	if err != nil {
+		log.Printf("[error] %v \n", err)
+		return err
+	}
+
+	return buf.Flush()
+}
+
+func getColumnTypes(db dbConn, table string) ([]*sql.ColumnType, error) {
+	rows, err := db.Query(fmt.Sprintf("SELECT * FROM %s LIMIT 0", sqlutil.QuoteIdentifier(table))) // ignore_security_alert_wait_for_fix SQL
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+	return rows.ColumnTypes()
+}
+
+func goStruct(name string, fields []string, columnTypes []*sql.ColumnType) string {
+	var builder strings.Builder
+	builder.WriteString(fmt.Sprintf("type %s struct {\n", name))
+	for i, columnType := range columnTypes {
+		typ := goType(columnType)
+		if nullable, ok := columnType.Nullable(); !ok || nullable {
+			typ = "*" + typ
+		}
+		builder.WriteString(fmt.Sprintf("\t%s %s\n", fields[i], typ))
+	}
+	builder.WriteString("}\n\n")
+	return builder.String()
+}
+
+func goFieldNames(columnTypes []*sql.ColumnType) []string {
+	fields := make([]string, len(columnTypes))
+	seen := make(map[string]bool)
+	for i, columnType := range columnTypes {
+		field := goIdentifier(columnType.Name())
+		for seen[field] {
+			field += "_"
+		}
+		seen[field] = true
+		fields[i] = field
+	}
+	return fields
+}
+
+var goInitialisms = map[string]string{
+	"id":   "ID",
+	"ip":   "IP",
+	"url":  "URL",
+	"uri":  "URI",
+	"uuid": "UUID",
+	"json": "JSON",
+	"http": "HTTP",
+	"api":  "API",
+	"sql":  "SQL",
+}
+
+// goIdentifier convert a snake_case name to an exported Go identifier, eg: user_id -> UserID
+func goIdentifier(name string) string {
+	var builder strings.Builder
+	for _, part := range strings.FieldsFunc(name, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		if initialism, ok := goInitialisms[strings.ToLower(part)]; ok {
+			builder.WriteString(initialism)
+			continue
+		}
+		runes := []rune(part)
+		builder.WriteString(strings.ToUpper(string(runes[0])) + string(runes[1:]))
+	}
+
+	identifier := builder.String()
+	if identifier == "" || !unicode.IsLetter([]rune(identifier)[0]) {
+		identifier = "X" + identifier
+	}
+	return identifier
+}
+
+func goType(columnType *sql.ColumnType) string {
+	typ := columnType.DatabaseTypeName()
+	unsigned := strings.Contains(typ, "UNSIGNED")
+	typ = strings.TrimSpace(strings.Replace(typ, "UNSIGNED", "", -1))
+
+	switch typ {
+	case "TINYINT", "SMALLINT", "MEDIUMINT", "INT", "INTEGER", "BIGINT", "YEAR":
+		if unsigned {
+			return "uint64"
+		}
+		return "int64"
+	case "FLOAT", "DOUBLE":
+		return "float64"
+	case "BOOL", "BOOLEAN":
+		return "bool"
+	case "BIT", "BINARY", "VARBINARY", "TINYBLOB", "BLOB", "MEDIUMBLOB", "LONGBLOB":
+		return "[]byte"
+	default:
+		// DECIMAL keeps its precision as a string, temporal types use their SQL text
+		return "string"
+	}
+}
+
+// goValue the Go literal of a non-NULL column value
+func goValue(columnType *sql.ColumnType, col interface{}) (string, error) {
+	typ := goType(columnType)
+
+	var literal string
+	switch typ {
+	case "[]byte":
+		literal = fmt.Sprintf("[]byte(%q)", rawString(col))
+	case "string":
+		literal = strconv.Quote(rawString(col))
+	case "bool":
+		s := rawString(col)
+		literal = strconv.FormatBool(s == "1" || s == "true")
+	default:
+		literal = rawString(col)
+		if _, err := strconv.ParseFloat(literal, 64); err != nil {
+			return "", fmt.Errorf("column %s: invalid number %q", columnType.Name(), literal)
+		}
+	}
+
+	// a pointer literal that needs no helper, so generated files of one package do not clash
+	if nullable, ok := columnType.Nullable(); !ok || nullable {
+		literal = fmt.Sprintf("&[]%s{%s}[0]", typ, literal)
+	}
+	return literal, nil
+}
+
+// rawString the text of a scanned value
+func rawString(col interface{}) string {
+	switch v := col.(type) {
+	case []byte:
+		return string(v)
+	case string:
+		return v
+	case time.Time:
+		return v.Format("2006-01-02 15:04:05")
+	default:
+		return fmt.Sprintf("%v", v)
+	}
+}
@@ -0,0 +1,130 @@
+package mysqldump
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+)
+
+// FormatVersion the version of the format of the dumps written by Dump, announced by the
+// first line of the dump, eg: "-- mysqldump-go format=2 features=annotations,templates".
+// The dumps of earlier versions of the package have no such line and are format 1.
+const FormatVersion = 2
+
+// formatHeaderPrefix the beginning of the format line
+const formatHeaderPrefix = "-- mysqldump-go "
+
+// the features of a dump Source has to know about
+const (
+	// FeatureAnnotations the statements are prefixed with comments, see WithAnnotations
+	FeatureAnnotations = "annotations"
+	// FeatureTemplates the dump holds placeholders, see WithTemplateVars
+	FeatureTemplates = "templates"
+	// FeatureSplitRows rows above a size are completed by UPDATE statements, see WithMaxRowBytes
+	FeatureSplitRows = "split-rows"
+	// FeatureSchemaFirst all the schemas come before the data, see WithSchemaFirst
+	FeatureSchemaFirst = "schema-first"
+	// FeatureBundle the dump is a bundle of some tables, see WithTagBundle
+	FeatureBundle = "bundle"
+)
+
+var knownFeatures = map[string]bool{
+	FeatureAnnotations: true,
+	FeatureTemplates:   true,
+	FeatureSplitRows:   true,
+	FeatureSchemaFirst: true,
+	FeatureBundle:      true,
+}
+
+// DumpFormat the format of a dump, see FormatVersion
+type DumpFormat struct {
+	Version  int
+	Features []string
+}
+
+// Has whether the dump has feature
+func (f DumpFormat) Has(feature string) bool {
+	for _, name := range f.Features {
+		if name == feature {
+			return true
+		}
+	}
+	return false
+}
+
+// String the format line of f
+func (f DumpFormat) String() string {
+	line := formatHeaderPrefix + "format=" + strconv.Itoa(f.Version)
+	if len(f.Features) > 0 {
+		line += " features=" + strings.Join(f.Features, ",")
+	}
+	return line
+}
+
+// dumpFormat the format of the dumps written with o
+func (o *dumpOption) dumpFormat() DumpFormat {
+	format := DumpFormat{Version: FormatVersion}
+	if o.isAnnotations {
+		format.Features = append(format.Features, FeatureAnnotations)
+	}
+	if o.templateVars != nil {
+		format.Features = append(format.Features, FeatureTemplates)
+	}
+	if o.maxRowBytes > 0 && o.rowSizePolicy == RowSizeSplit {
+		format.Features = append(format.Features, FeatureSplitRows)
+	}
+	if o.isSchemaFirst {
+		format.Features = append(format.Features, FeatureSchemaFirst)
+	}
+	return format
+}
+
+// parseDumpFormat the format announced by the leading comments of the first statement
+// of a dump, format 1 when there is none
+func parseDumpFormat(stmt string) DumpFormat {
+	for _, line := range strings.Split(stmt, "\n") {
+		line = strings.TrimSpace(line)
+		if !strings.HasPrefix(line, "--") && !strings.HasPrefix(line, "#") && line != "" {
+			break
+		}
+		if !strings.HasPrefix(line, formatHeaderPrefix) {
+			continue
+		}
+
+		format := DumpFormat{Version: 1}
+		for _, field := range strings.Fields(line[len(formatHeaderPrefix):]) {
+			switch {
+			case strings.HasPrefix(field, "format="):
+				format.Version, _ = strconv.Atoi(strings.TrimPrefix(field, "format="))
+			case strings.HasPrefix(field, "features="):
+				format.Features = strings.Split(strings.TrimPrefix(field, "features="), ",")
+			}
+		}
+		return format
+	}
+	return DumpFormat{Version: 1}
+}
+
+// checkFormat detect the format of the dump from its first statement, a dump of a newer
+// format than FormatVersion is refused
+func (o *sourceOption) checkFormat(stmt string) error {
+	format := parseDumpFormat(stmt)
+	o.format = format
+	if o.result != nil {
+		o.result.Format = format
+	}
+	if format.Version > FormatVersion {
+		return fmt.Errorf("the dump is of format %d, this version of mysqldump reads up to format %d", format.Version, FormatVersion)
+	}
+
+	for _, feature := range format.Features {
+		if !knownFeatures[feature] {
+			log.Printf("[warn] the dump has the unknown feature %s\n", feature)
+		}
+	}
+	if format.Has(FeatureTemplates) && o.templateVars == nil {
+		log.Printf("[warn] the dump holds placeholders, they are loaded as is without WithSourceTemplateVars\n")
+	}
+	return nil
+}
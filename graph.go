@@ -0,0 +1,391 @@
+package mysqldump
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+type ObjectType string
+
+const (
+	ObjectTable     ObjectType = "TABLE"
+	ObjectView      ObjectType = "VIEW"
+	ObjectTrigger   ObjectType = "TRIGGER"
+	ObjectProcedure ObjectType = "PROCEDURE"
+	ObjectFunction  ObjectType = "FUNCTION"
+)
+
+// Object a schema object, Name is unique per DB and Type family
+type Object struct {
+	Type ObjectType
+	DB   string
+	Name string
+}
+
+func (o Object) String() string {
+	return o.DB + "." + o.Name
+}
+
+type DependencyKind string
+
+const (
+	// DependencyForeignKey a table references another table
+	DependencyForeignKey DependencyKind = "FOREIGN KEY"
+	// DependencyView a view selects from a table or view
+	DependencyView DependencyKind = "VIEW"
+	// DependencyTrigger a trigger is defined on a table
+	DependencyTrigger DependencyKind = "TRIGGER"
+	// DependencyRoutine a routine body mentions a table or view, best effort
+	DependencyRoutine DependencyKind = "ROUTINE"
+)
+
+// Dependency From needs To to exist
+type Dependency struct {
+	From Object
+	To   Object
+	Kind DependencyKind
+}
+
+// DependencyGraph the objects of the dumped databases and their dependencies
+type DependencyGraph struct {
+	Objects      []Object
+	Dependencies []Dependency
+}
+
+// DependenciesOf the objects o depends on
+func (g *DependencyGraph) DependenciesOf(o Object) []Object {
+	var objects []Object
+	for _, dep := range g.Dependencies {
+		if dep.From == o {
+			objects = append(objects, dep.To)
+		}
+	}
+	return objects
+}
+
+// DependentsOf the objects depending on o
+func (g *DependencyGraph) DependentsOf(o Object) []Object {
+	var objects []Object
+	for _, dep := range g.Dependencies {
+		if dep.To == o {
+			objects = append(objects, dep.From)
+		}
+	}
+	return objects
+}
+
+// Sort the objects so that each one comes after its dependencies, objects which are part
+// of a cycle (eg: tables referencing each other) are returned in cycles
+func (g *DependencyGraph) Sort() (sorted []Object, cycles [][]Object) {
+	index := make(map[Object]int, len(g.Objects))
+	for i, o := range g.Objects {
+		index[o] = i
+	}
+	edges := make([][]int, len(g.Objects))
+	for _, dep := range g.Dependencies {
+		from, ok1 := index[dep.From]
+		to, ok2 := index[dep.To]
+		if ok1 && ok2 && from != to {
+			edges[from] = append(edges[from], to)
+		}
+	}
+
+	// Tarjan's strongly connected components come out in reverse topological order,
+	// which is dependencies first since edges point to dependencies
+	var (
+		counter int
+		stack   []int
+		onStack = make([]bool, len(g.Objects))
+		indexes = make([]int, len(g.Objects))
+		lowLink = make([]int, len(g.Objects))
+	)
+	for i := range indexes {
+		indexes[i] = -1
+	}
+
+	var strongConnect func(v int)
+	strongConnect = func(v int) {
+		indexes[v] = counter
+		lowLink[v] = counter
+		counter++
+		stack = append(stack, v)
+		onStack[v] = true
+
+		for _, w := range edges[v] {
+			if indexes[w] == -1 {
+				strongConnect(w)
+				if lowLink[w] < lowLink[v] {
+					lowLink[v] = lowLink[w]
+				}
+			} else if onStack[w] && indexes[w] < lowLink[v] {
+				lowLink[v] = indexes[w]
+			}
+		}
+
+		if lowLink[v] == indexes[v] {
+			var component []Object
+			for {
+				w := stack[len(stack)-1]
+				stack = stack[:len(stack)-1]
+				onStack[w] = false
+				component = append(component, g.Objects[w])
+				if w == v {
+					break
+				}
+			}
+			if len(component) > 1 {
+				cycles = append(cycles, component)
+			}
+			sorted = append(sorted, component...)
+		}
+	}
+
+	for v := range g.Objects {
+		if indexes[v] == -1 {
+			strongConnect(v)
+		}
+	}
+	return sorted, cycles
+}
+
+// LoadDependencyGraph read the tables, views, triggers and routines of the databases
+// selected by WithDBs or WithAllDatabases (the database of dns by default) and their dependencies
+func LoadDependencyGraph(dns string, opts ...DumpOption) (*DependencyGraph, error) {
+	var o dumpOption
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	if len(o.dbs) == 0 {
+		dbName, err := GetDBNameFromDNS(dns)
+		if err != nil {
+			log.Printf("[error] %v \n", err)
+			return nil, err
+		}
+		o.dbs = []string{dbName}
+	}
+
+	db, err := sql.Open("mysql", dns)
+	if err != nil {
+		log.Printf("[error] %v \n", err)
+		return nil, err
+	}
+	defer func() {
+		_ = db.Close()
+	}()
+
+	dbs := o.dbs
+	if o.isAllDB {
+		dbs, err = getDBs(db)
+		if err != nil {
+			log.Printf("[error] %v \n", err)
+			return nil, err
+		}
+	}
+
+	graph, err := loadDependencyGraph(db, dbs)
+	if err != nil {
+		log.Printf("[error] %v \n", err)
+		return nil, err
+	}
+	return graph, nil
+}
+
+func loadDependencyGraph(db dbConn, dbs []string) (*DependencyGraph, error) {
+	in := "(" + strings.TrimSuffix(strings.Repeat("?,", len(dbs)), ",") + ")"
+	args := make([]interface{}, len(dbs))
+	for i, dbName := range dbs {
+		args[i] = dbName
+	}
+
+	graph := &DependencyGraph{}
+	known := make(map[Object]bool)
+	add := func(o Object) {
+		if !known[o] {
+			known[o] = true
+			graph.Objects = append(graph.Objects, o)
+		}
+	}
+	// tables and views share a namespace
+	relation := func(dbName, name string) Object {
+		if known[Object{Type: ObjectView, DB: dbName, Name: name}] {
+			return Object{Type: ObjectView, DB: dbName, Name: name}
+		}
+		return Object{Type: ObjectTable, DB: dbName, Name: name}
+	}
+
+	err := queryRows(db, "SELECT TABLE_SCHEMA, TABLE_NAME, TABLE_TYPE FROM information_schema.TABLES "+
+		"WHERE TABLE_SCHEMA IN "+in+" ORDER BY TABLE_SCHEMA, TABLE_NAME", args, func(values []string) {
+		typ := ObjectTable
+		if values[2] == "VIEW" {
+			typ = ObjectView
+		}
+		add(Object{Type: typ, DB: values[0], Name: values[1]})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	err = queryRows(db, "SELECT CONSTRAINT_SCHEMA, TABLE_NAME, UNIQUE_CONSTRAINT_SCHEMA, REFERENCED_TABLE_NAME "+
+		"FROM information_schema.REFERENTIAL_CONSTRAINTS WHERE CONSTRAINT_SCHEMA IN "+in, args, func(values []string) {
+		graph.Dependencies = append(graph.Dependencies, Dependency{
+			From: Object{Type: ObjectTable, DB: values[0], Name: values[1]},
+			To:   Object{Type: ObjectTable, DB: values[2], Name: values[3]},
+			Kind: DependencyForeignKey,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	relations := append([]Object(nil), graph.Objects...)
+
+	err = queryRows(db, "SELECT TABLE_SCHEMA, TABLE_NAME, VIEW_DEFINITION FROM information_schema.VIEWS "+
+		"WHERE TABLE_SCHEMA IN "+in, args, func(values []string) {
+		view := Object{Type: ObjectView, DB: values[0], Name: values[1]}
+		for _, to := range referencedRelations(values[2], values[0], relations) {
+			if to != view {
+				graph.Dependencies = append(graph.Dependencies, Dependency{From: view, To: to, Kind: DependencyView})
+			}
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	err = queryRows(db, "SELECT TRIGGER_SCHEMA, TRIGGER_NAME, EVENT_OBJECT_SCHEMA, EVENT_OBJECT_TABLE "+
+		"FROM information_schema.TRIGGERS WHERE TRIGGER_SCHEMA IN "+in, args, func(values []string) {
+		trigger := Object{Type: ObjectTrigger, DB: values[0], Name: values[1]}
+		add(trigger)
+		graph.Dependencies = append(graph.Dependencies, Dependency{From: trigger, To: relation(values[2], values[3]), Kind: DependencyTrigger})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	err = queryRows(db, "SELECT ROUTINE_SCHEMA, ROUTINE_NAME, ROUTINE_TYPE, ROUTINE_DEFINITION "+
+		"FROM information_schema.ROUTINES WHERE ROUTINE_SCHEMA IN "+in, args, func(values []string) {
+		routine := Object{Type: ObjectType(values[2]), DB: values[0], Name: values[1]}
+		add(routine)
+		for _, to := range referencedRelations(values[3], values[0], relations) {
+			graph.Dependencies = append(graph.Dependencies, Dependency{From: routine, To: to, Kind: DependencyRoutine})
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(graph.Dependencies, func(i, j int) bool {
+		return graph.Dependencies[i].From.String() < graph.Dependencies[j].From.String()
+	})
+	return graph, nil
+}
+
+var identifierRe = regexp.MustCompile("`((?:[^`]|``)+)`(?:\\.`((?:[^`]|``)+)`)?|\\b([A-Za-z_$][A-Za-z0-9_$]*)(?:\\.([A-Za-z_$][A-Za-z0-9_$]*))?\\b")
+
+// referencedRelations the tables and views among relations mentioned in definition,
+// unqualified names are resolved in defaultDB
+func referencedRelations(definition, defaultDB string, relations []Object) []Object {
+	names := make(map[string]bool)
+	for _, m := range identifierRe.FindAllStringSubmatch(definition, -1) {
+		switch {
+		case m[2] != "":
+			names[m[1]+"."+m[2]] = true
+		case m[1] != "":
+			names[defaultDB+"."+m[1]] = true
+		case m[4] != "":
+			names[m[3]+"."+m[4]] = true
+		case m[3] != "":
+			names[defaultDB+"."+m[3]] = true
+		}
+	}
+
+	var referenced []Object
+	for _, o := range relations {
+		if names[o.String()] {
+			referenced = append(referenced, o)
+		}
+	}
+	return referenced
+}
+
+// queryRows run query and call fn with the values of each row, NULL is ""
+func queryRows(db dbConn, query string, args []interface{}, fn func(values []string)) error {
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return fmt.Errorf("%s: %v", query, err)
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return err
+	}
+
+	for rows.Next() {
+		values := make([]sql.NullString, len(columns))
+		pointers := make([]interface{}, len(columns))
+		for i := range values {
+			pointers[i] = &values[i]
+		}
+		err = rows.Scan(pointers...)
+		if err != nil {
+			return err
+		}
+
+		strs := make([]string, len(values))
+		for i, value := range values {
+			strs[i] = value.String
+		}
+		fn(strs)
+	}
+	return rows.Err()
+}
+
+// sortTablesByForeignKeys order the tables of dbName so that referenced tables come first,
+// cyclic reports foreign key cycles (a self-reference included) between the tables, their
+// rows can not be ordered and foreign key checks must be off to load them
+func sortTablesByForeignKeys(db dbConn, dbName string, tables []string) (sorted []string, cyclic []string, err error) {
+	fks, err := loadForeignKeys(db, []string{dbName})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	graph := &DependencyGraph{}
+	selfReferences := make(map[string]bool)
+	for _, table := range tables {
+		graph.Objects = append(graph.Objects, Object{Type: ObjectTable, DB: dbName, Name: table})
+	}
+	for _, fk := range fks {
+		if fk.child == fk.parent {
+			selfReferences[fk.child] = true
+		}
+		graph.Dependencies = append(graph.Dependencies, Dependency{
+			From: Object{Type: ObjectTable, DB: dbName, Name: strings.TrimPrefix(fk.child, dbName+".")},
+			To:   Object{Type: ObjectTable, DB: dbName, Name: strings.TrimPrefix(fk.parent, dbName+".")},
+			Kind: DependencyForeignKey,
+		})
+	}
+
+	objects, cycles := graph.Sort()
+	for _, o := range objects {
+		sorted = append(sorted, o.Name)
+		if selfReferences[o.String()] {
+			cyclic = append(cyclic, o.Name)
+		}
+	}
+	for _, cycle := range cycles {
+		for _, o := range cycle {
+			if !selfReferences[o.String()] {
+				cyclic = append(cyclic, o.Name)
+			}
+		}
+	}
+	return sorted, cyclic, nil
+}
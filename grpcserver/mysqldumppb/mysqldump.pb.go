@@ -0,0 +1,792 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.12
+// 	protoc        (unknown)
+// source: mysqldumppb/mysqldump.proto
+
+package mysqldumppb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type Progress_State int32
+
+const (
+	Progress_RUNNING   Progress_State = 0
+	Progress_SUCCEEDED Progress_State = 1
+	Progress_FAILED    Progress_State = 2
+	Progress_CANCELED  Progress_State = 3
+)
+
+// Enum value maps for Progress_State.
+var (
+	Progress_State_name = map[int32]string{
+		0: "RUNNING",
+		1: "SUCCEEDED",
+		2: "FAILED",
+		3: "CANCELED",
+	}
+	Progress_State_value = map[string]int32{
+		"RUNNING":   0,
+		"SUCCEEDED": 1,
+		"FAILED":    2,
+		"CANCELED":  3,
+	}
+)
+
+func (x Progress_State) Enum() *Progress_State {
+	p := new(Progress_State)
+	*p = x
+	return p
+}
+
+func (x Progress_State) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (Progress_State) Descriptor() protoreflect.EnumDescriptor {
+	return file_mysqldumppb_mysqldump_proto_enumTypes[0].Descriptor()
+}
+
+func (Progress_State) Type() protoreflect.EnumType {
+	return &file_mysqldumppb_mysqldump_proto_enumTypes[0]
+}
+
+func (x Progress_State) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use Progress_State.Descriptor instead.
+func (Progress_State) EnumDescriptor() ([]byte, []int) {
+	return file_mysqldumppb_mysqldump_proto_rawDescGZIP(), []int{6, 0}
+}
+
+type DumpRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Dsn   string                 `protobuf:"bytes,1,opt,name=dsn,proto3" json:"dsn,omitempty"`
+	// databases all databases when empty and all_databases is set, the database of dsn otherwise
+	Databases    []string `protobuf:"bytes,2,rep,name=databases,proto3" json:"databases,omitempty"`
+	AllDatabases bool     `protobuf:"varint,3,opt,name=all_databases,json=allDatabases,proto3" json:"all_databases,omitempty"`
+	// tables all tables when empty
+	Tables        []string `protobuf:"bytes,4,rep,name=tables,proto3" json:"tables,omitempty"`
+	Schema        bool     `protobuf:"varint,5,opt,name=schema,proto3" json:"schema,omitempty"`
+	Data          bool     `protobuf:"varint,6,opt,name=data,proto3" json:"data,omitempty"`
+	DropTable     bool     `protobuf:"varint,7,opt,name=drop_table,json=dropTable,proto3" json:"drop_table,omitempty"`
+	Triggers      bool     `protobuf:"varint,8,opt,name=triggers,proto3" json:"triggers,omitempty"`
+	Routines      bool     `protobuf:"varint,9,opt,name=routines,proto3" json:"routines,omitempty"`
+	Where         string   `protobuf:"bytes,10,opt,name=where,proto3" json:"where,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DumpRequest) Reset() {
+	*x = DumpRequest{}
+	mi := &file_mysqldumppb_mysqldump_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DumpRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DumpRequest) ProtoMessage() {}
+
+func (x *DumpRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_mysqldumppb_mysqldump_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DumpRequest.ProtoReflect.Descriptor instead.
+func (*DumpRequest) Descriptor() ([]byte, []int) {
+	return file_mysqldumppb_mysqldump_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *DumpRequest) GetDsn() string {
+	if x != nil {
+		return x.Dsn
+	}
+	return ""
+}
+
+func (x *DumpRequest) GetDatabases() []string {
+	if x != nil {
+		return x.Databases
+	}
+	return nil
+}
+
+func (x *DumpRequest) GetAllDatabases() bool {
+	if x != nil {
+		return x.AllDatabases
+	}
+	return false
+}
+
+func (x *DumpRequest) GetTables() []string {
+	if x != nil {
+		return x.Tables
+	}
+	return nil
+}
+
+func (x *DumpRequest) GetSchema() bool {
+	if x != nil {
+		return x.Schema
+	}
+	return false
+}
+
+func (x *DumpRequest) GetData() bool {
+	if x != nil {
+		return x.Data
+	}
+	return false
+}
+
+func (x *DumpRequest) GetDropTable() bool {
+	if x != nil {
+		return x.DropTable
+	}
+	return false
+}
+
+func (x *DumpRequest) GetTriggers() bool {
+	if x != nil {
+		return x.Triggers
+	}
+	return false
+}
+
+func (x *DumpRequest) GetRoutines() bool {
+	if x != nil {
+		return x.Routines
+	}
+	return false
+}
+
+func (x *DumpRequest) GetWhere() string {
+	if x != nil {
+		return x.Where
+	}
+	return ""
+}
+
+type StartDumpRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Dump  *DumpRequest           `protobuf:"bytes,1,opt,name=dump,proto3" json:"dump,omitempty"`
+	// output_path the file written on the server host
+	OutputPath    string `protobuf:"bytes,2,opt,name=output_path,json=outputPath,proto3" json:"output_path,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StartDumpRequest) Reset() {
+	*x = StartDumpRequest{}
+	mi := &file_mysqldumppb_mysqldump_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StartDumpRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StartDumpRequest) ProtoMessage() {}
+
+func (x *StartDumpRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_mysqldumppb_mysqldump_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StartDumpRequest.ProtoReflect.Descriptor instead.
+func (*StartDumpRequest) Descriptor() ([]byte, []int) {
+	return file_mysqldumppb_mysqldump_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *StartDumpRequest) GetDump() *DumpRequest {
+	if x != nil {
+		return x.Dump
+	}
+	return nil
+}
+
+func (x *StartDumpRequest) GetOutputPath() string {
+	if x != nil {
+		return x.OutputPath
+	}
+	return ""
+}
+
+type StartDumpResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	JobId         string                 `protobuf:"bytes,1,opt,name=job_id,json=jobId,proto3" json:"job_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StartDumpResponse) Reset() {
+	*x = StartDumpResponse{}
+	mi := &file_mysqldumppb_mysqldump_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StartDumpResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StartDumpResponse) ProtoMessage() {}
+
+func (x *StartDumpResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_mysqldumppb_mysqldump_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StartDumpResponse.ProtoReflect.Descriptor instead.
+func (*StartDumpResponse) Descriptor() ([]byte, []int) {
+	return file_mysqldumppb_mysqldump_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *StartDumpResponse) GetJobId() string {
+	if x != nil {
+		return x.JobId
+	}
+	return ""
+}
+
+type WatchJobRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	JobId         string                 `protobuf:"bytes,1,opt,name=job_id,json=jobId,proto3" json:"job_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WatchJobRequest) Reset() {
+	*x = WatchJobRequest{}
+	mi := &file_mysqldumppb_mysqldump_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WatchJobRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WatchJobRequest) ProtoMessage() {}
+
+func (x *WatchJobRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_mysqldumppb_mysqldump_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WatchJobRequest.ProtoReflect.Descriptor instead.
+func (*WatchJobRequest) Descriptor() ([]byte, []int) {
+	return file_mysqldumppb_mysqldump_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *WatchJobRequest) GetJobId() string {
+	if x != nil {
+		return x.JobId
+	}
+	return ""
+}
+
+type CancelJobRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	JobId         string                 `protobuf:"bytes,1,opt,name=job_id,json=jobId,proto3" json:"job_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CancelJobRequest) Reset() {
+	*x = CancelJobRequest{}
+	mi := &file_mysqldumppb_mysqldump_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CancelJobRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CancelJobRequest) ProtoMessage() {}
+
+func (x *CancelJobRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_mysqldumppb_mysqldump_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CancelJobRequest.ProtoReflect.Descriptor instead.
+func (*CancelJobRequest) Descriptor() ([]byte, []int) {
+	return file_mysqldumppb_mysqldump_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *CancelJobRequest) GetJobId() string {
+	if x != nil {
+		return x.JobId
+	}
+	return ""
+}
+
+type CancelJobResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CancelJobResponse) Reset() {
+	*x = CancelJobResponse{}
+	mi := &file_mysqldumppb_mysqldump_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CancelJobResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CancelJobResponse) ProtoMessage() {}
+
+func (x *CancelJobResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_mysqldumppb_mysqldump_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CancelJobResponse.ProtoReflect.Descriptor instead.
+func (*CancelJobResponse) Descriptor() ([]byte, []int) {
+	return file_mysqldumppb_mysqldump_proto_rawDescGZIP(), []int{5}
+}
+
+type Progress struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	JobId string                 `protobuf:"bytes,1,opt,name=job_id,json=jobId,proto3" json:"job_id,omitempty"`
+	State Progress_State         `protobuf:"varint,2,opt,name=state,proto3,enum=mysqldump.v1.Progress_State" json:"state,omitempty"`
+	// bytes written by a dump, read by a restore
+	Bytes int64 `protobuf:"varint,3,opt,name=bytes,proto3" json:"bytes,omitempty"`
+	// statements executed by a restore
+	Statements    int64    `protobuf:"varint,4,opt,name=statements,proto3" json:"statements,omitempty"`
+	Error         string   `protobuf:"bytes,5,opt,name=error,proto3" json:"error,omitempty"`
+	Warnings      []string `protobuf:"bytes,6,rep,name=warnings,proto3" json:"warnings,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Progress) Reset() {
+	*x = Progress{}
+	mi := &file_mysqldumppb_mysqldump_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Progress) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Progress) ProtoMessage() {}
+
+func (x *Progress) ProtoReflect() protoreflect.Message {
+	mi := &file_mysqldumppb_mysqldump_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Progress.ProtoReflect.Descriptor instead.
+func (*Progress) Descriptor() ([]byte, []int) {
+	return file_mysqldumppb_mysqldump_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *Progress) GetJobId() string {
+	if x != nil {
+		return x.JobId
+	}
+	return ""
+}
+
+func (x *Progress) GetState() Progress_State {
+	if x != nil {
+		return x.State
+	}
+	return Progress_RUNNING
+}
+
+func (x *Progress) GetBytes() int64 {
+	if x != nil {
+		return x.Bytes
+	}
+	return 0
+}
+
+func (x *Progress) GetStatements() int64 {
+	if x != nil {
+		return x.Statements
+	}
+	return 0
+}
+
+func (x *Progress) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+func (x *Progress) GetWarnings() []string {
+	if x != nil {
+		return x.Warnings
+	}
+	return nil
+}
+
+type DumpChunk struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Data          []byte                 `protobuf:"bytes,1,opt,name=data,proto3" json:"data,omitempty"`
+	Progress      *Progress              `protobuf:"bytes,2,opt,name=progress,proto3" json:"progress,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DumpChunk) Reset() {
+	*x = DumpChunk{}
+	mi := &file_mysqldumppb_mysqldump_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DumpChunk) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DumpChunk) ProtoMessage() {}
+
+func (x *DumpChunk) ProtoReflect() protoreflect.Message {
+	mi := &file_mysqldumppb_mysqldump_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DumpChunk.ProtoReflect.Descriptor instead.
+func (*DumpChunk) Descriptor() ([]byte, []int) {
+	return file_mysqldumppb_mysqldump_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *DumpChunk) GetData() []byte {
+	if x != nil {
+		return x.Data
+	}
+	return nil
+}
+
+func (x *DumpChunk) GetProgress() *Progress {
+	if x != nil {
+		return x.Progress
+	}
+	return nil
+}
+
+type RestoreRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Dsn   string                 `protobuf:"bytes,1,opt,name=dsn,proto3" json:"dsn,omitempty"`
+	// merge_insert INSERT statements merged per batch, 0 disables
+	MergeInsert   int32 `protobuf:"varint,2,opt,name=merge_insert,json=mergeInsert,proto3" json:"merge_insert,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RestoreRequest) Reset() {
+	*x = RestoreRequest{}
+	mi := &file_mysqldumppb_mysqldump_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RestoreRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RestoreRequest) ProtoMessage() {}
+
+func (x *RestoreRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_mysqldumppb_mysqldump_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RestoreRequest.ProtoReflect.Descriptor instead.
+func (*RestoreRequest) Descriptor() ([]byte, []int) {
+	return file_mysqldumppb_mysqldump_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *RestoreRequest) GetDsn() string {
+	if x != nil {
+		return x.Dsn
+	}
+	return ""
+}
+
+func (x *RestoreRequest) GetMergeInsert() int32 {
+	if x != nil {
+		return x.MergeInsert
+	}
+	return 0
+}
+
+type RestoreChunk struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// request set in the first chunk only
+	Request       *RestoreRequest `protobuf:"bytes,1,opt,name=request,proto3" json:"request,omitempty"`
+	Data          []byte          `protobuf:"bytes,2,opt,name=data,proto3" json:"data,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RestoreChunk) Reset() {
+	*x = RestoreChunk{}
+	mi := &file_mysqldumppb_mysqldump_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RestoreChunk) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RestoreChunk) ProtoMessage() {}
+
+func (x *RestoreChunk) ProtoReflect() protoreflect.Message {
+	mi := &file_mysqldumppb_mysqldump_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RestoreChunk.ProtoReflect.Descriptor instead.
+func (*RestoreChunk) Descriptor() ([]byte, []int) {
+	return file_mysqldumppb_mysqldump_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *RestoreChunk) GetRequest() *RestoreRequest {
+	if x != nil {
+		return x.Request
+	}
+	return nil
+}
+
+func (x *RestoreChunk) GetData() []byte {
+	if x != nil {
+		return x.Data
+	}
+	return nil
+}
+
+var File_mysqldumppb_mysqldump_proto protoreflect.FileDescriptor
+
+const file_mysqldumppb_mysqldump_proto_rawDesc = "" +
+	"\n" +
+	"\x1bmysqldumppb/mysqldump.proto\x12\fmysqldump.v1\"\x93\x02\n" +
+	"\vDumpRequest\x12\x10\n" +
+	"\x03dsn\x18\x01 \x01(\tR\x03dsn\x12\x1c\n" +
+	"\tdatabases\x18\x02 \x03(\tR\tdatabases\x12#\n" +
+	"\rall_databases\x18\x03 \x01(\bR\fallDatabases\x12\x16\n" +
+	"\x06tables\x18\x04 \x03(\tR\x06tables\x12\x16\n" +
+	"\x06schema\x18\x05 \x01(\bR\x06schema\x12\x12\n" +
+	"\x04data\x18\x06 \x01(\bR\x04data\x12\x1d\n" +
+	"\n" +
+	"drop_table\x18\a \x01(\bR\tdropTable\x12\x1a\n" +
+	"\btriggers\x18\b \x01(\bR\btriggers\x12\x1a\n" +
+	"\broutines\x18\t \x01(\bR\broutines\x12\x14\n" +
+	"\x05where\x18\n" +
+	" \x01(\tR\x05where\"b\n" +
+	"\x10StartDumpRequest\x12-\n" +
+	"\x04dump\x18\x01 \x01(\v2\x19.mysqldump.v1.DumpRequestR\x04dump\x12\x1f\n" +
+	"\voutput_path\x18\x02 \x01(\tR\n" +
+	"outputPath\"*\n" +
+	"\x11StartDumpResponse\x12\x15\n" +
+	"\x06job_id\x18\x01 \x01(\tR\x05jobId\"(\n" +
+	"\x0fWatchJobRequest\x12\x15\n" +
+	"\x06job_id\x18\x01 \x01(\tR\x05jobId\")\n" +
+	"\x10CancelJobRequest\x12\x15\n" +
+	"\x06job_id\x18\x01 \x01(\tR\x05jobId\"\x13\n" +
+	"\x11CancelJobResponse\"\xfc\x01\n" +
+	"\bProgress\x12\x15\n" +
+	"\x06job_id\x18\x01 \x01(\tR\x05jobId\x122\n" +
+	"\x05state\x18\x02 \x01(\x0e2\x1c.mysqldump.v1.Progress.StateR\x05state\x12\x14\n" +
+	"\x05bytes\x18\x03 \x01(\x03R\x05bytes\x12\x1e\n" +
+	"\n" +
+	"statements\x18\x04 \x01(\x03R\n" +
+	"statements\x12\x14\n" +
+	"\x05error\x18\x05 \x01(\tR\x05error\x12\x1a\n" +
+	"\bwarnings\x18\x06 \x03(\tR\bwarnings\"=\n" +
+	"\x05State\x12\v\n" +
+	"\aRUNNING\x10\x00\x12\r\n" +
+	"\tSUCCEEDED\x10\x01\x12\n" +
+	"\n" +
+	"\x06FAILED\x10\x02\x12\f\n" +
+	"\bCANCELED\x10\x03\"S\n" +
+	"\tDumpChunk\x12\x12\n" +
+	"\x04data\x18\x01 \x01(\fR\x04data\x122\n" +
+	"\bprogress\x18\x02 \x01(\v2\x16.mysqldump.v1.ProgressR\bprogress\"E\n" +
+	"\x0eRestoreRequest\x12\x10\n" +
+	"\x03dsn\x18\x01 \x01(\tR\x03dsn\x12!\n" +
+	"\fmerge_insert\x18\x02 \x01(\x05R\vmergeInsert\"Z\n" +
+	"\fRestoreChunk\x126\n" +
+	"\arequest\x18\x01 \x01(\v2\x1c.mysqldump.v1.RestoreRequestR\arequest\x12\x12\n" +
+	"\x04data\x18\x02 \x01(\fR\x04data2\xf8\x02\n" +
+	"\tMySQLDump\x12L\n" +
+	"\tStartDump\x12\x1e.mysqldump.v1.StartDumpRequest\x1a\x1f.mysqldump.v1.StartDumpResponse\x12C\n" +
+	"\bWatchJob\x12\x1d.mysqldump.v1.WatchJobRequest\x1a\x16.mysqldump.v1.Progress0\x01\x12L\n" +
+	"\tCancelJob\x12\x1e.mysqldump.v1.CancelJobRequest\x1a\x1f.mysqldump.v1.CancelJobResponse\x12B\n" +
+	"\n" +
+	"StreamDump\x12\x19.mysqldump.v1.DumpRequest\x1a\x17.mysqldump.v1.DumpChunk0\x01\x12F\n" +
+	"\fApplyRestore\x12\x1a.mysqldump.v1.RestoreChunk\x1a\x16.mysqldump.v1.Progress(\x010\x01B\"Z mysqldump/grpcserver/mysqldumppbb\x06proto3"
+
+var (
+	file_mysqldumppb_mysqldump_proto_rawDescOnce sync.Once
+	file_mysqldumppb_mysqldump_proto_rawDescData []byte
+)
+
+func file_mysqldumppb_mysqldump_proto_rawDescGZIP() []byte {
+	file_mysqldumppb_mysqldump_proto_rawDescOnce.Do(func() {
+		file_mysqldumppb_mysqldump_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_mysqldumppb_mysqldump_proto_rawDesc), len(file_mysqldumppb_mysqldump_proto_rawDesc)))
+	})
+	return file_mysqldumppb_mysqldump_proto_rawDescData
+}
+
+var file_mysqldumppb_mysqldump_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
+var file_mysqldumppb_mysqldump_proto_msgTypes = make([]protoimpl.MessageInfo, 10)
+var file_mysqldumppb_mysqldump_proto_goTypes = []any{
+	(Progress_State)(0),       // 0: mysqldump.v1.Progress.State
+	(*DumpRequest)(nil),       // 1: mysqldump.v1.DumpRequest
+	(*StartDumpRequest)(nil),  // 2: mysqldump.v1.StartDumpRequest
+	(*StartDumpResponse)(nil), // 3: mysqldump.v1.StartDumpResponse
+	(*WatchJobRequest)(nil),   // 4: mysqldump.v1.WatchJobRequest
+	(*CancelJobRequest)(nil),  // 5: mysqldump.v1.CancelJobRequest
+	(*CancelJobResponse)(nil), // 6: mysqldump.v1.CancelJobResponse
+	(*Progress)(nil),          // 7: mysqldump.v1.Progress
+	(*DumpChunk)(nil),         // 8: mysqldump.v1.DumpChunk
+	(*RestoreRequest)(nil),    // 9: mysqldump.v1.RestoreRequest
+	(*RestoreChunk)(nil),      // 10: mysqldump.v1.RestoreChunk
+}
+var file_mysqldumppb_mysqldump_proto_depIdxs = []int32{
+	1,  // 0: mysqldump.v1.StartDumpRequest.dump:type_name -> mysqldump.v1.DumpRequest
+	0,  // 1: mysqldump.v1.Progress.state:type_name -> mysqldump.v1.Progress.State
+	7,  // 2: mysqldump.v1.DumpChunk.progress:type_name -> mysqldump.v1.Progress
+	9,  // 3: mysqldump.v1.RestoreChunk.request:type_name -> mysqldump.v1.RestoreRequest
+	2,  // 4: mysqldump.v1.MySQLDump.StartDump:input_type -> mysqldump.v1.StartDumpRequest
+	4,  // 5: mysqldump.v1.MySQLDump.WatchJob:input_type -> mysqldump.v1.WatchJobRequest
+	5,  // 6: mysqldump.v1.MySQLDump.CancelJob:input_type -> mysqldump.v1.CancelJobRequest
+	1,  // 7: mysqldump.v1.MySQLDump.StreamDump:input_type -> mysqldump.v1.DumpRequest
+	10, // 8: mysqldump.v1.MySQLDump.ApplyRestore:input_type -> mysqldump.v1.RestoreChunk
+	3,  // 9: mysqldump.v1.MySQLDump.StartDump:output_type -> mysqldump.v1.StartDumpResponse
+	7,  // 10: mysqldump.v1.MySQLDump.WatchJob:output_type -> mysqldump.v1.Progress
+	6,  // 11: mysqldump.v1.MySQLDump.CancelJob:output_type -> mysqldump.v1.CancelJobResponse
+	8,  // 12: mysqldump.v1.MySQLDump.StreamDump:output_type -> mysqldump.v1.DumpChunk
+	7,  // 13: mysqldump.v1.MySQLDump.ApplyRestore:output_type -> mysqldump.v1.Progress
+	9,  // [9:14] is the sub-list for method output_type
+	4,  // [4:9] is the sub-list for method input_type
+	4,  // [4:4] is the sub-list for extension type_name
+	4,  // [4:4] is the sub-list for extension extendee
+	0,  // [0:4] is the sub-list for field type_name
+}
+
+func init() { file_mysqldumppb_mysqldump_proto_init() }
+func file_mysqldumppb_mysqldump_proto_init() {
+	if File_mysqldumppb_mysqldump_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_mysqldumppb_mysqldump_proto_rawDesc), len(file_mysqldumppb_mysqldump_proto_rawDesc)),
+			NumEnums:      1,
+			NumMessages:   10,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_mysqldumppb_mysqldump_proto_goTypes,
+		DependencyIndexes: file_mysqldumppb_mysqldump_proto_depIdxs,
+		EnumInfos:         file_mysqldumppb_mysqldump_proto_enumTypes,
+		MessageInfos:      file_mysqldumppb_mysqldump_proto_msgTypes,
+	}.Build()
+	File_mysqldumppb_mysqldump_proto = out.File
+	file_mysqldumppb_mysqldump_proto_goTypes = nil
+	file_mysqldumppb_mysqldump_proto_depIdxs = nil
+}
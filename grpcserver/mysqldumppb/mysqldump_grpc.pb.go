@@ -0,0 +1,288 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.6.2
+// - protoc             (unknown)
+// source: mysqldumppb/mysqldump.proto
+
+package mysqldumppb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	MySQLDump_StartDump_FullMethodName    = "/mysqldump.v1.MySQLDump/StartDump"
+	MySQLDump_WatchJob_FullMethodName     = "/mysqldump.v1.MySQLDump/WatchJob"
+	MySQLDump_CancelJob_FullMethodName    = "/mysqldump.v1.MySQLDump/CancelJob"
+	MySQLDump_StreamDump_FullMethodName   = "/mysqldump.v1.MySQLDump/StreamDump"
+	MySQLDump_ApplyRestore_FullMethodName = "/mysqldump.v1.MySQLDump/ApplyRestore"
+)
+
+// MySQLDumpClient is the client API for MySQLDump service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// MySQLDump dump and restore orchestration, served by grpcserver.Server
+type MySQLDumpClient interface {
+	// StartDump start a dump written to a file on the server host, follow it with WatchJob
+	StartDump(ctx context.Context, in *StartDumpRequest, opts ...grpc.CallOption) (*StartDumpResponse, error)
+	// WatchJob the progress of a StartDump job until it ends
+	WatchJob(ctx context.Context, in *WatchJobRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Progress], error)
+	// CancelJob abort a StartDump job
+	CancelJob(ctx context.Context, in *CancelJobRequest, opts ...grpc.CallOption) (*CancelJobResponse, error)
+	// StreamDump run a dump and stream its content to the caller
+	StreamDump(ctx context.Context, in *DumpRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[DumpChunk], error)
+	// ApplyRestore load the streamed dump, the first chunk carries the request
+	ApplyRestore(ctx context.Context, opts ...grpc.CallOption) (grpc.BidiStreamingClient[RestoreChunk, Progress], error)
+}
+
+type mySQLDumpClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewMySQLDumpClient(cc grpc.ClientConnInterface) MySQLDumpClient {
+	return &mySQLDumpClient{cc}
+}
+
+func (c *mySQLDumpClient) StartDump(ctx context.Context, in *StartDumpRequest, opts ...grpc.CallOption) (*StartDumpResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(StartDumpResponse)
+	err := c.cc.Invoke(ctx, MySQLDump_StartDump_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *mySQLDumpClient) WatchJob(ctx context.Context, in *WatchJobRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Progress], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &MySQLDump_ServiceDesc.Streams[0], MySQLDump_WatchJob_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[WatchJobRequest, Progress]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type MySQLDump_WatchJobClient = grpc.ServerStreamingClient[Progress]
+
+func (c *mySQLDumpClient) CancelJob(ctx context.Context, in *CancelJobRequest, opts ...grpc.CallOption) (*CancelJobResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CancelJobResponse)
+	err := c.cc.Invoke(ctx, MySQLDump_CancelJob_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *mySQLDumpClient) StreamDump(ctx context.Context, in *DumpRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[DumpChunk], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &MySQLDump_ServiceDesc.Streams[1], MySQLDump_StreamDump_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[DumpRequest, DumpChunk]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type MySQLDump_StreamDumpClient = grpc.ServerStreamingClient[DumpChunk]
+
+func (c *mySQLDumpClient) ApplyRestore(ctx context.Context, opts ...grpc.CallOption) (grpc.BidiStreamingClient[RestoreChunk, Progress], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &MySQLDump_ServiceDesc.Streams[2], MySQLDump_ApplyRestore_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[RestoreChunk, Progress]{ClientStream: stream}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type MySQLDump_ApplyRestoreClient = grpc.BidiStreamingClient[RestoreChunk, Progress]
+
+// MySQLDumpServer is the server API for MySQLDump service.
+// All implementations must embed UnimplementedMySQLDumpServer
+// for forward compatibility.
+//
+// MySQLDump dump and restore orchestration, served by grpcserver.Server
+type MySQLDumpServer interface {
+	// StartDump start a dump written to a file on the server host, follow it with WatchJob
+	StartDump(context.Context, *StartDumpRequest) (*StartDumpResponse, error)
+	// WatchJob the progress of a StartDump job until it ends
+	WatchJob(*WatchJobRequest, grpc.ServerStreamingServer[Progress]) error
+	// CancelJob abort a StartDump job
+	CancelJob(context.Context, *CancelJobRequest) (*CancelJobResponse, error)
+	// StreamDump run a dump and stream its content to the caller
+	StreamDump(*DumpRequest, grpc.ServerStreamingServer[DumpChunk]) error
+	// ApplyRestore load the streamed dump, the first chunk carries the request
+	ApplyRestore(grpc.BidiStreamingServer[RestoreChunk, Progress]) error
+	mustEmbedUnimplementedMySQLDumpServer()
+}
+
+// UnimplementedMySQLDumpServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedMySQLDumpServer struct{}
+
+func (UnimplementedMySQLDumpServer) StartDump(context.Context, *StartDumpRequest) (*StartDumpResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method StartDump not implemented")
+}
+func (UnimplementedMySQLDumpServer) WatchJob(*WatchJobRequest, grpc.ServerStreamingServer[Progress]) error {
+	return status.Error(codes.Unimplemented, "method WatchJob not implemented")
+}
+func (UnimplementedMySQLDumpServer) CancelJob(context.Context, *CancelJobRequest) (*CancelJobResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method CancelJob not implemented")
+}
+func (UnimplementedMySQLDumpServer) StreamDump(*DumpRequest, grpc.ServerStreamingServer[DumpChunk]) error {
+	return status.Error(codes.Unimplemented, "method StreamDump not implemented")
+}
+func (UnimplementedMySQLDumpServer) ApplyRestore(grpc.BidiStreamingServer[RestoreChunk, Progress]) error {
+	return status.Error(codes.Unimplemented, "method ApplyRestore not implemented")
+}
+func (UnimplementedMySQLDumpServer) mustEmbedUnimplementedMySQLDumpServer() {}
+func (UnimplementedMySQLDumpServer) testEmbeddedByValue()                   {}
+
+// UnsafeMySQLDumpServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to MySQLDumpServer will
+// result in compilation errors.
+type UnsafeMySQLDumpServer interface {
+	mustEmbedUnimplementedMySQLDumpServer()
+}
+
+func RegisterMySQLDumpServer(s grpc.ServiceRegistrar, srv MySQLDumpServer) {
+	// If the following call panics, it indicates UnimplementedMySQLDumpServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&MySQLDump_ServiceDesc, srv)
+}
+
+func _MySQLDump_StartDump_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(StartDumpRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MySQLDumpServer).StartDump(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MySQLDump_StartDump_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MySQLDumpServer).StartDump(ctx, req.(*StartDumpRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MySQLDump_WatchJob_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchJobRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(MySQLDumpServer).WatchJob(m, &grpc.GenericServerStream[WatchJobRequest, Progress]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type MySQLDump_WatchJobServer = grpc.ServerStreamingServer[Progress]
+
+func _MySQLDump_CancelJob_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CancelJobRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MySQLDumpServer).CancelJob(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MySQLDump_CancelJob_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MySQLDumpServer).CancelJob(ctx, req.(*CancelJobRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MySQLDump_StreamDump_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(DumpRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(MySQLDumpServer).StreamDump(m, &grpc.GenericServerStream[DumpRequest, DumpChunk]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type MySQLDump_StreamDumpServer = grpc.ServerStreamingServer[DumpChunk]
+
+func _MySQLDump_ApplyRestore_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(MySQLDumpServer).ApplyRestore(&grpc.GenericServerStream[RestoreChunk, Progress]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type MySQLDump_ApplyRestoreServer = grpc.BidiStreamingServer[RestoreChunk, Progress]
+
+// MySQLDump_ServiceDesc is the grpc.ServiceDesc for MySQLDump service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var MySQLDump_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "mysqldump.v1.MySQLDump",
+	HandlerType: (*MySQLDumpServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "StartDump",
+			Handler:    _MySQLDump_StartDump_Handler,
+		},
+		{
+			MethodName: "CancelJob",
+			Handler:    _MySQLDump_CancelJob_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "WatchJob",
+			Handler:       _MySQLDump_WatchJob_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "StreamDump",
+			Handler:       _MySQLDump_StreamDump_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "ApplyRestore",
+			Handler:       _MySQLDump_ApplyRestore_Handler,
+			ServerStreams: true,
+			ClientStreams: true,
+		},
+	},
+	Metadata: "mysqldumppb/mysqldump.proto",
+}
@@ -0,0 +1,323 @@
+// Package grpcserver exposes mysqldump as a gRPC service (mysqldumppb/mysqldump.proto) so
+// that a controller can orchestrate the backups and restores of many database hosts:
+//
+//	server := grpc.NewServer()
+//	mysqldumppb.RegisterMySQLDumpServer(server, grpcserver.NewServer())
+//	_ = server.Serve(listener)
+//
+// The mysqldumppb package is generated with go generate (protoc, protoc-gen-go and
+// protoc-gen-go-grpc).
+package grpcserver
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative mysqldumppb/mysqldump.proto
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"mysqldump"
+	"mysqldump/grpcserver/mysqldumppb"
+)
+
+// progressInterval the period of the progress messages
+const progressInterval = time.Second
+
+// Server the MySQLDump service, StartDump jobs are kept in memory until the process exits
+type Server struct {
+	mysqldumppb.UnimplementedMySQLDumpServer
+
+	mu     sync.Mutex
+	jobs   map[string]*job
+	nextID int
+}
+
+func NewServer() *Server {
+	return &Server{
+		jobs: make(map[string]*job),
+	}
+}
+
+type job struct {
+	id     string
+	bytes  atomic.Int64
+	cancel context.CancelFunc
+	done   chan struct{}
+	// set before done is closed
+	err    error
+	result mysqldump.DumpResult
+}
+
+func (j *job) progress() *mysqldumppb.Progress {
+	p := &mysqldumppb.Progress{
+		JobId: j.id,
+		State: mysqldumppb.Progress_RUNNING,
+		Bytes: j.bytes.Load(),
+	}
+	select {
+	case <-j.done:
+		p.Warnings = j.result.Warnings
+		switch {
+		case j.err == nil:
+			p.State = mysqldumppb.Progress_SUCCEEDED
+		case errors.Is(j.err, context.Canceled):
+			p.State = mysqldumppb.Progress_CANCELED
+		default:
+			p.State = mysqldumppb.Progress_FAILED
+			p.Error = j.err.Error()
+		}
+	default:
+	}
+	return p
+}
+
+func (s *Server) StartDump(_ context.Context, req *mysqldumppb.StartDumpRequest) (*mysqldumppb.StartDumpResponse, error) {
+	if req.GetDump().GetDsn() == "" || req.GetOutputPath() == "" {
+		return nil, status.Error(codes.InvalidArgument, "dsn and output_path are required")
+	}
+
+	file, err := os.Create(req.GetOutputPath())
+	if err != nil {
+		return nil, status.Error(codes.FailedPrecondition, err.Error())
+	}
+
+	s.mu.Lock()
+	s.nextID++
+	j := &job{
+		id:   fmt.Sprintf("dump-%d", s.nextID),
+		done: make(chan struct{}),
+	}
+	s.jobs[j.id] = j
+	s.mu.Unlock()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	j.cancel = cancel
+	go func() {
+		defer close(j.done)
+		defer cancel()
+
+		j.err = runDump(ctx, req.GetDump(), &countingWriter{w: file, n: &j.bytes}, &j.result)
+		if closeErr := file.Close(); j.err == nil {
+			j.err = closeErr
+		}
+	}()
+
+	return &mysqldumppb.StartDumpResponse{JobId: j.id}, nil
+}
+
+func (s *Server) job(id string) (*job, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	j, ok := s.jobs[id]
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "job %s not found", id)
+	}
+	return j, nil
+}
+
+func (s *Server) WatchJob(req *mysqldumppb.WatchJobRequest, stream mysqldumppb.MySQLDump_WatchJobServer) error {
+	j, err := s.job(req.GetJobId())
+	if err != nil {
+		return err
+	}
+
+	ticker := time.NewTicker(progressInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-j.done:
+			return stream.Send(j.progress())
+		case <-ticker.C:
+			err = stream.Send(j.progress())
+			if err != nil {
+				return err
+			}
+		case <-stream.Context().Done():
+			return stream.Context().Err()
+		}
+	}
+}
+
+func (s *Server) CancelJob(_ context.Context, req *mysqldumppb.CancelJobRequest) (*mysqldumppb.CancelJobResponse, error) {
+	j, err := s.job(req.GetJobId())
+	if err != nil {
+		return nil, err
+	}
+	j.cancel()
+	return &mysqldumppb.CancelJobResponse{}, nil
+}
+
+func (s *Server) StreamDump(req *mysqldumppb.DumpRequest, stream mysqldumppb.MySQLDump_StreamDumpServer) error {
+	if req.GetDsn() == "" {
+		return status.Error(codes.InvalidArgument, "dsn is required")
+	}
+
+	var bytes atomic.Int64
+	var result mysqldump.DumpResult
+	// the dump writes through a BufferSize buffer, each flush is one chunk
+	err := runDump(stream.Context(), req, &countingWriter{w: chunkWriter{stream: stream, bytes: &bytes}, n: &bytes}, &result)
+	if err != nil {
+		return status.Error(codes.Unknown, err.Error())
+	}
+	return stream.Send(&mysqldumppb.DumpChunk{Progress: &mysqldumppb.Progress{
+		State:    mysqldumppb.Progress_SUCCEEDED,
+		Bytes:    bytes.Load(),
+		Warnings: result.Warnings,
+	}})
+}
+
+func (s *Server) ApplyRestore(stream mysqldumppb.MySQLDump_ApplyRestoreServer) error {
+	first, err := stream.Recv()
+	if err != nil {
+		return err
+	}
+	req := first.GetRequest()
+	if req.GetDsn() == "" {
+		return status.Error(codes.InvalidArgument, "the first chunk must carry the request with a dsn")
+	}
+
+	var opts []mysqldump.SourceOption
+	if req.GetMergeInsert() > 0 {
+		opts = append(opts, mysqldump.WithMergeInsert(int(req.GetMergeInsert())))
+	}
+	var result mysqldump.SourceResult
+	opts = append(opts, mysqldump.WithSourceResult(&result))
+	sourcer, err := mysqldump.NewSourcer(req.GetDsn(), opts...)
+	if err != nil {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+	defer func() {
+		_ = sourcer.Close()
+	}()
+
+	// the chunks are fed to the restore by a pipe, only this goroutine sends on the stream
+	// until it returns
+	reader, writer := io.Pipe()
+	var bytes int64
+	received := make(chan error, 1)
+	go func() {
+		received <- func() error {
+			data := first.GetData()
+			for {
+				if len(data) > 0 {
+					_, err := writer.Write(data)
+					if err != nil {
+						return err
+					}
+					bytes += int64(len(data))
+					err = stream.Send(&mysqldumppb.Progress{State: mysqldumppb.Progress_RUNNING, Bytes: bytes})
+					if err != nil {
+						return err
+					}
+				}
+				chunk, err := stream.Recv()
+				if err == io.EOF {
+					return nil
+				}
+				if err != nil {
+					return err
+				}
+				data = chunk.GetData()
+			}
+		}()
+		_ = writer.Close()
+	}()
+
+	err = sourcer.Run(stream.Context(), reader)
+	_ = reader.Close()
+	// after a failed restore the receiving fails on the closed pipe
+	if recvErr := <-received; err == nil {
+		err = recvErr
+	}
+
+	p := &mysqldumppb.Progress{
+		State:      mysqldumppb.Progress_SUCCEEDED,
+		Bytes:      bytes,
+		Statements: int64(result.Statements),
+	}
+	if err != nil {
+		p.State = mysqldumppb.Progress_FAILED
+		p.Error = err.Error()
+	}
+	return stream.Send(p)
+}
+
+// runDump a dump of req into w
+func runDump(ctx context.Context, req *mysqldumppb.DumpRequest, w io.Writer, result *mysqldump.DumpResult) error {
+	opts := []mysqldump.DumpOption{mysqldump.WithWriter(w), mysqldump.WithDumpResult(result)}
+	if len(req.GetDatabases()) > 0 {
+		opts = append(opts, mysqldump.WithDBs(req.GetDatabases()...))
+	} else if req.GetAllDatabases() {
+		opts = append(opts, mysqldump.WithAllDatabases())
+	}
+	if len(req.GetTables()) > 0 {
+		opts = append(opts, mysqldump.WithTables(req.GetTables()...))
+	} else {
+		opts = append(opts, mysqldump.WithAllTables())
+	}
+	if req.GetSchema() {
+		opts = append(opts, mysqldump.WithDumpTable())
+	}
+	if req.GetData() {
+		opts = append(opts, mysqldump.WithData())
+	}
+	if req.GetDropTable() {
+		opts = append(opts, mysqldump.WithDropTable())
+	}
+	if req.GetTriggers() {
+		opts = append(opts, mysqldump.WithTriggers())
+	}
+	if req.GetRoutines() {
+		opts = append(opts, mysqldump.WithRoutines())
+	}
+	if req.GetWhere() != "" {
+		opts = append(opts, mysqldump.WithWhere(req.GetWhere()))
+	}
+
+	dumper, err := mysqldump.NewDumper(req.GetDsn(), opts...)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = dumper.Close()
+	}()
+	return dumper.Run(ctx)
+}
+
+type countingWriter struct {
+	w io.Writer
+	n *atomic.Int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n.Add(int64(n))
+	return n, err
+}
+
+// chunkWriter send each write as a DumpChunk, bytes is the progress before the write
+type chunkWriter struct {
+	stream mysqldumppb.MySQLDump_StreamDumpServer
+	bytes  *atomic.Int64
+}
+
+func (c chunkWriter) Write(p []byte) (int, error) {
+	err := c.stream.Send(&mysqldumppb.DumpChunk{
+		// Send serializes the message before returning, p may be reused afterwards
+		Data:     p,
+		Progress: &mysqldumppb.Progress{State: mysqldumppb.Progress_RUNNING, Bytes: c.bytes.Load() + int64(len(p))},
+	})
+	if err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
@@ -0,0 +1,27 @@
+package mysqldump
+
+import (
+	"strings"
+)
+
+// WithSelectHint add an optimizer hint to the SELECTs reading the rows of the tables, eg:
+// "MAX_EXECUTION_TIME(60000) RESOURCE_GROUP(backup)" or the same wrapped in /*+ ... */,
+// so that the server can tell the load of the backups apart. Servers ignore the hints they
+// do not know, with a warning.
+func WithSelectHint(hint string) DumpOption {
+	return func(option *dumpOption) {
+		hint = strings.TrimSpace(hint)
+		if hint != "" && !strings.HasPrefix(hint, "/*+") {
+			hint = "/*+ " + hint + " */"
+		}
+		option.selectHint = hint
+	}
+}
+
+// selectKeyword SELECT followed by the hint of WithSelectHint
+func (o *dumpOption) selectKeyword() string {
+	if o.selectHint == "" {
+		return "SELECT"
+	}
+	return "SELECT " + o.selectHint
+}
@@ -0,0 +1,49 @@
+package mysqldump
+
+import (
+	"fmt"
+
+	"mysqldump/sqlutil"
+)
+
+// WithHistograms dump the column histograms of each table (information_schema.COLUMN_STATISTICS,
+// MySQL 8.0) after its data, as ANALYZE TABLE ... UPDATE HISTOGRAM ... USING DATA statements,
+// so that the restored server plans queries like the original one. The statements are
+// wrapped in /*!80031 ... */, servers that can not load a histogram skip them.
+func WithHistograms() DumpOption {
+	return func(option *dumpOption) {
+		option.isHistograms = true
+	}
+}
+
+func writeHistograms(db dbConn, dbName, table string, buf *SafeWriter, o *dumpOption) error {
+	type histogram struct {
+		column string
+		data   string
+	}
+	var histograms []histogram
+	err := queryRows(db, "SELECT COLUMN_NAME, HISTOGRAM FROM information_schema.COLUMN_STATISTICS "+
+		"WHERE SCHEMA_NAME = ? AND TABLE_NAME = ? ORDER BY COLUMN_NAME", []interface{}{dbName, table}, func(values []string) {
+		histograms = append(histograms, histogram{column: values[0], data: values[1]})
+	})
+	if err != nil {
+		// MySQL 5.7 and MariaDB have no COLUMN_STATISTICS
+		o.warnf("histograms are not dumped: %v", err)
+		o.isHistograms = false
+		return nil
+	}
+	if len(histograms) == 0 {
+		return nil
+	}
+
+	_, _ = buf.WriteString("-- ----------------------------\n")
+	_, _ = buf.WriteString(fmt.Sprintf("-- Histograms of %s\n", table))
+	_, _ = buf.WriteString("-- ----------------------------\n")
+	for _, h := range histograms {
+		_, _ = buf.WriteString(o.annotation(dbName, ObjectTable, table, 0))
+		_, _ = buf.WriteString(fmt.Sprintf("/*!80031 ANALYZE TABLE %s UPDATE HISTOGRAM ON %s USING DATA %s */;\n",
+			sqlutil.QuoteIdentifier(table), sqlutil.QuoteIdentifier(h.column), sqlutil.QuoteString(h.data)))
+	}
+	_, _ = buf.WriteString("\n\n")
+	return nil
+}
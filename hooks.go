@@ -0,0 +1,54 @@
+package mysqldump
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// WithPreSQL execute stmts on the dump connection before the dump, eg: to set session
+// variables
+func WithPreSQL(stmts ...string) DumpOption {
+	return func(option *dumpOption) {
+		option.preSQL = append(option.preSQL, stmts...)
+	}
+}
+
+// WithPostSQL execute stmts on the dump connection after the dump, even when it failed,
+// eg: to undo a WithPreSQL statement
+func WithPostSQL(stmts ...string) DumpOption {
+	return func(option *dumpOption) {
+		option.postSQL = append(option.postSQL, stmts...)
+	}
+}
+
+// WithSourcePreSQL execute stmts before the statements of the dump, eg:
+// SET GLOBAL event_scheduler = OFF
+func WithSourcePreSQL(stmts ...string) SourceOption {
+	return func(o *sourceOption) {
+		o.preSQL = append(o.preSQL, stmts...)
+	}
+}
+
+// WithSourcePostSQL execute stmts once the restore is committed, eg: to refresh the
+// summary tables built from the restored data. They are not executed if the restore failed.
+func WithSourcePostSQL(stmts ...string) SourceOption {
+	return func(o *sourceOption) {
+		o.postSQL = append(o.postSQL, stmts...)
+	}
+}
+
+// execer the dump connection or the dbWrapper of the restore
+type execer interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+}
+
+// execStatements execute the statements of a pre or post hook in order
+func execStatements(db execer, stmts []string) error {
+	for _, stmt := range stmts {
+		_, err := db.Exec(stmt)
+		if err != nil {
+			return fmt.Errorf("%s: %w", stmt, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,118 @@
+package mysqldump
+
+import (
+	"bytes"
+	"database/sql/driver"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+// identifierTests names that break unquoted or badly quoted statements
+var identifierTests = []struct {
+	name   string
+	quoted string
+}{
+	{"order", "`order`"},
+	{"group", "`group`"},
+	{"select", "`select`"},
+	{"we`ird", "`we``ird`"},
+}
+
+func TestIdentifierQuoting(t *testing.T) {
+	for _, test := range identifierTests {
+		t.Run(test.name, func(t *testing.T) {
+			// a database, a table and a view of the same name
+			view := test.name + "_v"
+			quotedView := mockQuote(view)
+			tables := []mockTable{
+				{
+					name:    test.name,
+					ddl:     "CREATE TABLE " + test.quoted + " (`id` int NOT NULL)",
+					columns: []*sqlmock.Column{sqlmock.NewColumn("id").OfType("INT", int64(0))},
+					rows:    [][]driver.Value{{int64(1)}},
+				},
+				{
+					name: view,
+					view: true,
+					ddl:  "CREATE VIEW " + quotedView + " AS SELECT `id` FROM " + test.quoted,
+				},
+			}
+
+			// the queries of the dump are checked by mockDump
+			dump := mockDump(t, test.name, tables, WithTruncateTables())
+			for _, stmt := range []string{
+				"USE " + test.quoted + ";\n",
+				"DROP TABLE IF EXISTS " + test.quoted + ";\n",
+				"TRUNCATE TABLE " + test.quoted + ";\n",
+				"INSERT INTO " + test.quoted + " VALUES (1);\n",
+				"DROP VIEW IF EXISTS " + quotedView + ";\n",
+			} {
+				if !strings.Contains(dump, stmt) {
+					t.Errorf("dump misses %q:\n%s", stmt, dump)
+				}
+			}
+
+			// the bundle of a tagged table has USE statements of its own
+			var bundle bytes.Buffer
+			_ = mockDump(t, test.name, tables[:1], WithTableTags(map[string]string{test.name: "cold"}), WithTagBundle("cold", &bundle))
+			for _, stmt := range []string{
+				"USE " + test.quoted + ";\n",
+				"INSERT INTO " + test.quoted + " VALUES (1);\n",
+			} {
+				if !strings.Contains(bundle.String(), stmt) {
+					t.Errorf("bundle misses %q:\n%s", stmt, bundle.String())
+				}
+			}
+
+			if got, want := qualifiedName(test.name+"."+test.name), test.quoted+"."+test.quoted; got != want {
+				t.Errorf("qualifiedName = %s, want %s", got, want)
+			}
+
+			conn, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer func() {
+				_ = conn.Close()
+			}()
+			mock.ExpectQuery("SELECT MIN(" + test.quoted + "), MAX(" + test.quoted + ") FROM " + test.quoted).
+				WillReturnRows(sqlmock.NewRows([]string{"MIN", "MAX"}).AddRow("1", "100"))
+			ranges, err := splitRanges(conn, test.name, test.name, "", 2)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if want := "(" + test.quoted + " < 50 OR " + test.quoted + " IS NULL)"; len(ranges) != 2 || ranges[0] != want {
+				t.Errorf("split ranges = %q, want %s first", ranges, want)
+			}
+
+			o := &sourceOption{database: test.name}
+			if got, want := preprocess("USE `shop`", o), "USE "+test.quoted; got != want {
+				t.Errorf("source USE = %s, want %s", got, want)
+			}
+
+			dir := t.TempDir()
+			err = os.WriteFile(filepath.Join(dir, test.name+"-schema-create.sql"), []byte("CREATE DATABASE "+test.quoted+";\n"), 0644)
+			if err != nil {
+				t.Fatal(err)
+			}
+			files, err := mydumperFiles(dir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			reader := &fileChainReader{files: files}
+			defer reader.close()
+			content, err := io.ReadAll(reader)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if stmt := "USE " + test.quoted + ";\n"; !strings.Contains(string(content), stmt) {
+				t.Errorf("mydumper files miss %q:\n%s", stmt, content)
+			}
+		})
+	}
+}
@@ -0,0 +1,98 @@
+package mysqldump
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+var loadDataLocalRe = regexp.MustCompile(`(?is)^(LOAD\s+DATA\s+(?:LOW_PRIORITY\s+|CONCURRENT\s+)?LOCAL\s+INFILE\s+)'((?:[^'\\]|\\.|'')*)'`)
+
+// WithLocalInfile allow LOAD DATA LOCAL INFILE statements of the dump, the referenced
+// files are registered into the allowlist of the mysql driver for the duration of the
+// statement. The paths must be relative and stay inside dir, the others are rejected.
+// The server must have local_infile enabled.
+func WithLocalInfile(dir string) SourceOption {
+	return func(o *sourceOption) {
+		o.localInfile = true
+		o.localInfileDir = dir
+	}
+}
+
+// prepareLocalInfile register the file of a LOAD DATA LOCAL INFILE statement, it returns
+// the statement with the resolved path and a func to deregister the file
+func prepareLocalInfile(dml, dir string) (string, func(), error) {
+	m := loadDataLocalRe.FindStringSubmatchIndex(dml)
+	if m == nil {
+		return dml, func() {}, nil
+	}
+
+	path := unescapeString(dml[m[4]:m[5]])
+	if path == "" {
+		return "", nil, fmt.Errorf("LOAD DATA LOCAL INFILE without file: %s", dml)
+	}
+	path, err := localInfilePath(dir, path)
+	if err != nil {
+		return "", nil, err
+	}
+
+	mysql.RegisterLocalFile(path)
+	dml = dml[:m[3]] + "'" + strings.Replace(strings.Replace(path, `\`, `\\`, -1), "'", `\'`, -1) + "'" + dml[m[1]:]
+	return dml, func() {
+		mysql.DeregisterLocalFile(path)
+	}, nil
+}
+
+// localInfilePath resolve name against dir, a name that is absolute or leaves dir is an error
+func localInfilePath(dir, name string) (string, error) {
+	name = filepath.Clean(filepath.FromSlash(name))
+	if filepath.IsAbs(name) || filepath.VolumeName(name) != "" {
+		return "", fmt.Errorf("LOAD DATA LOCAL INFILE of an absolute path: %s", name)
+	}
+
+	root, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+	path := filepath.Join(root, name)
+	rel, err := filepath.Rel(root, path)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("LOAD DATA LOCAL INFILE outside of %s: %s", dir, name)
+	}
+	return path, nil
+}
+
+// unescapeString the value of a single quoted string literal without its quotes
+func unescapeString(s string) string {
+	var builder strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == '\\' && i+1 < len(s):
+			i++
+			switch s[i] {
+			case '0':
+				builder.WriteByte(0)
+			case 'n':
+				builder.WriteByte('\n')
+			case 'r':
+				builder.WriteByte('\r')
+			case 't':
+				builder.WriteByte('\t')
+			case 'Z':
+				builder.WriteByte('\x1a')
+			default:
+				builder.WriteByte(s[i])
+			}
+		case c == '\'' && i+1 < len(s) && s[i+1] == '\'':
+			i++
+			builder.WriteByte('\'')
+		default:
+			builder.WriteByte(c)
+		}
+	}
+	return builder.String()
+}
@@ -0,0 +1,56 @@
+package mysqldump
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestPrepareLocalInfile(t *testing.T) {
+	dir := t.TempDir()
+	root, err := filepath.Abs(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		dml  string
+		want string
+	}{
+		{"LOAD DATA LOCAL INFILE 'order.tsv' INTO TABLE `order`",
+			"LOAD DATA LOCAL INFILE '" + filepath.Join(root, "order.tsv") + "' INTO TABLE `order`"},
+		{"load data concurrent local infile 'data/../order.tsv' INTO TABLE `order`",
+			"load data concurrent local infile '" + filepath.Join(root, "order.tsv") + "' INTO TABLE `order`"},
+		{`LOAD DATA LOCAL INFILE 'it\'s.tsv' INTO TABLE t`,
+			"LOAD DATA LOCAL INFILE '" + filepath.Join(root, `it\'s.tsv`) + "' INTO TABLE t"},
+		{"LOAD DATA LOCAL INFILE 'it''s '' ok.tsv' INTO TABLE t",
+			"LOAD DATA LOCAL INFILE '" + filepath.Join(root, `it\'s \' ok.tsv`) + "' INTO TABLE t"},
+		{`LOAD DATA LOCAL INFILE 'a\\b.tsv' INTO TABLE t`,
+			"LOAD DATA LOCAL INFILE '" + filepath.Join(root, `a\\b.tsv`) + "' INTO TABLE t"},
+		{"LOAD DATA INFILE '/var/lib/mysql-files/t.tsv' INTO TABLE t", "LOAD DATA INFILE '/var/lib/mysql-files/t.tsv' INTO TABLE t"},
+	}
+	for _, test := range tests {
+		got, deregister, err := prepareLocalInfile(test.dml, dir)
+		if err != nil {
+			t.Errorf("prepareLocalInfile(%s): %v", test.dml, err)
+			continue
+		}
+		deregister()
+		if got != test.want {
+			t.Errorf("prepareLocalInfile(%s) = %s, want %s", test.dml, got, test.want)
+		}
+	}
+
+	for _, dml := range []string{
+		"LOAD DATA LOCAL INFILE '/etc/passwd' INTO TABLE t",
+		"LOAD DATA LOCAL INFILE '../secret.tsv' INTO TABLE t",
+		"LOAD DATA LOCAL INFILE 'data/../../secret.tsv' INTO TABLE t",
+		"LOAD DATA LOCAL INFILE '..' INTO TABLE t",
+		`LOAD DATA LOCAL INFILE '.\./x' INTO TABLE t`,
+		`LOAD DATA LOCAL INFILE '/tmp/it\'s.tsv' INTO TABLE t`,
+		"LOAD DATA LOCAL INFILE '' INTO TABLE t",
+	} {
+		if _, _, err := prepareLocalInfile(dml, dir); err == nil {
+			t.Errorf("prepareLocalInfile(%s) accepted", dml)
+		}
+	}
+}
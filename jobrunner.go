@@ -0,0 +1,248 @@
+package mysqldump
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"sync"
+	"time"
+)
+
+// JobRunner run Dump and Source jobs concurrently within a budget of connections and
+// bandwidth shared by all of them, eg: to back up the databases of dozens of tenants of
+// one instance without exhausting its connections. Run may be called concurrently, the
+// budget applies to all the runs.
+type JobRunner struct {
+	maxConns int
+	limiter  *bandwidthLimiter
+
+	mu   sync.Mutex
+	used int
+	// closed when connections are given back
+	released chan struct{}
+}
+
+// NewJobRunner a JobRunner whose jobs hold at most maxConns connections at once and
+// write (Dump) or read (Source) at most bytesPerSecond bytes per second in total, 0 for
+// no limit. A job waits until all the connections it needs are free, eg: 1 + the workers
+// of WithSplitTable for a Dump.
+func NewJobRunner(maxConns int, bytesPerSecond int64) *JobRunner {
+	r := &JobRunner{
+		maxConns: maxConns,
+		released: make(chan struct{}),
+	}
+	if bytesPerSecond > 0 {
+		r.limiter = &bandwidthLimiter{bytesPerSecond: bytesPerSecond}
+	}
+	return r
+}
+
+// Job a job of a JobRunner, see DumpJob and SourceJob
+type Job struct {
+	Name  string
+	conns int
+	run   func(ctx context.Context, limiter *bandwidthLimiter) error
+}
+
+// DumpJob a job running dumper into writer, the console if nil, opts are applied for this
+// run only
+func DumpJob(name string, dumper *Dumper, writer io.Writer, opts ...DumpOption) Job {
+	if writer == nil {
+		writer = os.Stdout
+	}
+
+	var o dumpOption
+	for _, opt := range append(dumper.Options(), opts...) {
+		opt(&o)
+	}
+	conns := 1
+	for _, workers := range o.splitTables {
+		if workers > 1 && 1+workers > conns {
+			conns = 1 + workers
+		}
+	}
+
+	return Job{
+		Name:  name,
+		conns: conns,
+		run: func(ctx context.Context, limiter *bandwidthLimiter) error {
+			writer := writer
+			if limiter != nil {
+				writer = &limitedWriter{ctx: ctx, writer: writer, limiter: limiter}
+			}
+			return dumper.Run(ctx, append(opts, WithWriter(writer))...)
+		},
+	}
+}
+
+// SourceJob a job loading reader with sourcer, opts are applied for this run only
+func SourceJob(name string, sourcer *Sourcer, reader io.Reader, opts ...SourceOption) Job {
+	return Job{
+		Name:  name,
+		conns: 1,
+		run: func(ctx context.Context, limiter *bandwidthLimiter) error {
+			reader := reader
+			if limiter != nil {
+				reader = &limitedReader{ctx: ctx, reader: reader, limiter: limiter}
+			}
+			return sourcer.Run(ctx, reader, opts...)
+		},
+	}
+}
+
+// JobResult the outcome of a job of JobRunner.Run
+type JobResult struct {
+	Name string
+	Err  error
+	// Wait the time the job waited for its connections
+	Wait     time.Duration
+	Duration time.Duration
+}
+
+// Run run jobs concurrently within the budget of the runner, the results are in the order
+// of jobs. The error tells how many jobs failed, see the results for their errors.
+func (r *JobRunner) Run(ctx context.Context, jobs ...Job) ([]JobResult, error) {
+	results := make([]JobResult, len(jobs))
+	var wg sync.WaitGroup
+	for i, job := range jobs {
+		wg.Add(1)
+		go func(result *JobResult, job Job) {
+			defer wg.Done()
+			result.Name = job.Name
+
+			start := time.Now()
+			conns, err := r.take(ctx, job.conns)
+			result.Wait = time.Since(start)
+			if err != nil {
+				result.Err = err
+				return
+			}
+			defer r.give(conns)
+
+			start = time.Now()
+			result.Err = job.run(ctx, r.limiter)
+			result.Duration = time.Since(start)
+		}(&results[i], job)
+	}
+	wg.Wait()
+
+	failed := 0
+	for _, result := range results {
+		if result.Err != nil {
+			log.Printf("[error] [job] %s: %v \n", result.Name, result.Err)
+			failed++
+		}
+	}
+	if failed > 0 {
+		return results, fmt.Errorf("%d of %d jobs failed", failed, len(jobs))
+	}
+	return results, nil
+}
+
+// take n connections of the budget, all at once so that jobs waiting for their further
+// connections never hold the ones the others wait for
+func (r *JobRunner) take(ctx context.Context, n int) (int, error) {
+	if r.maxConns <= 0 {
+		return 0, nil
+	}
+	if n > r.maxConns {
+		n = r.maxConns
+	}
+	for {
+		// a released connection and the cancellation may be ready at once
+		if err := ctx.Err(); err != nil {
+			return 0, err
+		}
+		r.mu.Lock()
+		if r.used+n <= r.maxConns {
+			r.used += n
+			r.mu.Unlock()
+			return n, nil
+		}
+		released := r.released
+		r.mu.Unlock()
+
+		select {
+		case <-released:
+		case <-ctx.Done():
+			return 0, ctx.Err()
+		}
+	}
+}
+
+// give back n connections of take
+func (r *JobRunner) give(n int) {
+	if n == 0 {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.used -= n
+	close(r.released)
+	r.released = make(chan struct{})
+}
+
+// bandwidthLimiter pace the bytes of all the jobs to bytesPerSecond
+type bandwidthLimiter struct {
+	bytesPerSecond int64
+
+	mu sync.Mutex
+	// when the bytes let through so far are paid for
+	next time.Time
+}
+
+// wait until n more bytes fit in the bandwidth
+func (l *bandwidthLimiter) wait(ctx context.Context, n int) error {
+	l.mu.Lock()
+	now := time.Now()
+	if l.next.Before(now) {
+		l.next = now
+	}
+	delay := l.next.Sub(now)
+	l.next = l.next.Add(time.Duration(float64(n) / float64(l.bytesPerSecond) * float64(time.Second)))
+	l.mu.Unlock()
+
+	if delay <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+type limitedWriter struct {
+	ctx     context.Context
+	writer  io.Writer
+	limiter *bandwidthLimiter
+}
+
+func (w *limitedWriter) Write(p []byte) (int, error) {
+	err := w.limiter.wait(w.ctx, len(p))
+	if err != nil {
+		return 0, err
+	}
+	return w.writer.Write(p)
+}
+
+type limitedReader struct {
+	ctx     context.Context
+	reader  io.Reader
+	limiter *bandwidthLimiter
+}
+
+func (r *limitedReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	if n > 0 {
+		if waitErr := r.limiter.wait(r.ctx, n); waitErr != nil {
+			return n, waitErr
+		}
+	}
+	return n, err
+}
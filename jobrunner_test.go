@@ -0,0 +1,153 @@
+package mysqldump
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// fakeJob a job running run instead of a Dumper
+func fakeJob(name string, conns int, run func(ctx context.Context) error) Job {
+	return Job{
+		Name:  name,
+		conns: conns,
+		run: func(ctx context.Context, limiter *bandwidthLimiter) error {
+			return run(ctx)
+		},
+	}
+}
+
+func TestJobRunnerScheduling(t *testing.T) {
+	runner := NewJobRunner(3, 0)
+
+	var mu sync.Mutex
+	var held, maxHeld int
+	job := func(name string, conns int) Job {
+		return fakeJob(name, conns, func(ctx context.Context) error {
+			mu.Lock()
+			held += conns
+			if held > maxHeld {
+				maxHeld = held
+			}
+			mu.Unlock()
+			time.Sleep(10 * time.Millisecond)
+			mu.Lock()
+			held -= conns
+			mu.Unlock()
+			return nil
+		})
+	}
+	var jobs []Job
+	for _, name := range []string{"a", "b", "c", "d", "e", "f"} {
+		jobs = append(jobs, job(name, 1))
+	}
+	jobs = append(jobs, job("split", 2), job("split2", 2))
+
+	results, err := runner.Run(context.Background(), jobs...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, result := range results {
+		if result.Name != jobs[i].Name || result.Err != nil {
+			t.Errorf("result %d = %s %v, want %s without error", i, result.Name, result.Err, jobs[i].Name)
+		}
+	}
+	if maxHeld > 3 {
+		t.Errorf("%d connections held at once, the budget is 3", maxHeld)
+	}
+	if runner.used != 0 {
+		t.Errorf("%d connections still taken", runner.used)
+	}
+
+	// the workers of WithSplitTable are connections of the job, capped at the budget
+	dumpJob := DumpJob("tenant", NewDumperWithConn(nil, WithSplitTable("order", 4)), nil)
+	if dumpJob.conns != 5 {
+		t.Errorf("dump job with 4 workers takes %d connections, want 5", dumpJob.conns)
+	}
+	conns, err := runner.take(context.Background(), dumpJob.conns)
+	if err != nil || conns != 3 {
+		t.Errorf("take = %d, %v, want the 3 connections of the budget", conns, err)
+	}
+	runner.give(conns)
+}
+
+func TestJobRunnerRetry(t *testing.T) {
+	runner := NewJobRunner(1, 0)
+
+	var attempts int32
+	flaky := fakeJob("flaky", 1, func(ctx context.Context) error {
+		if atomic.AddInt32(&attempts, 1) == 1 {
+			return errors.New("connection lost")
+		}
+		return nil
+	})
+	ok := fakeJob("ok", 1, func(ctx context.Context) error {
+		return nil
+	})
+
+	jobs := []Job{flaky, ok}
+	results, err := runner.Run(context.Background(), jobs...)
+	if err == nil || err.Error() != "1 of 2 jobs failed" {
+		t.Fatalf("err = %v, want 1 of 2 jobs failed", err)
+	}
+	if results[0].Err == nil || results[1].Err != nil {
+		t.Fatalf("results = %+v, want only flaky failed", results)
+	}
+
+	// the failed job gave its connection back, running it again succeeds
+	var retry []Job
+	for i, result := range results {
+		if result.Err != nil {
+			retry = append(retry, jobs[i])
+		}
+	}
+	results, err = runner.Run(context.Background(), retry...)
+	if err != nil || len(results) != 1 || results[0].Name != "flaky" {
+		t.Fatalf("retry = %+v, %v", results, err)
+	}
+	if attempts != 2 {
+		t.Errorf("%d attempts, want 2", attempts)
+	}
+}
+
+func TestJobRunnerCancel(t *testing.T) {
+	runner := NewJobRunner(1, 0)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// the first job holds the only connection until the cancellation, the other one waits
+	var once sync.Once
+	started := make(chan struct{})
+	var ran int32
+	job := func(name string) Job {
+		return fakeJob(name, 1, func(ctx context.Context) error {
+			atomic.AddInt32(&ran, 1)
+			once.Do(func() { close(started) })
+			<-ctx.Done()
+			return ctx.Err()
+		})
+	}
+	go func() {
+		<-started
+		cancel()
+	}()
+
+	results, err := runner.Run(ctx, job("a"), job("b"))
+	if err == nil || err.Error() != "2 of 2 jobs failed" {
+		t.Fatalf("err = %v, want 2 of 2 jobs failed", err)
+	}
+	for _, result := range results {
+		if !errors.Is(result.Err, context.Canceled) {
+			t.Errorf("%s: err = %v, want context canceled", result.Name, result.Err)
+		}
+	}
+	if ran != 1 {
+		t.Errorf("%d jobs ran, want only the one holding the connection", ran)
+	}
+	if runner.used != 0 {
+		t.Errorf("%d connections still taken", runner.used)
+	}
+}
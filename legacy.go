@@ -0,0 +1,70 @@
+package mysqldump
+
+import (
+	"log"
+	"regexp"
+	"strings"
+
+	"mysqldump/sqlutil"
+)
+
+// WithLegacyBackslashes load the dumps of format 1 with the sql_mode NO_BACKSLASH_ESCAPES:
+// the first versions of Dump doubled the quotes of text and JSON values but wrote their
+// backslashes as is, eg: 'C:\new' is restored as C:\new instead of C:<newline>ew. Later
+// format 1 dumps escape JSON values with backslashes, they must be loaded without it.
+func WithLegacyBackslashes() SourceOption {
+	return func(o *sourceOption) {
+		o.legacyBackslashes = true
+	}
+}
+
+// legacyEmptyBinary the empty binary value of format 1, 0x is not a valid literal
+var legacyEmptyBinary = regexp.MustCompile(`(?i)0x\s*[,)]`)
+
+// startLegacyMode prepare the session for a dump of format 1, the returned func puts the
+// session back
+func (o *sourceOption) startLegacyMode(db *dbWrapper) (func(), error) {
+	log.Printf("[info] the dump has no format line, it is loaded as format 1\n")
+	if !o.legacyBackslashes {
+		return func() {}, nil
+	}
+
+	_, err := db.Exec("SET @mysqldump_sql_mode = @@SESSION.sql_mode, SESSION sql_mode = CONCAT(@@SESSION.sql_mode, ',NO_BACKSLASH_ESCAPES');")
+	if err != nil {
+		return nil, err
+	}
+	return func() {
+		_, err := db.Exec("SET SESSION sql_mode = @mysqldump_sql_mode;")
+		if err != nil {
+			log.Printf("[warn] restore sql_mode: %v\n", err)
+		}
+	}, nil
+}
+
+// syntax the string syntax of the statements of the dump, those of format 1 are loaded
+// without backslash escapes with WithLegacyBackslashes
+func (o *sourceOption) syntax() sqlutil.Syntax {
+	return sqlutil.Syntax{NoBackslashEscapes: o.format.Version == 1 && o.legacyBackslashes}
+}
+
+// legacyStatement rewrite a statement of a dump of format 1 into one the server accepts:
+// the empty binary values written as 0x are written like Dump writes them now
+func legacyStatement(dml string, syntax sqlutil.Syntax) string {
+	verb := sqlutil.StatementVerb(dml)
+	if verb != "INSERT" && verb != "REPLACE" || !legacyEmptyBinary.MatchString(dml) {
+		return dml
+	}
+
+	values, err := syntax.SplitInsertValues(dml)
+	if err != nil {
+		return dml
+	}
+	for _, row := range values.Rows {
+		for i, value := range row {
+			if strings.EqualFold(strings.TrimSpace(value), "0x") {
+				row[i] = "x''"
+			}
+		}
+	}
+	return values.String()
+}
@@ -0,0 +1,28 @@
+package mysqldump
+
+import (
+	"testing"
+
+	"mysqldump/sqlutil"
+)
+
+func TestLegacyStatement(t *testing.T) {
+	tests := []struct {
+		name   string
+		syntax sqlutil.Syntax
+		dml    string
+		want   string
+	}{
+		{"last value", sqlutil.Syntax{}, "INSERT INTO `t` VALUES (1,0x)", "INSERT INTO `t` VALUES (1,x'')"},
+		{"first value", sqlutil.Syntax{}, "INSERT INTO `t` VALUES (0x,1)", "INSERT INTO `t` VALUES (x'',1)"},
+		{"spaces and case", sqlutil.Syntax{}, "INSERT INTO `t` VALUES (0X ,1),(2, 0x )", "INSERT INTO `t` VALUES (x'',1),(2,x'')"},
+		{"in a string", sqlutil.Syntax{}, "INSERT INTO `t` VALUES ('0x,',0x01)", "INSERT INTO `t` VALUES ('0x,',0x01)"},
+		{"not an insert", sqlutil.Syntax{}, "SELECT 0x, 1", "SELECT 0x, 1"},
+		{"no backslash escapes", sqlutil.Syntax{NoBackslashEscapes: true}, `INSERT INTO t VALUES ('C:\',0x)`, `INSERT INTO t VALUES ('C:\',x'')`},
+	}
+	for _, test := range tests {
+		if got := legacyStatement(test.dml, test.syntax); got != test.want {
+			t.Errorf("%s: legacyStatement(%q) = %q, want %q", test.name, test.dml, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package mysqldump
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// lintIndexBytes the largest index key part of the COMPACT and REDUNDANT row formats,
+// longer varchar keys fail to restore there and bloat the index everywhere else
+const lintIndexBytes = 767
+
+// lintMoneyColumn column names that usually hold amounts of money
+var lintMoneyColumn = regexp.MustCompile(`(?i)(price|amount|cost|total|balance|money|fee|salary|payment|revenue)`)
+
+// WithSchemaLint check the schema of each dumped table and report the issues into the
+// DumpResult warnings, eg: "lint shop.orders: no primary key". It flags tables without a
+// primary key, utf8 (utf8mb3) tables and columns, FLOAT and DOUBLE money columns and
+// varchar index keys longer than 767 bytes. The dump itself is not changed.
+func WithSchemaLint() DumpOption {
+	return func(option *dumpOption) {
+		option.isSchemaLint = true
+	}
+}
+
+// lintTable warn about the schema issues of a table, a failed check is a warning too
+func (o *dumpOption) lintTable(db dbConn, dbName, table string) {
+	issues, err := lintTable(db, dbName, table)
+	if err != nil {
+		o.warnf("lint %s.%s: %v", dbName, table, err)
+		return
+	}
+	for _, issue := range issues {
+		o.warnf("lint %s.%s: %s", dbName, table, issue)
+	}
+}
+
+func lintTable(db dbConn, dbName, table string) ([]string, error) {
+	var issues []string
+
+	primaryKey, err := getPrimaryKeyColumns(db, dbName, table)
+	if err != nil {
+		return nil, err
+	}
+	if len(primaryKey) == 0 {
+		issues = append(issues, "no primary key")
+	}
+
+	err = queryRows(db, "SELECT TABLE_COLLATION FROM information_schema.TABLES WHERE TABLE_SCHEMA = ? AND TABLE_NAME = ?",
+		[]interface{}{dbName, table}, func(values []string) {
+			if isUTF8MB3(values[0]) {
+				issues = append(issues, fmt.Sprintf("default collation %s is utf8mb3, use utf8mb4", values[0]))
+			}
+		})
+	if err != nil {
+		return nil, err
+	}
+
+	err = queryRows(db, "SELECT COLUMN_NAME, DATA_TYPE, COALESCE(CHARACTER_SET_NAME, '') FROM information_schema.COLUMNS "+
+		"WHERE TABLE_SCHEMA = ? AND TABLE_NAME = ? ORDER BY ORDINAL_POSITION",
+		[]interface{}{dbName, table}, func(values []string) {
+			column, dataType, charset := values[0], strings.ToLower(values[1]), values[2]
+			if isUTF8MB3(charset) {
+				issues = append(issues, fmt.Sprintf("column %s uses the %s character set, use utf8mb4", column, charset))
+			}
+			if (dataType == "float" || dataType == "double") && lintMoneyColumn.MatchString(column) {
+				issues = append(issues, fmt.Sprintf("column %s looks like money but is %s, use DECIMAL", column, strings.ToUpper(dataType)))
+			}
+		})
+	if err != nil {
+		return nil, err
+	}
+
+	err = queryRows(db, "SELECT s.INDEX_NAME, s.COLUMN_NAME, COALESCE(s.SUB_PART, c.CHARACTER_MAXIMUM_LENGTH) * cs.MAXLEN "+
+		"FROM information_schema.STATISTICS s "+
+		"JOIN information_schema.COLUMNS c ON c.TABLE_SCHEMA = s.TABLE_SCHEMA AND c.TABLE_NAME = s.TABLE_NAME AND c.COLUMN_NAME = s.COLUMN_NAME "+
+		"JOIN information_schema.CHARACTER_SETS cs ON cs.CHARACTER_SET_NAME = c.CHARACTER_SET_NAME "+
+		"WHERE s.TABLE_SCHEMA = ? AND s.TABLE_NAME = ? AND c.DATA_TYPE IN ('varchar', 'char') ORDER BY s.INDEX_NAME, s.SEQ_IN_INDEX",
+		[]interface{}{dbName, table}, func(values []string) {
+			bytes, _ := strconv.Atoi(values[2])
+			if bytes > lintIndexBytes {
+				issues = append(issues, fmt.Sprintf("index %s key part %s is up to %d bytes, over %d", values[0], values[1], bytes, lintIndexBytes))
+			}
+		})
+	if err != nil {
+		return nil, err
+	}
+	return issues, nil
+}
+
+// isUTF8MB3 eg: "utf8", "utf8mb3" or the collation "utf8_general_ci"
+func isUTF8MB3(name string) bool {
+	name = strings.ToLower(name)
+	return name == "utf8" || name == "utf8mb3" || strings.HasPrefix(name, "utf8_") || strings.HasPrefix(name, "utf8mb3_")
+}
@@ -0,0 +1,114 @@
+package mysqldump
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+
+	"mysqldump/sqlutil"
+)
+
+// lockRetryBackoff the wait before the first retry of a table, doubled for each retry
+const lockRetryBackoff = time.Second
+
+// WithLockRetry when reading a table fails on a lock, eg: a lock wait timeout on its
+// metadata lock held by a long ALTER, the table is dumped again at the end of the dump, up
+// to attempts times with an exponential backoff, instead of failing the dump. The retries
+// are recorded into DumpResult.Retries. The rows of a retried table come after the
+// triggers of its database and are loaded with foreign key checks off.
+func WithLockRetry(attempts int) DumpOption {
+	return func(option *dumpOption) {
+		option.lockRetryAttempts = attempts
+	}
+}
+
+// TableRetry a table dumped again after a lock error, see WithLockRetry
+type TableRetry struct {
+	DB    string
+	Table string
+	// Attempts the number of retries
+	Attempts int
+	// Err the last lock error
+	Err error
+}
+
+// lockRetry a table waiting for its retry
+type lockRetry struct {
+	db    string
+	table string
+	where string
+	err   error
+}
+
+// isLockError lock wait timeout, deadlock and NOWAIT errors, the statement can be retried
+func isLockError(err error) bool {
+	var mysqlErr *mysql.MySQLError
+	if errors.As(err, &mysqlErr) {
+		switch mysqlErr.Number {
+		case 1205, 1213, 3572:
+			return true
+		}
+	}
+	return false
+}
+
+// queueLockRetry defer the data of a table that failed on err, it reports whether the
+// table is retried
+func (o *dumpOption) queueLockRetry(dbName, table, where string, err error) bool {
+	if o.lockRetryAttempts <= 0 || !isLockError(err) {
+		return false
+	}
+	o.warnf("%s.%s: %v, retried at the end of the dump", dbName, table, err)
+	o.lockRetries = append(o.lockRetries, lockRetry{db: dbName, table: table, where: where, err: err})
+	return true
+}
+
+// retryLockedTables dump the tables queued by queueLockRetry
+func (o *dumpOption) retryLockedTables(ctx context.Context, db dbConn, buf *SafeWriter) error {
+	if len(o.lockRetries) == 0 {
+		return nil
+	}
+
+	_, _ = buf.WriteString(o.annotation("", "", "", 0))
+	_, _ = buf.WriteString("SET FOREIGN_KEY_CHECKS=0;\n")
+	for _, retry := range o.lockRetries {
+		record := TableRetry{DB: retry.db, Table: retry.table, Err: retry.err}
+
+		_, err := db.Exec("USE " + sqlutil.QuoteIdentifier(retry.db))
+		if err != nil {
+			return err
+		}
+		_, _ = buf.WriteString(o.annotation(retry.db, "", "", 0))
+		_, _ = buf.WriteString(fmt.Sprintf("USE %s;\n", sqlutil.QuoteIdentifier(o.template(retry.db))))
+
+		backoff := lockRetryBackoff
+		for {
+			record.Attempts++
+			select {
+			case <-time.After(backoff):
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+			backoff *= 2
+
+			_, err = writeTableData(db, retry.db, retry.table, retry.where, o.tableWriter(buf, retry.db, retry.table), o)
+			if err == nil {
+				break
+			}
+			if !isLockError(err) || record.Attempts >= o.lockRetryAttempts {
+				return fmt.Errorf("%s.%s after %d retries: %w", retry.db, retry.table, record.Attempts, err)
+			}
+			record.Err = err
+		}
+
+		if o.result != nil {
+			o.result.Retries = append(o.result.Retries, record)
+		}
+	}
+	_, _ = buf.WriteString(o.annotation("", "", "", 0))
+	_, _ = buf.WriteString("SET FOREIGN_KEY_CHECKS=1;\n")
+	return nil
+}
@@ -0,0 +1,113 @@
+package mysqldump
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"mysqldump/sqlutil"
+)
+
+// Manifest the state of each table of a dump, keyed by "db.table": a fingerprint of its DDL
+// and a checksum of its data. Keep it with the dump (eg: as JSON) to take the next dump
+// with WithChangedSince.
+type Manifest struct {
+	Created time.Time         `json:"created"`
+	Tables  map[string]string `json:"tables"`
+	// ColumnStats the stats of the dumped columns by "db.table", see WithColumnStats
+	ColumnStats map[string][]ColumnStats `json:"column_stats,omitempty"`
+}
+
+// WithManifest record the state of the selected tables into manifest, including the tables
+// skipped by WithChangedSince, so that manifest is the base of the next differential dump.
+// Only keep manifest if the dump succeeded.
+func WithManifest(manifest *Manifest) DumpOption {
+	return func(option *dumpOption) {
+		option.manifest = manifest
+	}
+}
+
+// WithChangedSince only dump the tables whose DDL or data changed since previous, the
+// manifest of an earlier dump, eg: a nightly dump of a mostly static schema. The data is
+// compared with CHECKSUM TABLE, or with WithChangeColumn. Tables missing from previous are
+// changed.
+func WithChangedSince(previous *Manifest) DumpOption {
+	return func(option *dumpOption) {
+		option.previousManifest = previous
+	}
+}
+
+// WithChangeColumn compare the data with MAX(column) and COUNT(*) instead of a full
+// CHECKSUM TABLE in the tables having the column, eg: "updated_at". Cheaper, but an update
+// that does not touch column goes unnoticed.
+func WithChangeColumn(column string) DumpOption {
+	return func(option *dumpOption) {
+		option.changeColumn = column
+	}
+}
+
+// filterUnchangedTables record the state of tables into the manifest and drop the tables
+// unchanged since the previous manifest
+func (o *dumpOption) filterUnchangedTables(db dbConn, dbName string, tables []string, views map[string]bool) ([]string, error) {
+	if o.manifest != nil {
+		if o.manifest.Tables == nil {
+			o.manifest.Tables = make(map[string]string)
+		}
+		if o.manifest.Created.IsZero() {
+			o.manifest.Created = time.Now().UTC()
+		}
+	}
+
+	var changed []string
+	for _, table := range tables {
+		state, err := o.tableState(db, dbName, table, views[table])
+		if err != nil {
+			return nil, err
+		}
+		if o.manifest != nil {
+			o.manifest.Tables[dbName+"."+table] = state
+		}
+		if o.previousManifest == nil || o.previousManifest.Tables[dbName+"."+table] != state {
+			changed = append(changed, table)
+		}
+	}
+	return changed, nil
+}
+
+// tableState the DDL fingerprint then the data checksum of table, the method is part
+// of the state so that changing it dumps every table once
+func (o *dumpOption) tableState(db dbConn, dbName, table string, isView bool) (string, error) {
+	fingerprint, err := getTableFingerprint(db, table, isView)
+	if err != nil {
+		return "", err
+	}
+	if isView {
+		return "schema=" + fingerprint, nil
+	}
+
+	if o.changeColumn != "" {
+		var hasColumn int
+		err = db.QueryRow("SELECT COUNT(*) FROM information_schema.COLUMNS WHERE TABLE_SCHEMA = ? AND TABLE_NAME = ? AND COLUMN_NAME = ?",
+			dbName, table, o.changeColumn).Scan(&hasColumn)
+		if err != nil {
+			return "", err
+		}
+		if hasColumn > 0 {
+			var maxValue sql.NullString
+			var count int64
+			err = db.QueryRow(fmt.Sprintf("SELECT MAX(%s), COUNT(*) FROM %s", sqlutil.QuoteIdentifier(o.changeColumn), sqlutil.QuoteIdentifier(table))).Scan(&maxValue, &count) // ignore_security_alert_wait_for_fix SQL
+			if err != nil {
+				return "", err
+			}
+			return fmt.Sprintf("schema=%s max=%s count=%d", fingerprint, maxValue.String, count), nil
+		}
+	}
+
+	var name string
+	var checksum sql.NullString
+	err = db.QueryRow("CHECKSUM TABLE "+sqlutil.QuoteIdentifier(table)).Scan(&name, &checksum) // ignore_security_alert_wait_for_fix SQL
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("schema=%s checksum=%s", fingerprint, checksum.String), nil
+}
@@ -0,0 +1,168 @@
+package mysqldump
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"mysqldump/sqlutil"
+)
+
+// SourceDir load a backup written by mydumper, dir contains
+//
+//	metadata
+//	db-schema-create.sql            CREATE DATABASE
+//	db.table-schema.sql             CREATE TABLE
+//	db.table.sql, db.table.00000.sql table data, optionally .gz compressed
+//	db.view-schema-view.sql         CREATE VIEW
+//	db.table-schema-triggers.sql    CREATE TRIGGER
+//	db-schema-post.sql              procedures, functions and events
+//
+// The files are applied in that order through Source, so all SourceOption apply.
+func SourceDir(dns string, dir string, opts ...SourceOption) error {
+	files, err := mydumperFiles(dir)
+	if err != nil {
+		log.Printf("[error] %v\n", err)
+		return err
+	}
+
+	// mydumper --load-data writes LOAD DATA LOCAL INFILE of files next to the dump
+	opts = append([]SourceOption{WithLocalInfile(dir)}, opts...)
+
+	reader := &fileChainReader{files: files}
+	defer reader.close()
+
+	return Source(dns, reader, opts...)
+}
+
+type mydumperFile struct {
+	db   string
+	path string
+}
+
+// mydumperFiles the files of dir in restore order
+func mydumperFiles(dir string) ([]mydumperFile, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var groups [6][]mydumperFile
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || name == "metadata" {
+			continue
+		}
+
+		base := strings.TrimSuffix(name, ".gz")
+		if !strings.HasSuffix(base, ".sql") {
+			continue
+		}
+		base = strings.TrimSuffix(base, ".sql")
+
+		db := base
+		if idx := strings.Index(base, "."); idx != -1 {
+			db = base[:idx]
+		}
+		db = strings.TrimSuffix(strings.TrimSuffix(db, "-schema-create"), "-schema-post")
+		file := mydumperFile{db: db, path: filepath.Join(dir, name)}
+
+		switch {
+		case strings.HasSuffix(base, "-schema-create"):
+			groups[0] = append(groups[0], file)
+		case strings.HasSuffix(base, "-schema-view"):
+			groups[3] = append(groups[3], file)
+		case strings.HasSuffix(base, "-schema-triggers"):
+			groups[4] = append(groups[4], file)
+		case strings.HasSuffix(base, "-schema-post"):
+			groups[5] = append(groups[5], file)
+		case strings.HasSuffix(base, "-schema"):
+			groups[1] = append(groups[1], file)
+		case strings.Contains(base, "."):
+			groups[2] = append(groups[2], file)
+		}
+	}
+
+	var files []mydumperFile
+	for _, group := range groups {
+		// chunk numbers are zero padded, name order is restore order
+		sort.Slice(group, func(i, j int) bool {
+			return group[i].path < group[j].path
+		})
+		files = append(files, group...)
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no mydumper files in %s", dir)
+	}
+	return files, nil
+}
+
+// fileChainReader read the files one after the other, opening each one only when
+// it is reached, every file is preceded by a USE of its database
+type fileChainReader struct {
+	files   []mydumperFile
+	current io.Reader
+	closers []io.Closer
+}
+
+func (r *fileChainReader) Read(p []byte) (int, error) {
+	for {
+		if r.current == nil {
+			if len(r.files) == 0 {
+				return 0, io.EOF
+			}
+			err := r.open(r.files[0])
+			if err != nil {
+				return 0, err
+			}
+			r.files = r.files[1:]
+		}
+
+		n, err := r.current.Read(p)
+		if err == io.EOF {
+			r.close()
+			if n > 0 {
+				return n, nil
+			}
+			continue
+		}
+		return n, err
+	}
+}
+
+func (r *fileChainReader) open(file mydumperFile) error {
+	f, err := os.Open(file.path)
+	if err != nil {
+		return err
+	}
+	r.closers = append(r.closers, f)
+
+	var reader io.Reader = f
+	if strings.HasSuffix(file.path, ".gz") {
+		gz, err := gzip.NewReader(f)
+		if err != nil {
+			r.close()
+			return err
+		}
+		r.closers = append(r.closers, gz)
+		reader = gz
+	}
+
+	// the empty statement ends a previous file without a trailing ';'
+	header := strings.NewReader(fmt.Sprintf("\n;\nUSE %s;\n", sqlutil.QuoteIdentifier(file.db)))
+	r.current = io.MultiReader(header, reader)
+	return nil
+}
+
+func (r *fileChainReader) close() {
+	for _, closer := range r.closers {
+		_ = closer.Close()
+	}
+	r.closers = nil
+	r.current = nil
+}
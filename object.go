@@ -0,0 +1,306 @@
+package mysqldump
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+
+	"github.com/go-sql-driver/mysql"
+
+	"mysqldump/sqlutil"
+)
+
+// errNoPrivilege SHOW CREATE returns NULL instead of the statement without enough privileges
+var errNoPrivilege = errors.New("no privilege")
+
+// isPrivilegeError access denied errors, eg: 1142 SHOW VIEW command denied
+func isPrivilegeError(err error) bool {
+	if errors.Is(err, errNoPrivilege) {
+		return true
+	}
+
+	var mysqlErr *mysql.MySQLError
+	if errors.As(err, &mysqlErr) {
+		switch mysqlErr.Number {
+		case 1044, 1142, 1143, 1227, 1370:
+			return true
+		}
+	}
+	return false
+}
+
+var (
+	createViewRe    = regexp.MustCompile(`(?is)^CREATE\s+((?:ALGORITHM\s*=\s*\S+\s+)?(?:DEFINER\s*=\s*\S+\s+)?(?:SQL\s+SECURITY\s+\S+\s+)?VIEW\s)`)
+	createTriggerRe = regexp.MustCompile("(?is)^CREATE\\s+(?:DEFINER\\s*=\\s*\\S+\\s+)?TRIGGER\\s+((?:`[^`]+`|\\w+)(?:\\.(?:`[^`]+`|\\w+))?)")
+	createRoutineRe = regexp.MustCompile("(?is)^CREATE\\s+(?:DEFINER\\s*=\\s*\\S+\\s+)?(PROCEDURE|FUNCTION)\\s+((?:`[^`]+`|\\w+)(?:\\.(?:`[^`]+`|\\w+))?)")
+)
+
+// idempotentDDL rewrite ddl so that it can be applied more than once
+// CREATE TABLE -> CREATE TABLE IF NOT EXISTS
+// CREATE VIEW -> CREATE OR REPLACE VIEW
+// CREATE TRIGGER -> DROP TRIGGER IF EXISTS + CREATE TRIGGER
+// CREATE PROCEDURE/FUNCTION -> DROP PROCEDURE/FUNCTION IF EXISTS + CREATE PROCEDURE/FUNCTION
+func idempotentDDL(ddl string) string {
+	if strings.HasPrefix(ddl, "CREATE TABLE ") && !strings.HasPrefix(ddl, "CREATE TABLE IF NOT EXISTS") {
+		return strings.Replace(ddl, "CREATE TABLE", "CREATE TABLE IF NOT EXISTS", 1)
+	}
+
+	if createViewRe.MatchString(ddl) {
+		return createViewRe.ReplaceAllString(ddl, "CREATE OR REPLACE $1")
+	}
+
+	if m := createTriggerRe.FindStringSubmatch(ddl); m != nil {
+		return fmt.Sprintf("DROP TRIGGER IF EXISTS %s;;\n%s", m[1], ddl)
+	}
+
+	if m := createRoutineRe.FindStringSubmatch(ddl); m != nil {
+		return fmt.Sprintf("DROP %s IF EXISTS %s;;\n%s", strings.ToUpper(m[1]), m[2], ddl)
+	}
+
+	return ddl
+}
+
+// rewriteDDL apply the DDL options to a view, trigger or routine definition
+func (o *dumpOption) rewriteDDL(ddl string) string {
+	if o.profile != ProfileMySQL {
+		ddl = stripDefiner(ddl)
+	}
+	if o.isIdempotentDDL {
+		ddl = idempotentDDL(ddl)
+	}
+	return o.escapeTemplate(ddl)
+}
+
+func getViews(db dbConn) (map[string]bool, error) {
+	views := make(map[string]bool)
+	rows, err := db.Query("SHOW FULL TABLES WHERE Table_type = 'VIEW'")
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	for rows.Next() {
+		var view, tableType string
+		err = rows.Scan(&view, &tableType)
+		if err != nil {
+			return nil, err
+		}
+		views[view] = true
+	}
+	return views, rows.Err()
+}
+
+func getCreateViewSQL(db dbConn, view string) (string, error) {
+	var createViewSQL, charset, collation string
+	err := db.QueryRow("SHOW CREATE VIEW "+sqlutil.QuoteIdentifier(view)).Scan(&view, &createViewSQL, &charset, &collation) // ignore_security_alert_wait_for_fix SQL
+	if err != nil {
+		return "", err
+	}
+	return createViewSQL, nil
+}
+
+type viewDef struct {
+	db   string
+	name string
+	ddl  string
+}
+
+// sortViews order views so that a view comes after the views it selects from,
+// in any database. The definitions returned by SHOW CREATE VIEW reference
+// objects by their qualified name `db`.`name`.
+func sortViews(views []viewDef) []viewDef {
+	var sorted []viewDef
+	visited := make(map[int]int) // 1 visiting, 2 done
+
+	var visit func(i int)
+	visit = func(i int) {
+		if visited[i] != 0 {
+			// a cycle can not happen between views, keep the original order
+			return
+		}
+		visited[i] = 1
+		for j, dep := range views {
+			if j != i && strings.Contains(views[i].ddl, sqlutil.QuoteIdentifier(dep.db)+"."+sqlutil.QuoteIdentifier(dep.name)) {
+				visit(j)
+			}
+		}
+		visited[i] = 2
+		sorted = append(sorted, views[i])
+	}
+
+	for i := range views {
+		visit(i)
+	}
+	return sorted
+}
+
+func writeViews(views []viewDef, buf *SafeWriter, o *dumpOption) {
+	for _, view := range views {
+		_, _ = buf.WriteString(o.annotation(view.db, "", "", 0))
+		_, _ = buf.WriteString(fmt.Sprintf("USE %s;\n", sqlutil.QuoteIdentifier(o.template(view.db))))
+
+		if o.isDropTable {
+			_, _ = buf.WriteString(o.annotation(view.db, ObjectView, view.name, 0))
+			_, _ = buf.WriteString(fmt.Sprintf("DROP VIEW IF EXISTS %s;\n", o.escapeTemplate(sqlutil.QuoteIdentifier(view.name))))
+		}
+
+		if o.isDumpTable {
+			_, _ = buf.WriteString("-- ----------------------------\n")
+			_, _ = buf.WriteString(fmt.Sprintf("-- View structure for %s\n", view.name))
+			_, _ = buf.WriteString("-- ----------------------------\n")
+
+			_, _ = buf.WriteString(o.annotation(view.db, ObjectView, view.name, 0))
+			_, _ = buf.WriteString(o.rewriteDDL(view.ddl))
+			_, _ = buf.WriteString(";")
+
+			_, _ = buf.WriteString("\n\n")
+		}
+	}
+}
+
+// writeTriggers triggers and routines bodies contain ';', they are written
+// between DELIMITER ;; and DELIMITER ; like the official mysqldump does
+func writeTriggers(db dbConn, dbName string, buf *SafeWriter, o *dumpOption) error {
+	rows, err := db.Query("SHOW TRIGGERS")
+	if err != nil {
+		if o.skipOnPrivilegeError(err, fmt.Sprintf("triggers of %s", dbName)) {
+			return nil
+		}
+		log.Printf("[error] %v \n", err)
+		return err
+	}
+
+	var triggers []string
+	columns, err := rows.Columns()
+	if err != nil {
+		_ = rows.Close()
+		return err
+	}
+	for rows.Next() {
+		values := make([]sql.RawBytes, len(columns))
+		pointers := make([]interface{}, len(columns))
+		for i := range values {
+			pointers[i] = &values[i]
+		}
+		err = rows.Scan(pointers...)
+		if err != nil {
+			_ = rows.Close()
+			return err
+		}
+		triggers = append(triggers, string(values[0]))
+	}
+	_ = rows.Close()
+
+	for _, trigger := range triggers {
+		createTriggerSQL, err := getCreateObjectSQL(db, "TRIGGER", trigger)
+		if err != nil {
+			if o.skipOnPrivilegeError(err, fmt.Sprintf("trigger %s.%s", dbName, trigger)) {
+				continue
+			}
+			log.Printf("[error] %v \n", err)
+			return err
+		}
+		writeDelimited(buf, fmt.Sprintf("Trigger structure for %s", trigger), o.annotation(dbName, ObjectTrigger, trigger, 0), o.rewriteDDL(createTriggerSQL))
+	}
+	return nil
+}
+
+func writeRoutines(db dbConn, dbName string, buf *SafeWriter, o *dumpOption) error {
+	rows, err := db.Query("SELECT ROUTINE_TYPE, ROUTINE_NAME FROM information_schema.ROUTINES WHERE ROUTINE_SCHEMA = ?", dbName)
+	if err != nil {
+		if o.skipOnPrivilegeError(err, fmt.Sprintf("routines of %s", dbName)) {
+			return nil
+		}
+		log.Printf("[error] %v \n", err)
+		return err
+	}
+
+	type routine struct {
+		kind string
+		name string
+	}
+	var routines []routine
+	for rows.Next() {
+		var r routine
+		err = rows.Scan(&r.kind, &r.name)
+		if err != nil {
+			_ = rows.Close()
+			return err
+		}
+		routines = append(routines, r)
+	}
+	_ = rows.Close()
+
+	for _, r := range routines {
+		createRoutineSQL, err := getCreateObjectSQL(db, r.kind, r.name)
+		if err != nil {
+			if o.skipOnPrivilegeError(err, fmt.Sprintf("%s %s.%s", strings.ToLower(r.kind), dbName, r.name)) {
+				continue
+			}
+			log.Printf("[error] %v \n", err)
+			return err
+		}
+		writeDelimited(buf, fmt.Sprintf("%s structure for %s", r.kind[:1]+strings.ToLower(r.kind[1:]), r.name),
+			o.annotation(dbName, ObjectType(r.kind), r.name, 0), o.rewriteDDL(createRoutineSQL))
+	}
+	return nil
+}
+
+// getCreateObjectSQL SHOW CREATE TRIGGER/PROCEDURE/FUNCTION, the statement is the third column
+func getCreateObjectSQL(db dbConn, kind, name string) (string, error) {
+	rows, err := db.Query(fmt.Sprintf("SHOW CREATE %s %s", kind, sqlutil.QuoteIdentifier(name))) // ignore_security_alert_wait_for_fix SQL
+	if err != nil {
+		return "", err
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return "", err
+	}
+	if !rows.Next() {
+		if err = rows.Err(); err != nil {
+			return "", err
+		}
+		return "", fmt.Errorf("%s %s not found", strings.ToLower(kind), name)
+	}
+
+	values := make([]sql.NullString, len(columns))
+	pointers := make([]interface{}, len(columns))
+	for i := range values {
+		pointers[i] = &values[i]
+	}
+	err = rows.Scan(pointers...)
+	if err != nil {
+		return "", err
+	}
+	if len(values) < 3 || !values[2].Valid {
+		return "", fmt.Errorf("%w to show %s %s", errNoPrivilege, strings.ToLower(kind), name)
+	}
+	return values[2].String, nil
+}
+
+// writeDelimited annotation precedes each statement of ddl, eg: the DROP and the CREATE
+// of WithIdempotentDDL
+func writeDelimited(buf *SafeWriter, title, annotation, ddl string) {
+	_, _ = buf.WriteString("-- ----------------------------\n")
+	_, _ = buf.WriteString(fmt.Sprintf("-- %s\n", title))
+	_, _ = buf.WriteString("-- ----------------------------\n")
+
+	_, _ = buf.WriteString("DELIMITER ;;\n")
+	if annotation != "" {
+		ddl = annotation + strings.Replace(ddl, ";;\n", ";;\n"+annotation, -1)
+	}
+	_, _ = buf.WriteString(ddl)
+	_, _ = buf.WriteString(";;\n")
+	_, _ = buf.WriteString("DELIMITER ;\n")
+
+	_, _ = buf.WriteString("\n\n")
+}
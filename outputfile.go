@@ -0,0 +1,89 @@
+package mysqldump
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// WithOutputFile write the dump to the file path instead of WithWriter. The dump is
+// written to a temporary file next to path, synced to the disk and renamed to path once
+// it succeeded, so that a failed or crashed dump never leaves a partial dump at path nor
+// replaces the previous one.
+func WithOutputFile(path string) DumpOption {
+	return func(option *dumpOption) {
+		option.outputFile = path
+	}
+}
+
+// atomicFile a file written under a temporary name, renamed to its path by commit
+type atomicFile struct {
+	*os.File
+	path string
+	done bool
+	// the first failed write, the file is never committed after it
+	err error
+}
+
+func createAtomicFile(path string) (*atomicFile, error) {
+	path = filepath.Clean(filepath.FromSlash(path))
+	file, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return nil, err
+	}
+	return &atomicFile{File: file, path: path}, nil
+}
+
+// Write the writes of the file go through Write only, so that a failed one is remembered
+func (f *atomicFile) Write(p []byte) (int, error) {
+	n, err := f.File.Write(p)
+	if err != nil && f.err == nil {
+		f.err = err
+	}
+	return n, err
+}
+
+// WriteString see Write
+func (f *atomicFile) WriteString(s string) (int, error) {
+	return f.Write([]byte(s))
+}
+
+// ReadFrom see Write, io.Copy and bufio use it instead of Write when the file has it
+func (f *atomicFile) ReadFrom(r io.Reader) (int64, error) {
+	return io.Copy(struct{ io.Writer }{f}, r)
+}
+
+// commit sync the file to the disk and rename it to its path
+func (f *atomicFile) commit() error {
+	if f.err != nil {
+		return f.err
+	}
+	err := f.Sync()
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), f.path)
+	}
+	if err != nil {
+		return err
+	}
+	f.done = true
+
+	// the rename itself survives a crash once the directory is synced, directories can
+	// not be synced on Windows
+	if dir, err := os.Open(filepath.Dir(f.path)); err == nil {
+		_ = dir.Sync()
+		_ = dir.Close()
+	}
+	return nil
+}
+
+// abort remove the file unless it was committed
+func (f *atomicFile) abort() {
+	if f.done {
+		return
+	}
+	_ = f.Close()
+	_ = os.Remove(f.Name())
+}
@@ -0,0 +1,85 @@
+package mysqldump
+
+import (
+	"database/sql/driver"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+// failingWriter a writer of a full disk
+type failingWriter struct{}
+
+func (failingWriter) Write(p []byte) (int, error) {
+	return 0, errors.New("no space left on device")
+}
+
+// checkUntouched check that path holds the previous dump only
+func checkUntouched(t *testing.T, path string) {
+	t.Helper()
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(content) != "-- previous dump\n" {
+		t.Errorf("%s was replaced by %q", path, content)
+	}
+	entries, err := os.ReadDir(filepath.Dir(path))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("temporary files left next to %s: %v", path, entries)
+	}
+}
+
+func TestOutputFileFailedWrite(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "dump.sql")
+	err := os.WriteFile(path, []byte("-- previous dump\n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	table := mockTable{
+		name:    "order",
+		ddl:     "CREATE TABLE `order` (`id` int NOT NULL)",
+		columns: []*sqlmock.Column{sqlmock.NewColumn("id").OfType("INT", int64(0))},
+		rows:    [][]driver.Value{{int64(1)}},
+	}
+	err = mockRun(t, "shop", []mockTable{table}, WithOutputFile(path), WithWriterMiddleware(func(io.Writer) io.Writer {
+		return failingWriter{}
+	}))
+	if err == nil {
+		t.Fatal("the dump succeeded on a failing writer")
+	}
+	checkUntouched(t, path)
+}
+
+func TestAtomicFileFailedWrite(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "dump.sql")
+	err := os.WriteFile(path, []byte("-- previous dump\n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	file, err := createAtomicFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.abort()
+	_, _ = file.WriteString("-- Table structure for order\n")
+	// the next write fails
+	_ = file.File.Close()
+	if _, err = file.WriteString("INSERT INTO `order` VALUES (1);\n"); err == nil {
+		t.Fatal("write to a closed file succeeded")
+	}
+	if err = file.commit(); err == nil {
+		t.Fatal("commit after a failed write succeeded")
+	}
+	file.abort()
+	checkUntouched(t, path)
+}
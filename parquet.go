@@ -0,0 +1,257 @@
+package mysqldump
+
+import (
+	"database/sql"
+	"fmt"
+	"io"
+	"log"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/parquet-go/parquet-go"
+)
+
+// parquetColumn where a column of the result set is written in the parquet schema
+type parquetColumn struct {
+	kind     string
+	scale    int
+	index    int
+	maxLevel int
+}
+
+// DumpParquet write the rows of the selected tables into dir as parquet files, one <table>.parquet
+// file per table, eg: queried by DuckDB with SELECT * FROM 'dir/*.parquet'. The characters of the
+// names other than letters, digits and _ are encoded like the file names of MySQL, eg: @002f for /.
+// The schema is inferred from the column types, nullable columns are optional. When several
+// databases are exported each one gets its own sub directory. WithDBs, WithAllDatabases, WithTables,
+// WithAllTables and WithWhere select the rows.
+func DumpParquet(dns string, dir string, opts ...DumpOption) error {
+	var o dumpOption
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	multiDB := o.isAllDB || len(o.dbs) > 1
+
+	err := forEachTable(dns, &o, func(db dbConn, dbName, table string) error {
+		tableDir := dir
+		if multiDB {
+			tableDir = filepath.Join(dir, fileName(dbName))
+		}
+		err := os.MkdirAll(tableDir, 0755)
+		if err != nil {
+			return err
+		}
+
+		file, err := os.Create(filepath.Join(tableDir, fileName(table)+".parquet"))
+		if err != nil {
+			return err
+		}
+		defer func() {
+			_ = file.Close()
+		}()
+
+		err = writeParquet(db, table, file, &o)
+		if err != nil {
+			return err
+		}
+		return file.Close()
+	})
+	if err != nil {
+		log.Printf("[error] %v \n", err)
+		return err
+	}
+	return nil
+}
+
+// writeParquet write the rows of table into w, the schema is known once the query returned
+func writeParquet(db dbConn, table string, w io.Writer, o *dumpOption) error {
+	var writer *parquet.Writer
+	var columns []parquetColumn
+	err := scanRows(db, table, o, func(columnTypes []*sql.ColumnType, row []interface{}) error {
+		if writer == nil {
+			var schema *parquet.Schema
+			schema, columns = parquetSchema(table, columnTypes)
+			writer = parquet.NewWriter(w, schema, parquet.Compression(&parquet.Snappy))
+		}
+
+		values := make(parquet.Row, len(row))
+		for i, col := range row {
+			value, err := parquetValue(columns[i], col)
+			if err != nil {
+				return fmt.Errorf("table %s column %s: %v", table, columnTypes[i].Name(), err)
+			}
+			values[columns[i].index] = value
+		}
+		_, err := writer.WriteRows([]parquet.Row{values})
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	// an empty table still gets a file with its schema
+	if writer == nil {
+		columnTypes, err := queryColumnTypes(db, table, o)
+		if err != nil {
+			return err
+		}
+		schema, _ := parquetSchema(table, columnTypes)
+		writer = parquet.NewWriter(w, schema, parquet.Compression(&parquet.Snappy))
+	}
+	return writer.Close()
+}
+
+// parquetSchema the schema of a result set, the columns of a parquet group are sorted by name so
+// the position of each column is looked up once the schema is built
+func parquetSchema(table string, columnTypes []*sql.ColumnType) (*parquet.Schema, []parquetColumn) {
+	group := make(parquet.Group, len(columnTypes))
+	columns := make([]parquetColumn, len(columnTypes))
+	for i, columnType := range columnTypes {
+		node, column := parquetNode(columnType)
+		if nullable, ok := columnType.Nullable(); !ok || nullable {
+			node = parquet.Optional(node)
+		}
+		group[columnType.Name()] = node
+		columns[i] = column
+	}
+
+	schema := parquet.NewSchema(table, group)
+	for i, columnType := range columnTypes {
+		leaf, _ := schema.Lookup(columnType.Name())
+		columns[i].index = leaf.ColumnIndex
+		columns[i].maxLevel = leaf.MaxDefinitionLevel
+	}
+	return schema, columns
+}
+
+// parquetNode the parquet type of a column, eg: DECIMAL(10,2) is a decimal backed by an int64,
+// DATETIME a timestamp in microseconds, TIME and the types without a parquet equivalent strings
+func parquetNode(columnType *sql.ColumnType) (parquet.Node, parquetColumn) {
+	typ := strings.TrimSpace(strings.Replace(columnType.DatabaseTypeName(), "UNSIGNED", "", -1))
+	switch {
+	case isDecimalType(typ):
+		precision, scale, ok := columnType.DecimalSize()
+		if !ok {
+			return parquet.String(), parquetColumn{kind: "string"}
+		}
+		if precision <= 18 {
+			return parquet.Decimal(int(scale), int(precision), parquet.Int64Type), parquetColumn{kind: "decimal64", scale: int(scale)}
+		}
+		return parquet.Decimal(int(scale), int(precision), parquet.ByteArrayType), parquetColumn{kind: "decimal", scale: int(scale)}
+	case typ == "DATE":
+		return parquet.Date(), parquetColumn{kind: "date"}
+	case typ == "DATETIME" || typ == "TIMESTAMP":
+		return parquet.Timestamp(parquet.Microsecond), parquetColumn{kind: "timestamp"}
+	case typ == "JSON":
+		return parquet.JSON(), parquetColumn{kind: "[]byte"}
+	}
+
+	switch kind := goType(columnType); kind {
+	case "int64":
+		return parquet.Int(64), parquetColumn{kind: kind}
+	case "uint64":
+		return parquet.Uint(64), parquetColumn{kind: kind}
+	case "float64":
+		return parquet.Leaf(parquet.DoubleType), parquetColumn{kind: kind}
+	case "bool":
+		return parquet.Leaf(parquet.BooleanType), parquetColumn{kind: kind}
+	case "[]byte":
+		return parquet.Leaf(parquet.ByteArrayType), parquetColumn{kind: kind}
+	default:
+		return parquet.String(), parquetColumn{kind: "string"}
+	}
+}
+
+// parquetValue the parquet value of a column, the driver sends the text of the value unless the
+// DSN has parseTime
+func parquetValue(column parquetColumn, col interface{}) (parquet.Value, error) {
+	if col == nil {
+		return parquet.NullValue().Level(0, 0, column.index), nil
+	}
+
+	var value parquet.Value
+	s := rawString(col)
+	switch column.kind {
+	case "int64":
+		n, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			return value, err
+		}
+		value = parquet.Int64Value(n)
+	case "uint64":
+		n, err := strconv.ParseUint(s, 10, 64)
+		if err != nil {
+			return value, err
+		}
+		value = parquet.Int64Value(int64(n))
+	case "float64":
+		f, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return value, err
+		}
+		value = parquet.DoubleValue(f)
+	case "bool":
+		value = parquet.BooleanValue(s == "1" || s == "true")
+	case "decimal64", "decimal":
+		n, err := unscaledDecimal(s, column.scale)
+		if err != nil {
+			return value, err
+		}
+		if column.kind == "decimal64" {
+			value = parquet.Int64Value(n.Int64())
+		} else {
+			value = parquet.ByteArrayValue(twosComplement(n))
+		}
+	case "date":
+		t, err := parseTemporal(col, "2006-01-02")
+		if err != nil {
+			return value, err
+		}
+		value = parquet.Int32Value(int32(t.Unix() / 86400))
+	case "timestamp":
+		t, err := parseTemporal(col, "2006-01-02 15:04:05.999999")
+		if err != nil {
+			return value, err
+		}
+		value = parquet.Int64Value(t.UnixMicro())
+	default:
+		value = parquet.ByteArrayValue([]byte(s))
+	}
+	return value.Level(0, column.maxLevel, column.index), nil
+}
+
+// parseTemporal the UTC time of a DATE or DATETIME value, zero dates have no parquet equivalent
+func parseTemporal(col interface{}, layout string) (time.Time, error) {
+	if t, ok := col.(time.Time); ok {
+		return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), time.UTC), nil
+	}
+	return time.ParseInLocation(layout, rawString(col), time.UTC)
+}
+
+// unscaledDecimal the integer of a decimal text at scale, eg: 12.5 at scale 2 is 1250
+func unscaledDecimal(s string, scale int) (*big.Int, error) {
+	intPart, fracPart, _ := strings.Cut(s, ".")
+	if len(fracPart) > scale {
+		return nil, fmt.Errorf("invalid decimal %q for scale %d", s, scale)
+	}
+	n, ok := new(big.Int).SetString(intPart+fracPart+strings.Repeat("0", scale-len(fracPart)), 10)
+	if !ok {
+		return nil, fmt.Errorf("invalid decimal %q", s)
+	}
+	return n, nil
+}
+
+// twosComplement the big-endian two's complement bytes parquet stores byte array decimals as
+func twosComplement(n *big.Int) []byte {
+	size := n.BitLen()/8 + 1
+	if n.Sign() >= 0 {
+		return n.FillBytes(make([]byte, size))
+	}
+	complement := new(big.Int).Lsh(big.NewInt(1), uint(size*8))
+	return complement.Add(complement, n).FillBytes(make([]byte, size))
+}
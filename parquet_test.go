@@ -0,0 +1,99 @@
+package mysqldump
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/parquet-go/parquet-go"
+)
+
+func TestWriteParquet(t *testing.T) {
+	conn, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = conn.Close()
+	}()
+
+	rows := sqlmock.NewRowsWithColumnDefinition(
+		sqlmock.NewColumn("id").OfType("BIGINT UNSIGNED", []byte{}).Nullable(false),
+		sqlmock.NewColumn("price").OfType("DECIMAL", []byte{}).WithPrecisionAndScale(10, 2).Nullable(false),
+		sqlmock.NewColumn("total").OfType("DECIMAL", []byte{}).WithPrecisionAndScale(30, 10).Nullable(true),
+		sqlmock.NewColumn("created").OfType("DATETIME", []byte{}).Nullable(false),
+		sqlmock.NewColumn("day").OfType("DATE", []byte{}).Nullable(true),
+		sqlmock.NewColumn("name").OfType("VARCHAR", []byte{}).Nullable(true),
+		sqlmock.NewColumn("data").OfType("VARBINARY", []byte{}).Nullable(true),
+	)
+	rows.AddRow([]byte("18446744073709551615"), []byte("-12.50"), []byte("-0.0000000001"),
+		[]byte("2024-02-29 23:59:59.123456"), []byte("1969-12-31"), []byte("it's"), []byte{0x00, 0xff})
+	rows.AddRow([]byte("1"), []byte("0.00"), nil, []byte("1970-01-01 00:00:00"), nil, nil, nil)
+	mock.ExpectQuery("SELECT * FROM `order`").WillReturnRows(rows)
+
+	var buf bytes.Buffer
+	err = writeParquet(conn, "order", &buf, &dumpOption{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+
+	reader := parquet.NewReader(bytes.NewReader(buf.Bytes()))
+	defer func() {
+		_ = reader.Close()
+	}()
+	got := make([]parquet.Row, 3)
+	n, _ := reader.ReadRows(got)
+	if n != 2 {
+		t.Fatalf("%d rows read, want 2", n)
+	}
+
+	created := time.Date(2024, 2, 29, 23, 59, 59, 123456000, time.UTC)
+	tests := []struct {
+		column string
+		want   []parquet.Value
+	}{
+		{"id", []parquet.Value{parquet.Int64Value(-1), parquet.Int64Value(1)}},
+		{"price", []parquet.Value{parquet.Int64Value(-1250), parquet.Int64Value(0)}},
+		{"total", []parquet.Value{parquet.ByteArrayValue([]byte{0xff}), parquet.NullValue()}},
+		{"created", []parquet.Value{parquet.Int64Value(created.UnixMicro()), parquet.Int64Value(0)}},
+		{"day", []parquet.Value{parquet.Int32Value(-1), parquet.NullValue()}},
+		{"name", []parquet.Value{parquet.ByteArrayValue([]byte("it's")), parquet.NullValue()}},
+		{"data", []parquet.Value{parquet.ByteArrayValue([]byte{0x00, 0xff}), parquet.NullValue()}},
+	}
+	for _, test := range tests {
+		leaf, ok := reader.Schema().Lookup(test.column)
+		if !ok {
+			t.Errorf("schema misses column %s", test.column)
+			continue
+		}
+		for i, want := range test.want {
+			value := got[i][leaf.ColumnIndex]
+			if !parquet.Equal(value, want) {
+				t.Errorf("row %d column %s = %v, want %v", i, test.column, value, want)
+			}
+		}
+	}
+}
+
+func TestFileName(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"order_items", "order_items"},
+		{"../etc", "@002e@002e@002fetc"},
+		{"a/b", "a@002fb"},
+		{`a\b`, "a@005cb"},
+		{"..", "@002e@002e"},
+		{"café", "caf@00e9"},
+	}
+	for _, test := range tests {
+		if got := fileName(test.name); got != test.want {
+			t.Errorf("fileName(%q) = %s, want %s", test.name, got, test.want)
+		}
+	}
+}
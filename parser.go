@@ -0,0 +1,129 @@
+package mysqldump
+
+import (
+	"io"
+	"strings"
+
+	"mysqldump/sqlutil"
+)
+
+// DumpEvent a statement of a dump file read by a Parser: UseDatabase, CreateTable,
+// InsertRows or Statement
+type DumpEvent interface {
+	dumpEvent()
+}
+
+// UseDatabase a USE statement
+type UseDatabase struct {
+	Name string
+}
+
+// CreateTable a CREATE TABLE statement
+type CreateTable struct {
+	Name string
+	DDL  string
+}
+
+// InsertRows an INSERT or REPLACE ... VALUES statement, INSERT ... SELECT is a Statement. The values are nil, bool,
+// json.Number, string, []byte or sqlutil.Expression, see sqlutil.ParseInsertValues.
+type InsertRows struct {
+	Table string
+	// Columns the column list of the statement, nil when it has none
+	Columns []string
+	Rows    [][]interface{}
+}
+
+// Statement any other statement, eg: DROP TABLE or SET
+type Statement struct {
+	SQL string
+}
+
+func (UseDatabase) dumpEvent() {}
+func (CreateTable) dumpEvent() {}
+func (InsertRows) dumpEvent()  {}
+func (Statement) dumpEvent()   {}
+
+// Parser read the statements of a dump file as events, eg: to analyze a dump, convert it
+// or restore some of its tables only:
+//
+//	parser := NewParser(file)
+//	for parser.Next() {
+//		switch event := parser.Event().(type) {
+//		case InsertRows:
+//			...
+//		}
+//	}
+//	err = parser.Err()
+type Parser struct {
+	scanner *sqlutil.Scanner
+	event   DumpEvent
+	err     error
+}
+
+// NewParser a Parser of the dump of reader, written by Dump or another tool
+func NewParser(reader io.Reader) *Parser {
+	return &Parser{scanner: sqlutil.NewScanner(reader)}
+}
+
+// Next advance to the next statement, false at the end of the dump or on error
+func (p *Parser) Next() bool {
+	if p.err != nil {
+		return false
+	}
+	for p.scanner.Scan() {
+		stmt := strings.TrimSpace(sqlutil.TrimLeadingComments(p.scanner.Statement()))
+		if sqlutil.IsBlank(stmt) {
+			continue
+		}
+		stmt = strings.TrimSuffix(stmt, ";")
+
+		p.event, p.err = parseEvent(stmt)
+		if p.err != nil {
+			p.err = newStatementError(p.scanner.Line(), p.scanner.Offset(), stmt, p.err)
+			return false
+		}
+		return true
+	}
+	p.err = p.scanner.Err()
+	return false
+}
+
+// Event the current statement
+func (p *Parser) Event() DumpEvent {
+	return p.event
+}
+
+// Line the line of the current statement in the dump
+func (p *Parser) Line() int {
+	return p.scanner.Line()
+}
+
+// Err the error that stopped the parser, nil at the end of the dump
+func (p *Parser) Err() error {
+	return p.err
+}
+
+func parseEvent(stmt string) (DumpEvent, error) {
+	switch sqlutil.StatementVerb(stmt) {
+	case "USE":
+		name := strings.TrimSpace(stmt[len("USE"):])
+		if strings.HasPrefix(name, "`") && strings.HasSuffix(name, "`") && len(name) > 1 {
+			name = strings.Replace(name[1:len(name)-1], "``", "`", -1)
+		}
+		return UseDatabase{Name: name}, nil
+	case "CREATE":
+		if table := sqlutil.StatementTable(stmt); table != "" {
+			return CreateTable{Name: table, DDL: stmt}, nil
+		}
+	case "INSERT", "REPLACE":
+		columns, rows, err := sqlutil.ParseInsertValues(stmt)
+		if err == sqlutil.ErrNoValues {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		return InsertRows{Table: sqlutil.StatementTable(stmt), Columns: columns, Rows: rows}, nil
+	}
+	return Statement{SQL: stmt}, nil
+}
@@ -0,0 +1,27 @@
+package mysqldump
+
+import (
+	"net/url"
+	"strings"
+)
+
+// WithPartitions only dump the rows of the given partitions of table, eg: the partition of
+// last month, WithPartitions("orders", "p202401"). The other tables are dumped whole. The
+// partitions are named in the Records header and, with WithAnnotations, as @partitions.
+// Can be given once per table.
+func WithPartitions(table string, partitions ...string) DumpOption {
+	return func(option *dumpOption) {
+		if option.partitions == nil {
+			option.partitions = make(map[string][]string)
+		}
+		option.partitions[table] = partitions
+	}
+}
+
+// partitionAnnotation append the partitions of a table to its annotation
+func (o *dumpOption) partitionAnnotation(annotation string, partitions []string) string {
+	if annotation == "" || len(partitions) == 0 {
+		return annotation
+	}
+	return strings.TrimSuffix(annotation, "\n") + " @partitions=" + url.QueryEscape(strings.Join(partitions, ",")) + "\n"
+}
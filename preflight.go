@@ -0,0 +1,148 @@
+package mysqldump
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"strings"
+)
+
+// restoreSpaceFactor the space of the loaded tables relative to the dump, for the indexes
+// and the page overhead
+const restoreSpaceFactor = 2
+
+// DefaultRestorePrivileges the privileges WithPreflight checks by default, for a dump with
+// tables, views, triggers and routines
+var DefaultRestorePrivileges = []string{
+	"CREATE", "DROP", "ALTER", "INDEX", "INSERT", "DELETE", "UPDATE", "REFERENCES",
+	"CREATE VIEW", "TRIGGER", "CREATE ROUTINE", "ALTER ROUTINE",
+}
+
+// Preflight the checks of a restore
+type Preflight struct {
+	// DumpSize the size of the dump in bytes, the restore needs about twice as much
+	DumpSize int64
+	// AvailableBytes the free space of the data directory of the target server, eg: from df
+	// on the host or the API of the provider, not checked when 0
+	AvailableBytes int64
+	// Privileges required on the target database, DefaultRestorePrivileges when empty
+	Privileges []string
+}
+
+// PreflightReport the result of the checks of a Preflight
+type PreflightReport struct {
+	Database       string
+	User           string
+	RequiredBytes  int64
+	AvailableBytes int64
+	// MissingPrivileges required privileges granted neither globally nor on Database
+	MissingPrivileges []string
+}
+
+// Err nil when the restore can go on, a readable report of the problems otherwise
+func (r *PreflightReport) Err() error {
+	var problems []string
+	if r.AvailableBytes > 0 && r.RequiredBytes > r.AvailableBytes {
+		problems = append(problems, fmt.Sprintf("about %d bytes needed, %d available", r.RequiredBytes, r.AvailableBytes))
+	}
+	if len(r.MissingPrivileges) > 0 {
+		problems = append(problems, fmt.Sprintf("%s lacks %s on %s", r.User, strings.Join(r.MissingPrivileges, ", "), r.Database))
+	}
+	if len(problems) == 0 {
+		return nil
+	}
+	return fmt.Errorf("restore preflight failed:\n  - %s", strings.Join(problems, "\n  - "))
+}
+
+// WithPreflight check the space and the privileges before the restore starts, the restore
+// fails with the report of PreflightReport.Err when a check fails
+func WithPreflight(preflight Preflight) SourceOption {
+	return func(o *sourceOption) {
+		o.preflight = &preflight
+	}
+}
+
+// RunPreflight the checks of a restore of the database of dns
+func RunPreflight(dns string, preflight Preflight) (*PreflightReport, error) {
+	dbName, err := GetDBNameFromDNS(dns)
+	if err != nil {
+		return nil, err
+	}
+	db, err := sql.Open("mysql", dns)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = db.Close()
+	}()
+	return runPreflight(db, dbName, preflight)
+}
+
+func runPreflight(db dbConn, dbName string, preflight Preflight) (*PreflightReport, error) {
+	report := &PreflightReport{
+		Database:       dbName,
+		RequiredBytes:  preflight.DumpSize * restoreSpaceFactor,
+		AvailableBytes: preflight.AvailableBytes,
+	}
+
+	var user string
+	err := db.QueryRow("SELECT CURRENT_USER()").Scan(&user)
+	if err != nil {
+		return nil, err
+	}
+	report.User = user
+	// the grantee of information_schema, eg: 'app'@'%'
+	grantee := user
+	if idx := strings.LastIndex(user, "@"); idx != -1 {
+		grantee = fmt.Sprintf("'%s'@'%s'", user[:idx], user[idx+1:])
+	}
+
+	granted := make(map[string]bool)
+	collect := func(values []string) {
+		granted[values[0]] = true
+	}
+	err = queryRows(db, "SELECT PRIVILEGE_TYPE FROM information_schema.USER_PRIVILEGES WHERE GRANTEE = ?",
+		[]interface{}{grantee}, collect)
+	if err != nil {
+		return nil, err
+	}
+	// schema grants may use wildcards, eg: `shop\_%`
+	err = queryRows(db, "SELECT PRIVILEGE_TYPE FROM information_schema.SCHEMA_PRIVILEGES WHERE GRANTEE = ? AND ? LIKE TABLE_SCHEMA",
+		[]interface{}{grantee, dbName}, collect)
+	if err != nil {
+		return nil, err
+	}
+
+	privileges := preflight.Privileges
+	if len(privileges) == 0 {
+		privileges = DefaultRestorePrivileges
+	}
+	for _, privilege := range privileges {
+		if !granted[strings.ToUpper(privilege)] {
+			report.MissingPrivileges = append(report.MissingPrivileges, privilege)
+		}
+	}
+	return report, nil
+}
+
+// sourcePreflight run the WithPreflight checks on the restore connection
+func sourcePreflight(ctx context.Context, conn QueryerExecer, dbName string, preflight Preflight) error {
+	db := &ctxConn{ctx: ctx, conn: conn}
+	if dbName == "" {
+		var err error
+		dbName, err = currentDB(db, "")
+		if err != nil {
+			return err
+		}
+	}
+	report, err := runPreflight(db, dbName, preflight)
+	if err != nil {
+		return err
+	}
+	if err = report.Err(); err != nil {
+		return err
+	}
+	log.Printf("[info] [source] preflight passed, about %d bytes needed\n", report.RequiredBytes)
+	return nil
+}
@@ -0,0 +1,87 @@
+package mysqldump
+
+import (
+	"log"
+	"regexp"
+	"strings"
+
+	"mysqldump/sqlutil"
+)
+
+// Profile a target platform, managed platforms forbid some statements
+type Profile string
+
+const (
+	// ProfileMySQL a self-managed server, nothing is changed
+	ProfileMySQL Profile = ""
+	// ProfileAurora Amazon RDS and Aurora, no SUPER privilege
+	ProfileAurora Profile = "aurora"
+	// ProfileCloudSQL Google Cloud SQL, no SUPER privilege
+	ProfileCloudSQL Profile = "cloudsql"
+	// ProfilePlanetScale PlanetScale and Vitess, no SUPER privilege, no LOCK TABLES,
+	// no triggers and routines
+	ProfilePlanetScale Profile = "planetscale"
+	// ProfileTiDB TiDB, explicit values of AUTO_RANDOM columns must be allowed
+	ProfileTiDB Profile = "tidb"
+)
+
+// WithProfile write a dump that can be loaded on the target platform: DEFINER clauses
+// (they require SUPER or SET_USER_ID) are removed, triggers and routines are skipped
+// on PlanetScale
+func WithProfile(profile Profile) DumpOption {
+	return func(option *dumpOption) {
+		option.profile = profile
+	}
+}
+
+// WithSourceProfile skip the statements of the dump that the target platform forbids,
+// eg: SET sql_log_bin or SET GLOBAL, and remove DEFINER clauses
+func WithSourceProfile(profile Profile) SourceOption {
+	return func(o *sourceOption) {
+		o.profile = profile
+	}
+}
+
+func (p Profile) supportsRoutines() bool {
+	return p != ProfilePlanetScale
+}
+
+// header the statements a dump for the platform starts with
+func (p Profile) header() string {
+	if p == ProfileTiDB {
+		// the dumped values of AUTO_RANDOM columns are rejected otherwise
+		return "SET @@allow_auto_random_explicit_insert = true;\n"
+	}
+	return ""
+}
+
+var (
+	definerRe = regexp.MustCompile("(?i)\\s+DEFINER\\s*=\\s*(?:`[^`]*`|'[^']*'|[^\\s@]+)@(?:`[^`]*`|'[^']*'|[^\\s]+)")
+	// statements requiring SUPER, SYSTEM_VARIABLES_ADMIN or similar
+	superRe = regexp.MustCompile(`(?is)^SET\s+(?:@@)?(?:GLOBAL\s+|@@GLOBAL\.|PERSIST\s+)|^SET\s+(?:@@SESSION\.|SESSION\s+|@@)?SQL_LOG_BIN\b|^SET\s+(?:@@GLOBAL\.)?GTID_PURGED\b|^CHANGE\s+(?:MASTER|REPLICATION)\b|^(?:START|STOP)\s+(?:SLAVE|REPLICA)\b|^PURGE\s+|^FLUSH\s+`)
+	lockRe  = regexp.MustCompile(`(?is)^(?:UN)?LOCK\s+TABLES?\b`)
+)
+
+// stripDefiner remove the DEFINER clause of a CREATE VIEW, TRIGGER or routine
+func stripDefiner(ddl string) string {
+	if sqlutil.StatementVerb(sqlutil.ExpandExecutableComments(ddl)) != "CREATE" {
+		return ddl
+	}
+	return definerRe.ReplaceAllString(ddl, "")
+}
+
+// applySourceProfile "" means the statement must be skipped
+func applySourceProfile(profile Profile, dml string) string {
+	if profile == ProfileMySQL {
+		return dml
+	}
+
+	stmt := sqlutil.ExpandExecutableComments(dml)
+	stmt = strings.TrimSpace(stmt)
+	if superRe.MatchString(stmt) || profile == ProfilePlanetScale && lockRe.MatchString(stmt) {
+		log.Printf("[warn] skip statement forbidden on %s: %s\n", profile, stmt)
+		return ""
+	}
+
+	return stripDefiner(dml)
+}
@@ -0,0 +1,50 @@
+package mysqldump
+
+import (
+	"context"
+	"io"
+	"log"
+)
+
+// DumpReader run Dump in the background and return the dump as a stream, eg: to upload it
+// with net/http or write it into an archive without a temporary file. The dump advances as
+// the stream is read, a failed dump is returned by Read. Close before the end aborts the
+// dump. A WithWriter option is overridden.
+func DumpReader(dns string, opts ...DumpOption) (io.ReadCloser, error) {
+	dumper, err := NewDumper(dns, opts...)
+	if err != nil {
+		log.Printf("[error] %v \n", err)
+		return nil, err
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	reader, writer := io.Pipe()
+	r := &dumpReader{
+		PipeReader: reader,
+		cancel:     cancel,
+		done:       make(chan struct{}),
+	}
+	go func() {
+		defer close(r.done)
+		defer func() {
+			_ = dumper.Close()
+		}()
+		err := dumper.Run(ctx, WithWriter(writer))
+		_ = writer.CloseWithError(err)
+	}()
+	return r, nil
+}
+
+type dumpReader struct {
+	*io.PipeReader
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// Close stop the dump if it is still running and wait for it
+func (r *dumpReader) Close() error {
+	_ = r.PipeReader.Close()
+	r.cancel()
+	<-r.done
+	return nil
+}
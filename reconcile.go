@@ -0,0 +1,101 @@
+package mysqldump
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+)
+
+// WithSchemaReconciliation load the rows of the dump into target tables whose columns
+// differ from the ones of the dump, eg: a table that gained columns since the backup. When
+// a table of the dump already exists, its CREATE TABLE is left as is (IF NOT EXISTS) and
+// its INSERT statements get the column list of the dump, the columns the dump lacks get
+// their default value. The target table must have every column of the dump (see
+// WithColumnMap to rename or drop them), and the columns the dump lacks must be nullable,
+// have a default or be generated, else the statements are executed as is and fail.
+func WithSchemaReconciliation() SourceOption {
+	return func(o *sourceOption) {
+		o.reconcile = true
+	}
+}
+
+// targetTable the columns of a table of the target database
+type targetTable struct {
+	columns map[string]bool
+	// required the columns without a value when they are missing from an INSERT
+	required []string
+}
+
+// loadTargetTable the columns of table in the current database of conn, nil if it does not exist
+func loadTargetTable(ctx context.Context, conn QueryerExecer, table string) (*targetTable, error) {
+	rows, err := conn.QueryContext(ctx, "SELECT COLUMN_NAME, IS_NULLABLE, COLUMN_DEFAULT IS NOT NULL, EXTRA "+
+		"FROM information_schema.COLUMNS WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = ?", table)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	var target *targetTable
+	for rows.Next() {
+		var name, nullable, extra string
+		var hasDefault bool
+		err = rows.Scan(&name, &nullable, &hasDefault, &extra)
+		if err != nil {
+			return nil, err
+		}
+		if target == nil {
+			target = &targetTable{columns: make(map[string]bool)}
+		}
+		target.columns[strings.ToLower(name)] = true
+
+		extra = strings.ToLower(extra)
+		optional := nullable == "YES" || hasDefault || strings.Contains(extra, "auto_increment") || strings.Contains(extra, "generated")
+		if !optional {
+			target.required = append(target.required, name)
+		}
+	}
+	return target, rows.Err()
+}
+
+// reconcileColumns whether an INSERT of columns can be loaded into table by naming its
+// columns, the target tables are loaded once
+func (o *sourceOption) reconcileColumns(table string, columns []string) bool {
+	if o.targetTables == nil {
+		o.targetTables = make(map[string]*targetTable)
+	}
+	target, ok := o.targetTables[table]
+	if !ok {
+		var err error
+		target, err = o.targetTableLoader(table)
+		if err != nil {
+			log.Printf("[warn] [reconcile] columns of %s: %v\n", table, err)
+		}
+		o.targetTables[table] = target
+		if err == nil && target != nil {
+			if reason := target.incompatibility(columns); reason != "" {
+				log.Printf("[warn] [reconcile] %s can not be reconciled: %s\n", table, reason)
+			}
+		}
+	}
+	return target != nil && target.incompatibility(columns) == ""
+}
+
+// incompatibility why rows of columns can not be loaded into the table, "" if they can
+func (t *targetTable) incompatibility(columns []string) string {
+	named := make(map[string]bool, len(columns))
+	for _, column := range columns {
+		if !t.columns[strings.ToLower(column)] {
+			return fmt.Sprintf("no column %s", column)
+		}
+		named[strings.ToLower(column)] = true
+	}
+	for _, column := range t.required {
+		if !named[strings.ToLower(column)] {
+			return fmt.Sprintf("column %s has no default", column)
+		}
+	}
+	return ""
+}
@@ -0,0 +1,60 @@
+package mysqldump
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestReconcileColumns(t *testing.T) {
+	targets := map[string]*targetTable{
+		// gained a nullable column since the dump
+		"order": {columns: map[string]bool{"id": true, "a`b": true, "note": true}},
+		// gained a column without default
+		"item": {columns: map[string]bool{"id": true, "sku": true}, required: []string{"id", "sku"}},
+		// renamed by WithTableMap
+		"user_restored": {columns: map[string]bool{"id": true, "full_name": true, "created": true}},
+	}
+	var loaded []string
+	o := &sourceOption{
+		reconcile: true,
+		tableMap:  map[string]string{"user": "user_restored"},
+		targetTableLoader: func(table string) (*targetTable, error) {
+			loaded = append(loaded, table)
+			return targets[table], nil
+		},
+	}
+	WithColumnMap("user", map[string]string{"name": "full_name"})(o)
+
+	// want "" for the statements left as is
+	tests := []struct {
+		dml  string
+		want string
+	}{
+		{"CREATE TABLE `order` (\n  `id` int NOT NULL,\n  `a``b` varchar(10)\n)", ""},
+		{"INSERT INTO `order` VALUES (1,'x'),(2,'y''z')", "INSERT INTO `order` (`id`,`a``b`) VALUES (1,'x'),(2,'y''z')"},
+		{"INSERT INTO `order` VALUES (3,NULL)", "INSERT INTO `order` (`id`,`a``b`) VALUES (3,NULL)"},
+		// sku has no default, the statement is executed as is and fails
+		{"CREATE TABLE `item` (\n  `id` int NOT NULL\n)", ""},
+		{"INSERT INTO `item` VALUES (1),(2)", "INSERT INTO `item` VALUES (1),(2)"},
+		// a table the target lacks is created by the dump
+		{"CREATE TABLE `log` (\n  `id` int NOT NULL\n)", ""},
+		{"INSERT INTO `log` VALUES (1),(2)", "INSERT INTO `log` VALUES (1),(2)"},
+		{"CREATE TABLE `user` (\n  `id` int NOT NULL,\n  `name` varchar(10)\n)",
+			"CREATE TABLE `user_restored` (\n  `id` int NOT NULL,\n  `name` varchar(10)\n)"},
+		{"INSERT INTO `user` VALUES (1,'a'),(2,'b')", "INSERT INTO `user_restored` (`id`,`full_name`) VALUES (1,'a'),(2,'b')"},
+	}
+	for _, test := range tests {
+		want := test.want
+		if want == "" {
+			want = test.dml
+		}
+		if got := o.mapTable(o.mapColumns(test.dml)); got != want {
+			t.Errorf("rewrite(%s) = %s, want %s", test.dml, got, want)
+		}
+	}
+
+	// the target tables are loaded once per database
+	if got := strings.Join(loaded, ","); got != "order,item,log,user_restored" {
+		t.Errorf("target tables loaded %s, want each once", got)
+	}
+}
@@ -0,0 +1,29 @@
+package mysqldump
+
+import (
+	"mysqldump/sqlutil"
+)
+
+// WithResourceGroup run the connections of the dump in the resource group name (MySQL 8.0,
+// RESOURCE_GROUP_USER privilege), eg: a group created by the DBA with a low THREAD_PRIORITY
+// and a few VCPUs, so that backups give way to the application on busy servers:
+//
+//	CREATE RESOURCE GROUP backup TYPE = USER VCPU = 2-3 THREAD_PRIORITY = 19;
+//
+// A server without resource groups records a warning and the dump goes on.
+func WithResourceGroup(name string) DumpOption {
+	return func(option *dumpOption) {
+		option.resourceGroup = name
+	}
+}
+
+// setResourceGroup move the session of db to the WithResourceGroup group
+func setResourceGroup(db dbConn, o *dumpOption) {
+	if o.resourceGroup == "" {
+		return
+	}
+	_, err := db.Exec("SET RESOURCE GROUP " + sqlutil.QuoteIdentifier(o.resourceGroup))
+	if err != nil {
+		o.warnf("resource group %s not set: %v", o.resourceGroup, err)
+	}
+}
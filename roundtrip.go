@@ -0,0 +1,168 @@
+package mysqldump
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log"
+	"time"
+
+	"mysqldump/sqlutil"
+)
+
+// TableComparison the rows of a table before and after a round trip
+type TableComparison struct {
+	Table string
+	// Rows and Checksum of the original table, RestoredRows and RestoredChecksum of its copy
+	Rows             int64
+	RestoredRows     int64
+	Checksum         int64
+	RestoredChecksum int64
+}
+
+// Equal the copy has the same rows as the original
+func (c TableComparison) Equal() bool {
+	return c.Rows == c.RestoredRows && c.Checksum == c.RestoredChecksum
+}
+
+// RoundTripReport the result of RoundTripVerify
+type RoundTripReport struct {
+	Tables []TableComparison
+}
+
+// Differences the tables whose copy differs from the original
+func (r *RoundTripReport) Differences() []TableComparison {
+	var differences []TableComparison
+	for _, table := range r.Tables {
+		if !table.Equal() {
+			differences = append(differences, table)
+		}
+	}
+	return differences
+}
+
+// RoundTripVerify check that the database of dns survives a Dump and Source unchanged: it
+// is dumped and loaded into a scratch database of the same server, then the row count
+// and CHECKSUM TABLE of each table are compared. The scratch database is dropped
+// afterwards, the account needs CREATE and DROP privileges. opts apply to the dump, eg:
+// WithTables, the data and the tables are always dumped. The data must not change meanwhile.
+func RoundTripVerify(dns string, opts ...DumpOption) (*RoundTripReport, error) {
+	ctx := context.Background()
+
+	dbName, err := GetDBNameFromDNS(dns)
+	if err != nil {
+		return nil, err
+	}
+	scratch, err := scratchDBName(dbName)
+	if err != nil {
+		return nil, err
+	}
+
+	db, err := sql.Open("mysql", dns)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = db.Close()
+	}()
+
+	_, err = db.ExecContext(ctx, "CREATE DATABASE "+sqlutil.QuoteIdentifier(scratch))
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_, dropErr := db.ExecContext(ctx, "DROP DATABASE "+sqlutil.QuoteIdentifier(scratch))
+		if dropErr != nil {
+			log.Printf("[error] [roundtrip] drop %s: %v\n", scratch, dropErr)
+		}
+	}()
+
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = conn.Close()
+	}()
+
+	// the dump is streamed into the restore, the USE statements are redirected to scratch
+	reader, writer := io.Pipe()
+	dumpErr := make(chan error, 1)
+	go func() {
+		err := Dump(dns, append(append([]DumpOption(nil), opts...), WithDBs(dbName), WithDumpTable(), WithData(), WithWriter(writer))...)
+		_ = writer.CloseWithError(err)
+		dumpErr <- err
+	}()
+
+	err = NewSourcerWithConn(conn, func(o *sourceOption) {
+		o.database = scratch
+	}).Run(ctx, reader)
+	_ = reader.CloseWithError(err)
+	if dErr := <-dumpErr; dErr != nil {
+		return nil, dErr
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var tables []string
+	err = queryRows(db, "SELECT TABLE_NAME FROM information_schema.TABLES WHERE TABLE_SCHEMA = ? AND TABLE_TYPE = 'BASE TABLE' ORDER BY TABLE_NAME",
+		[]interface{}{scratch}, func(values []string) {
+			tables = append(tables, values[0])
+		})
+	if err != nil {
+		return nil, err
+	}
+
+	report := &RoundTripReport{}
+	for _, table := range tables {
+		comparison := TableComparison{Table: table}
+		comparison.Rows, comparison.Checksum, err = tableChecksum(db, dbName, table)
+		if err != nil {
+			return nil, err
+		}
+		comparison.RestoredRows, comparison.RestoredChecksum, err = tableChecksum(db, scratch, table)
+		if err != nil {
+			return nil, err
+		}
+		report.Tables = append(report.Tables, comparison)
+	}
+	return report, nil
+}
+
+// tableChecksum the row count and CHECKSUM TABLE of a table
+func tableChecksum(db dbConn, dbName, table string) (int64, int64, error) {
+	name := sqlutil.QuoteIdentifier(dbName) + "." + sqlutil.QuoteIdentifier(table)
+
+	var rows int64
+	err := db.QueryRow("SELECT COUNT(*) FROM " + name).Scan(&rows) // ignore_security_alert_wait_for_fix SQL
+	if err != nil {
+		return 0, 0, err
+	}
+
+	var checksumTable string
+	var checksum sql.NullInt64
+	err = db.QueryRow("CHECKSUM TABLE "+name).Scan(&checksumTable, &checksum) // ignore_security_alert_wait_for_fix SQL
+	if err != nil {
+		return 0, 0, err
+	}
+	return rows, checksum.Int64, nil
+}
+
+// scratchDBName the name of the scratch database of a RoundTripVerify of dbName, random so
+// that concurrent verifications of one database do not share it, at most 64 characters
+func scratchDBName(dbName string) (string, error) {
+	suffix := make([]byte, 6)
+	_, err := rand.Read(suffix)
+	if err != nil {
+		return "", err
+	}
+	name := fmt.Sprintf("_roundtrip_%d_%s", time.Now().Unix(), hex.EncodeToString(suffix))
+	if runes := []rune(dbName); len(runes) > 64-len(name) {
+		dbName = string(runes[:64-len(name)])
+	}
+	return dbName + name, nil
+}
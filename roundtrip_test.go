@@ -0,0 +1,26 @@
+package mysqldump
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestScratchDBName(t *testing.T) {
+	for _, dbName := range []string{"shop", strings.Repeat("s", 64), strings.Repeat("é", 64)} {
+		first, err := scratchDBName(dbName)
+		if err != nil {
+			t.Fatal(err)
+		}
+		second, err := scratchDBName(dbName)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if first == second {
+			t.Errorf("two verifications of %s share the scratch database %s", dbName, first)
+		}
+		if utf8.RuneCountInString(first) > 64 || !strings.HasPrefix(first, dbName[:1]) {
+			t.Errorf("scratch database %s of %s", first, dbName)
+		}
+	}
+}
@@ -0,0 +1,124 @@
+package mysqldump
+
+import (
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"mysqldump/sqlutil"
+)
+
+// RowSizePolicy what WithMaxRowBytes does with a row above the limit
+type RowSizePolicy int
+
+const (
+	// RowSizeError fail the dump
+	RowSizeError RowSizePolicy = iota
+	// RowSizeSkip leave the row out with a warning
+	RowSizeSkip
+	// RowSizeSplit insert the row with its large binary values empty, then append them
+	// piece by piece with UPDATE statements matching the primary key. Tables without a
+	// primary key, and rows still too large without their binary values, fail the dump.
+	RowSizeSplit
+)
+
+// WithMaxRowBytes apply policy to the rows whose INSERT statement would exceed n bytes
+// (estimated), eg: the max_allowed_packet of the target server, so that the restore does
+// not fail on them
+func WithMaxRowBytes(n int, policy RowSizePolicy) DumpOption {
+	return func(option *dumpOption) {
+		option.maxRowBytes = n
+		option.rowSizePolicy = policy
+	}
+}
+
+// rowAppendOverhead the bytes of an UPDATE statement besides the hex of its piece
+const rowAppendOverhead = 256
+
+// isBinaryType the types written as hex literals
+func isBinaryType(typ string) bool {
+	switch typ {
+	case "BINARY", "VARBINARY", "TINYBLOB", "BLOB", "MEDIUMBLOB", "LONGBLOB":
+		return true
+	}
+	return false
+}
+
+// estimateValueSize the bytes of the literal of a value, text may grow by its escapes
+func estimateValueSize(columnType *sql.ColumnType, col interface{}) int {
+	if col == nil {
+		return len("NULL")
+	}
+	if isBinaryType(normalizeTypeName(columnType.DatabaseTypeName())) {
+		return 2 + 2*len(rawString(col))
+	}
+	return 2 + len(rawString(col))
+}
+
+// limitRow apply WithMaxRowBytes to a row of table: the row to insert, the statements
+// to write after its INSERT, and whether it is skipped
+func (o *dumpOption) limitRow(table string, columnTypes []*sql.ColumnType, row []interface{}, primaryKey []string) ([]interface{}, []string, bool, error) {
+	size := len("INSERT INTO `` VALUES ();\n") + len(table)
+	for i, col := range row {
+		size += estimateValueSize(columnTypes[i], col) + 1
+	}
+	if size <= o.maxRowBytes {
+		return row, nil, false, nil
+	}
+
+	switch o.rowSizePolicy {
+	case RowSizeSkip:
+		o.warnf("skip a row of %s: about %d bytes, more than %d", table, size, o.maxRowBytes)
+		return nil, nil, true, nil
+	case RowSizeSplit:
+		return o.splitRow(table, columnTypes, row, primaryKey, size)
+	default:
+		return nil, nil, false, fmt.Errorf("a row of %s is about %d bytes, more than %d, see WithMaxRowBytes", table, size, o.maxRowBytes)
+	}
+}
+
+func (o *dumpOption) splitRow(table string, columnTypes []*sql.ColumnType, row []interface{}, primaryKey []string, size int) ([]interface{}, []string, bool, error) {
+	if len(primaryKey) == 0 || o.withoutPrimaryID {
+		return nil, nil, false, fmt.Errorf("a row of %s is about %d bytes, more than %d, and can not be split without its primary key", table, size, o.maxRowBytes)
+	}
+
+	var conditions []string
+	for _, column := range primaryKey {
+		for i, columnType := range columnTypes {
+			if columnType.Name() == column {
+				conditions = append(conditions, sqlutil.QuoteIdentifier(column)+" = "+sqlutil.QuoteString(rawString(row[i])))
+			}
+		}
+	}
+	where := strings.Join(conditions, " AND ")
+
+	piece := (o.maxRowBytes - rowAppendOverhead - len(table) - len(where)) / 2
+	if piece <= 0 {
+		return nil, nil, false, fmt.Errorf("WithMaxRowBytes %d is too small to split the rows of %s", o.maxRowBytes, table)
+	}
+
+	split := append([]interface{}(nil), row...)
+	var appends []string
+	for i, col := range row {
+		bs, ok := col.([]byte)
+		if !ok || len(bs) <= piece || !isBinaryType(normalizeTypeName(columnTypes[i].DatabaseTypeName())) {
+			continue
+		}
+		size -= 2 * len(bs)
+		split[i] = []byte{}
+		column := sqlutil.QuoteIdentifier(columnTypes[i].Name())
+		for start := 0; start < len(bs); start += piece {
+			end := start + piece
+			if end > len(bs) {
+				end = len(bs)
+			}
+			appends = append(appends, fmt.Sprintf("UPDATE %s SET %s = CONCAT(%s, 0x%s) WHERE %s;\n",
+				sqlutil.QuoteIdentifier(table), column, column, strings.ToUpper(hex.EncodeToString(bs[start:end])), where))
+		}
+	}
+	if size > o.maxRowBytes {
+		return nil, nil, false, fmt.Errorf("a row of %s is about %d bytes without its large binary values, more than %d", table, size, o.maxRowBytes)
+	}
+	return split, appends, false, nil
+}
@@ -0,0 +1,207 @@
+package mysqldump
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"strings"
+)
+
+// tableSchema the metadata of a table for the generated docs and diagrams
+type tableSchema struct {
+	name        string
+	comment     string
+	columns     []columnSchema
+	indexes     []indexSchema
+	foreignKeys []foreignKey
+}
+
+type columnSchema struct {
+	name         string
+	columnType   string
+	nullable     bool
+	defaultValue string
+	hasDefault   bool
+	key          string
+	extra        string
+	comment      string
+}
+
+type indexSchema struct {
+	name    string
+	columns []string
+	unique  bool
+}
+
+// schemaLoader the metadata of the tables, the foreign keys are loaded once per database
+type schemaLoader struct {
+	db          dbConn
+	foreignKeys map[string][]foreignKey
+}
+
+func newSchemaLoader(db dbConn) *schemaLoader {
+	return &schemaLoader{db: db, foreignKeys: make(map[string][]foreignKey)}
+}
+
+func (l *schemaLoader) load(dbName, table string) (*tableSchema, error) {
+	schema := &tableSchema{name: table}
+	err := queryRows(l.db, "SELECT TABLE_COMMENT FROM information_schema.TABLES WHERE TABLE_SCHEMA = ? AND TABLE_NAME = ?",
+		[]interface{}{dbName, table}, func(values []string) {
+			schema.comment = values[0]
+		})
+	if err != nil {
+		return nil, err
+	}
+
+	err = queryRows(l.db, "SELECT COLUMN_NAME, COLUMN_TYPE, IS_NULLABLE, COLUMN_DEFAULT IS NOT NULL, COALESCE(COLUMN_DEFAULT, ''), "+
+		"COLUMN_KEY, EXTRA, COLUMN_COMMENT FROM information_schema.COLUMNS WHERE TABLE_SCHEMA = ? AND TABLE_NAME = ? ORDER BY ORDINAL_POSITION",
+		[]interface{}{dbName, table}, func(values []string) {
+			schema.columns = append(schema.columns, columnSchema{
+				name:         values[0],
+				columnType:   values[1],
+				nullable:     values[2] == "YES",
+				hasDefault:   values[3] == "1",
+				defaultValue: values[4],
+				key:          values[5],
+				extra:        values[6],
+				comment:      values[7],
+			})
+		})
+	if err != nil {
+		return nil, err
+	}
+
+	err = queryRows(l.db, "SELECT INDEX_NAME, COLUMN_NAME, NON_UNIQUE FROM information_schema.STATISTICS "+
+		"WHERE TABLE_SCHEMA = ? AND TABLE_NAME = ? ORDER BY INDEX_NAME = 'PRIMARY' DESC, INDEX_NAME, SEQ_IN_INDEX",
+		[]interface{}{dbName, table}, func(values []string) {
+			if n := len(schema.indexes); n == 0 || schema.indexes[n-1].name != values[0] {
+				schema.indexes = append(schema.indexes, indexSchema{name: values[0], unique: values[2] == "0"})
+			}
+			index := &schema.indexes[len(schema.indexes)-1]
+			// functional key parts have no column
+			index.columns = append(index.columns, values[1])
+		})
+	if err != nil {
+		return nil, err
+	}
+
+	fks, ok := l.foreignKeys[dbName]
+	if !ok {
+		fks, err = loadForeignKeys(l.db, []string{dbName})
+		if err != nil {
+			return nil, err
+		}
+		l.foreignKeys[dbName] = fks
+	}
+	for _, fk := range fks {
+		if fk.child == dbName+"."+table {
+			schema.foreignKeys = append(schema.foreignKeys, fk)
+		}
+	}
+	return schema, nil
+}
+
+// GenerateSchemaDocs write Markdown documentation of the selected tables: their columns,
+// indexes and foreign keys, eg: to publish the docs of a schema with each backup.
+// WithDBs, WithAllDatabases, WithTables and WithAllTables select the tables.
+func GenerateSchemaDocs(dns string, writer io.Writer, opts ...DumpOption) error {
+	var o dumpOption
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	buf := NewSafeWriterWithSize(writer, BufferSize)
+	defer func() {
+		_ = buf.Flush()
+	}()
+
+	var loader *schemaLoader
+	var lastDB string
+	err := forEachTable(dns, &o, func(db dbConn, dbName, table string) error {
+		if loader == nil {
+			loader = newSchemaLoader(db)
+		}
+		schema, err := loader.load(dbName, table)
+		if err != nil {
+			return err
+		}
+
+		if dbName != lastDB {
+			lastDB = dbName
+			_, _ = buf.WriteString(fmt.Sprintf("# Database `%s`\n\n", dbName))
+		}
+		_, _ = buf.WriteString(markdownTable(schema))
+		return nil
+	})
+	if err != nil {
+		log.Printf("[error] %v \n", err)
+		return err
+	}
+	return buf.Flush()
+}
+
+func markdownTable(schema *tableSchema) string {
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("## `%s`\n\n", schema.name))
+	if schema.comment != "" {
+		b.WriteString(markdownCell(schema.comment) + "\n\n")
+	}
+
+	b.WriteString("| Column | Type | Nullable | Default | Key | Extra | Comment |\n")
+	b.WriteString("| --- | --- | --- | --- | --- | --- | --- |\n")
+	for _, column := range schema.columns {
+		nullable := "NO"
+		if column.nullable {
+			nullable = "YES"
+		}
+		defaultValue := ""
+		if column.hasDefault {
+			defaultValue = "`" + markdownCell(column.defaultValue) + "`"
+		} else if column.nullable {
+			defaultValue = "NULL"
+		}
+		b.WriteString(fmt.Sprintf("| `%s` | `%s` | %s | %s | %s | %s | %s |\n", markdownCell(column.name), markdownCell(column.columnType),
+			nullable, defaultValue, column.key, markdownCell(column.extra), markdownCell(column.comment)))
+	}
+	b.WriteString("\n")
+
+	if len(schema.indexes) > 0 {
+		b.WriteString("### Indexes\n\n")
+		b.WriteString("| Name | Columns | Unique |\n")
+		b.WriteString("| --- | --- | --- |\n")
+		for _, index := range schema.indexes {
+			unique := "NO"
+			if index.unique {
+				unique = "YES"
+			}
+			b.WriteString(fmt.Sprintf("| `%s` | %s | %s |\n", markdownCell(index.name), markdownColumns(index.columns), unique))
+		}
+		b.WriteString("\n")
+	}
+
+	if len(schema.foreignKeys) > 0 {
+		b.WriteString("### Foreign keys\n\n")
+		b.WriteString("| Columns | References |\n")
+		b.WriteString("| --- | --- |\n")
+		for _, fk := range schema.foreignKeys {
+			b.WriteString(fmt.Sprintf("| %s | `%s` (%s) |\n", markdownColumns(fk.childColumns), markdownCell(fk.parent), markdownColumns(fk.parentColumns)))
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// markdownCell text that fits in a table cell
+func markdownCell(s string) string {
+	s = strings.Replace(s, "|", "\\|", -1)
+	s = strings.Replace(s, "\r\n", "<br>", -1)
+	return strings.Replace(s, "\n", "<br>", -1)
+}
+
+func markdownColumns(columns []string) string {
+	quoted := make([]string, len(columns))
+	for i, column := range columns {
+		quoted[i] = "`" + markdownCell(column) + "`"
+	}
+	return strings.Join(quoted, ", ")
+}
@@ -0,0 +1,143 @@
+package mysqldump
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io"
+	"log"
+	"regexp"
+	"strings"
+	"time"
+
+	"mysqldump/sqlutil"
+)
+
+// maxIdentifierLength the longest table name of MySQL
+const maxIdentifierLength = 64
+
+// checkNameRe the name of a CHECK constraint, unique in the database
+var checkNameRe = regexp.MustCompile("(?i)CONSTRAINT\\s+`(?:[^`]|``)*`\\s+CHECK")
+
+// RestoreShadow validate a dump against the live database of dns without a second server:
+// the tables of the dump are loaded into shadow tables of the same database, named
+// <table>_shadow_<unix time>, their row count and CHECKSUM TABLE are compared with the live
+// tables, then the shadows are dropped. Only the tables and their rows are loaded, the
+// foreign keys are left out and the other statements (views, triggers, routines, USE, ...)
+// are skipped. The live data must not change meanwhile. opts apply to the restore.
+func RestoreShadow(dns string, dump io.Reader, opts ...SourceOption) (*RoundTripReport, error) {
+	ctx := context.Background()
+
+	dbName, err := GetDBNameFromDNS(dns)
+	if err != nil {
+		return nil, err
+	}
+	db, err := sql.Open("mysql", dns)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = db.Close()
+	}()
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = conn.Close()
+	}()
+
+	shadow := &shadowRestore{
+		suffix: fmt.Sprintf("_shadow_%d", time.Now().Unix()),
+		names:  make(map[string]string),
+	}
+	defer func() {
+		for _, table := range shadow.tables {
+			_, dropErr := db.ExecContext(ctx, "DROP TABLE IF EXISTS "+qualifiedName(dbName+"."+shadow.names[table]))
+			if dropErr != nil {
+				log.Printf("[error] [shadow] drop %s: %v\n", shadow.names[table], dropErr)
+			}
+		}
+	}()
+
+	err = NewSourcerWithConn(conn, append(append([]SourceOption(nil), opts...), func(o *sourceOption) {
+		o.shadow = shadow
+	})...).Run(ctx, dump)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &RoundTripReport{}
+	for _, table := range shadow.tables {
+		comparison := TableComparison{Table: table}
+		comparison.Rows, comparison.Checksum, err = tableChecksum(db, dbName, table)
+		if err != nil {
+			return nil, fmt.Errorf("table %s of the dump: %w", table, err)
+		}
+		comparison.RestoredRows, comparison.RestoredChecksum, err = tableChecksum(db, dbName, shadow.names[table])
+		if err != nil {
+			return nil, err
+		}
+		report.Tables = append(report.Tables, comparison)
+	}
+	return report, nil
+}
+
+// shadowRestore the tables of a RestoreShadow, in the order of the dump
+type shadowRestore struct {
+	suffix string
+	tables []string
+	names  map[string]string
+}
+
+// rewrite a statement of the dump for the shadow tables, "" skips it
+func (s *shadowRestore) rewrite(dml string) string {
+	verb := sqlutil.StatementVerb(dml)
+	if verb == "SET" {
+		upper := strings.ToUpper(dml)
+		if strings.Contains(upper, "GLOBAL") || strings.Contains(upper, "PERSIST") {
+			return ""
+		}
+		return dml
+	}
+
+	table := sqlutil.StatementTable(dml)
+	if table == "" {
+		return ""
+	}
+	name, ok := s.names[table]
+	if !ok {
+		name = table
+		if len(name)+len(s.suffix) > maxIdentifierLength {
+			name = name[:maxIdentifierLength-len(s.suffix)]
+		}
+		name += s.suffix
+		s.names[table] = name
+		s.tables = append(s.tables, table)
+	}
+
+	if verb == "CREATE" {
+		dml = shadowTableDDL(dml)
+	}
+	return sqlutil.RenameStatementTable(dml, name)
+}
+
+// shadowTableDDL a CREATE TABLE without foreign keys and constraint names, the names are
+// unique in the database and the live table has them
+func shadowTableDDL(ddl string) string {
+	lines := strings.Split(ddl, "\n")
+	kept := lines[:0]
+	for _, line := range lines {
+		if strings.Contains(strings.ToUpper(line), "FOREIGN KEY") {
+			continue
+		}
+		kept = append(kept, checkNameRe.ReplaceAllString(line, "CHECK"))
+	}
+	// the definition before the closing parenthesis has no comma
+	for i := 0; i+1 < len(kept); i++ {
+		if strings.HasPrefix(strings.TrimSpace(kept[i+1]), ")") {
+			kept[i] = strings.TrimSuffix(kept[i], ",")
+		}
+	}
+	return strings.Join(kept, "\n")
+}
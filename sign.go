@@ -0,0 +1,56 @@
+package mysqldump
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"hash"
+	"io"
+	"strings"
+)
+
+// signaturePrefix the signed message is signaturePrefix then the SHA-256 of the artifact
+const signaturePrefix = "mysqldump-signature-v1\n"
+
+// WithSignature write a detached ed25519 signature of the dump (the bytes written, encrypted
+// or not) into signature once the dump succeeded, check it with Verify before a restore
+func WithSignature(privateKey ed25519.PrivateKey, signature io.Writer) DumpOption {
+	return func(option *dumpOption) {
+		option.signingKey = privateKey
+		option.signature = signature
+	}
+}
+
+// Sign the detached signature of an artifact, eg: a dump or a manifest, as a base64 line
+func Sign(r io.Reader, privateKey ed25519.PrivateKey) ([]byte, error) {
+	digest := sha256.New()
+	_, err := io.Copy(digest, r)
+	if err != nil {
+		return nil, err
+	}
+	return signDigest(digest, privateKey), nil
+}
+
+func signDigest(digest hash.Hash, privateKey ed25519.PrivateKey) []byte {
+	sig := ed25519.Sign(privateKey, digest.Sum([]byte(signaturePrefix)))
+	return []byte(base64.StdEncoding.EncodeToString(sig) + "\n")
+}
+
+// Verify check the detached signature of an artifact made by WithSignature or Sign, a
+// tampered artifact returns an error
+func Verify(r io.Reader, signature []byte, publicKey ed25519.PublicKey) error {
+	sig, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(signature)))
+	if err != nil {
+		return errors.New("malformed signature")
+	}
+	digest := sha256.New()
+	_, err = io.Copy(digest, r)
+	if err != nil {
+		return err
+	}
+	if !ed25519.Verify(publicKey, digest.Sum([]byte(signaturePrefix)), sig) {
+		return errors.New("signature mismatch, the artifact was modified or signed by another key")
+	}
+	return nil
+}
@@ -0,0 +1,68 @@
+package mysqldump
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"database/sql/driver"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestSignVerify(t *testing.T) {
+	publicKey, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	artifact := []byte("USE `shop`;\nINSERT INTO `order` VALUES (1);\n")
+	signature, err := Sign(bytes.NewReader(artifact), privateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = Verify(bytes.NewReader(artifact), signature, publicKey)
+	if err != nil {
+		t.Fatalf("verify: %v", err)
+	}
+
+	for i := range artifact {
+		modified := append([]byte{}, artifact...)
+		modified[i] ^= 0x01
+		if Verify(bytes.NewReader(modified), signature, publicKey) == nil {
+			t.Fatalf("the signature still verifies with byte %d changed", i)
+		}
+	}
+
+	otherKey, _, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if Verify(bytes.NewReader(artifact), signature, otherKey) == nil {
+		t.Error("the signature verifies with another key")
+	}
+}
+
+func TestDumpSignature(t *testing.T) {
+	publicKey, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	table := mockTable{
+		name:    "order",
+		ddl:     "CREATE TABLE `order` (`id` int NOT NULL)",
+		columns: []*sqlmock.Column{sqlmock.NewColumn("id").OfType("INT", int64(0)).Nullable(false)},
+		rows:    [][]driver.Value{{int64(1)}},
+	}
+	var signature bytes.Buffer
+	dump := mockDump(t, "shop", []mockTable{table}, WithSignature(privateKey, &signature))
+
+	err = Verify(bytes.NewReader([]byte(dump)), signature.Bytes(), publicKey)
+	if err != nil {
+		t.Fatalf("verify: %v", err)
+	}
+	modified := []byte(dump)
+	modified[len(modified)/2] ^= 0x01
+	if Verify(bytes.NewReader(modified), signature.Bytes(), publicKey) == nil {
+		t.Error("the signature still verifies with one byte changed")
+	}
+}
@@ -0,0 +1,185 @@
+package mysqldump
+
+import (
+	"database/sql"
+	"encoding/json"
+	"log"
+)
+
+// Row a dumped row, Values holds nil, bool, json.Number (integers, floats and decimals),
+// string or []byte (binary columns)
+type Row struct {
+	DB      string
+	Table   string
+	Columns []string
+	Values  []interface{}
+	// PrimaryKey the primary key columns of the table, empty if it has none
+	PrimaryKey []string
+}
+
+// Map the row as a column -> value map
+func (r Row) Map() map[string]interface{} {
+	m := make(map[string]interface{}, len(r.Columns))
+	for i, column := range r.Columns {
+		m[column] = r.Values[i]
+	}
+	return m
+}
+
+// RowSink receive the rows of DumpToSink
+type RowSink interface {
+	WriteRow(row Row) error
+	// Close called once after the last row, even when the dump failed
+	Close() error
+}
+
+// DumpToSink send each selected row to sink instead of writing SQL.
+// WithDBs, WithAllDatabases, WithTables, WithAllTables and WithWhere select the rows,
+// WithRowTransformer applies.
+func DumpToSink(dns string, sink RowSink, opts ...DumpOption) (err error) {
+	var o dumpOption
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	defer func() {
+		closeErr := sink.Close()
+		if err == nil {
+			err = closeErr
+		}
+	}()
+
+	err = forEachTable(dns, &o, func(db dbConn, dbName, table string) error {
+		primaryKey, err := getPrimaryKeyColumns(db, dbName, table)
+		if err != nil {
+			return err
+		}
+
+		return scanRows(db, table, &o, func(columnTypes []*sql.ColumnType, row []interface{}) error {
+			columns := make([]string, len(columnTypes))
+			for i, columnType := range columnTypes {
+				columns[i] = columnType.Name()
+			}
+
+			rows, err := o.transformRow(table, columns, row)
+			if err != nil {
+				return err
+			}
+
+			for _, row := range rows {
+				r := Row{
+					DB:         dbName,
+					Table:      table,
+					Columns:    columns,
+					Values:     make([]interface{}, len(columnTypes)),
+					PrimaryKey: primaryKey,
+				}
+				for i, col := range row {
+					r.Values[i] = jsonValue(columnTypes[i], col)
+				}
+				err = sink.WriteRow(r)
+				if err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		log.Printf("[error] %v \n", err)
+	}
+	return err
+}
+
+func getPrimaryKeyColumns(db dbConn, dbName, table string) ([]string, error) {
+	rows, err := db.Query("SELECT COLUMN_NAME FROM information_schema.KEY_COLUMN_USAGE "+
+		"WHERE TABLE_SCHEMA = ? AND TABLE_NAME = ? AND CONSTRAINT_NAME = 'PRIMARY' ORDER BY ORDINAL_POSITION", dbName, table)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	var columns []string
+	for rows.Next() {
+		var column string
+		err = rows.Scan(&column)
+		if err != nil {
+			return nil, err
+		}
+		columns = append(columns, column)
+	}
+	return columns, rows.Err()
+}
+
+// jsonValue convert a scanned value into a value that encoding/json serializes faithfully
+func jsonValue(columnType *sql.ColumnType, col interface{}) interface{} {
+	if col == nil {
+		return nil
+	}
+
+	switch goType(columnType) {
+	case "int64", "uint64", "float64":
+		return json.Number(rawString(col))
+	case "bool":
+		s := rawString(col)
+		return s == "1" || s == "true"
+	case "[]byte":
+		if bs, ok := col.([]byte); ok {
+			return bs
+		}
+		return []byte(rawString(col))
+	}
+
+	if isDecimalType(columnType.DatabaseTypeName()) {
+		return json.Number(rawString(col))
+	}
+	return rawString(col)
+}
+
+// Publisher publish a message to a topic, implement it with the Kafka client of
+// your choice, eg: a kafka-go Writer or a sarama SyncProducer
+type Publisher interface {
+	Publish(topic string, key, value []byte) error
+}
+
+type kafkaSink struct {
+	publisher   Publisher
+	topicPrefix string
+}
+
+// NewKafkaSink a RowSink publishing each row as a JSON object to the topic
+// topicPrefix+db+"."+table, the message key is the JSON array of the primary key values
+func NewKafkaSink(publisher Publisher, topicPrefix string) RowSink {
+	return &kafkaSink{
+		publisher:   publisher,
+		topicPrefix: topicPrefix,
+	}
+}
+
+func (s *kafkaSink) WriteRow(row Row) error {
+	value, err := json.Marshal(row.Map())
+	if err != nil {
+		return err
+	}
+
+	var key []byte
+	if len(row.PrimaryKey) > 0 {
+		m := row.Map()
+		keyValues := make([]interface{}, len(row.PrimaryKey))
+		for i, column := range row.PrimaryKey {
+			keyValues[i] = m[column]
+		}
+		key, err = json.Marshal(keyValues)
+		if err != nil {
+			return err
+		}
+	}
+
+	return s.publisher.Publish(s.topicPrefix+row.DB+"."+row.Table, key, value)
+}
+
+func (s *kafkaSink) Close() error {
+	return nil
+}
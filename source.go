@@ -1,26 +1,95 @@
 package mysqldump
 
 import (
-	"bufio"
+	"context"
 	"database/sql"
-	"errors"
 	"fmt"
 	"io"
 	"log"
 	"strings"
 	"time"
+
+	"mysqldump/sqlutil"
 )
 
 type sourceOption struct {
-	dryRun      bool
-	mergeInsert int
-	debug       bool
+	dryRun       bool
+	dryRunWriter io.Writer
+	mergeInsert  int
+	// merged INSERT statements stay below targetPacketSize bytes
+	targetPacketSize int
+	debug            bool
+	debugLog         DebugLog
+	// statements slower than slowThreshold are recorded into result
+	slowThreshold time.Duration
+	result        *SourceResult
+	// savepoint per table, or every savepointEvery statements
+	savepoints     bool
+	savepointEvery int
+	// how to handle /*! ... */ comments
+	executableComments ExecutableCommentMode
+	// how to handle plain comments
+	comments CommentMode
+	// allow LOAD DATA LOCAL INFILE, relative to localInfileDir
+	localInfile    bool
+	localInfileDir string
+	// target platform
+	profile Profile
+	// JSON lines of the executed statements
+	auditLog io.Writer
+	// replace the database of the USE statements
+	database string
+	// pace the restore on a replica, see WithReplicaThrottle
+	replicaDSN     string
+	replicaEvery   int
+	replicaTimeout time.Duration
+	// executed before and after the restore
+	preSQL  []string
+	postSQL []string
+	// decrypt the input, see WithSourceEncryption
+	kms KMS
+	// the input is a recipe of chunks, see WithSourceChunkStore
+	chunkStore ChunkStore
+	// decompress the input, see WithSourceCompression
+	compression string
+	// checks before the restore
+	preflight *Preflight
+	// rewrite the target tables, see WithTableMap
+	tableMap map[string]string
+	// load into shadow tables, see RestoreShadow
+	shadow *shadowRestore
+	// resolve duplicate key and already exists errors
+	conflictHandler func(err ConflictError) ConflictAction
+	// per table timing, see WithTimingReport
+	timingReport bool
+	timingWriter io.Writer
+	// limit of each statement, see WithSourceStatementTimeout
+	statementTimeout time.Duration
+	// rewrite the columns of the INSERT statements, see WithColumnMap
+	columnMaps  map[string]map[string]string
+	dumpColumns map[string][]string
+	// fit the INSERT statements to the target tables, see WithSchemaReconciliation
+	reconcile         bool
+	targetTableLoader func(table string) (*targetTable, error)
+	targetTables      map[string]*targetTable
+	// rewrite the values of the INSERT statements, see WithValueRewriter
+	valueRewriters map[string][]valueRewriter
+	// values of the placeholders of the dump, see WithSourceTemplateVars
+	templateVars map[string]string
+	// the format of the dump, see checkFormat
+	format            DumpFormat
+	legacyBackslashes bool
 }
 type SourceOption func(*sourceOption)
 
-func WithDryRun() SourceOption {
+// WithDryRun nothing is executed, if a writer is given the statements that
+// would be executed are written to it
+func WithDryRun(writer ...io.Writer) SourceOption {
 	return func(o *sourceOption) {
 		o.dryRun = true
+		if len(writer) > 0 {
+			o.dryRunWriter = writer[0]
+		}
 	}
 }
 
@@ -30,40 +99,403 @@ func WithMergeInsert(size int) SourceOption {
 	}
 }
 
-func WithDebug() SourceOption {
+// WithTargetPacketSize the size limit of the INSERT statements merged by WithMergeInsert, a
+// merged statement ends before it would exceed n bytes. The max_allowed_packet of the
+// target server by default.
+func WithTargetPacketSize(n int) SourceOption {
+	return func(o *sourceOption) {
+		o.targetPacketSize = n
+	}
+}
+
+// packetMargin bytes of max_allowed_packet left to the protocol
+const packetMargin = 1024
+
+// DebugLog how WithDebug logs the statements, for restores of real data
+type DebugLog struct {
+	// MaxBytes statements are truncated to MaxBytes, 0 logs them whole
+	MaxBytes int
+	// Redact replace the string, number and hex literals by ?
+	Redact bool
+	// SampleRate the fraction of the statements logged, eg: 0.01 logs one statement out
+	// of 100, 0 logs them all
+	SampleRate float64
+}
+
+// WithDebug log each executed statement, how is set by an optional DebugLog
+func WithDebug(debugLog ...DebugLog) SourceOption {
 	return func(o *sourceOption) {
 		o.debug = true
+		if len(debugLog) > 0 {
+			o.debugLog = debugLog[0]
+		}
 	}
 }
 
-type dbWrapper struct {
-	DB     *sql.DB
-	debug  bool
-	dryRun bool
+// SourceResult the report of a Source run, see WithSourceResult
+type SourceResult struct {
+	// Statements number of executed statements
+	Statements int
+	// SlowStatements statements exceeding the threshold of WithSlowThreshold
+	SlowStatements []SlowStatement
+	// Rollbacks failures rolled back to a savepoint, see WithSavepoints
+	Rollbacks []Rollback
+	// Tables the statements of each table, see WithTimingReport
+	Tables []TableTiming
+	// Format the format of the dump, see FormatVersion
+	Format DumpFormat
+}
+
+type Rollback struct {
+	// Table the table of the failed statement
+	Table string
+	Err   error
+}
+
+type SlowStatement struct {
+	// Statement the beginning of the statement
+	Statement string
+	Duration  time.Duration
+	// Bytes size of the whole statement
+	Bytes int
+}
+
+// WithSourceResult fill result with the report of the run
+func WithSourceResult(result *SourceResult) SourceOption {
+	return func(o *sourceOption) {
+		o.result = result
+	}
+}
+
+// WithSlowThreshold record statements taking longer than threshold into the result
+func WithSlowThreshold(threshold time.Duration) SourceOption {
+	return func(o *sourceOption) {
+		o.slowThreshold = threshold
+	}
+}
+
+// WithSavepoints create a savepoint before the data of each table, or every n
+// statements if n is given. When a statement fails its changes are rolled back
+// to the savepoint, the remaining statements of the table (or of the n
+// statements) are skipped and the restore goes on. DDL can not be rolled back
+// and still aborts the restore.
+func WithSavepoints(n ...int) SourceOption {
+	return func(o *sourceOption) {
+		o.savepoints = true
+		if len(n) > 0 {
+			o.savepointEvery = n[0]
+		}
+	}
+}
+
+type ExecutableCommentMode int
+
+const (
+	// ExecutableCommentPass send /*!NNNNN ... */ comments as is, the server decides by its version
+	ExecutableCommentPass ExecutableCommentMode = iota
+	// ExecutableCommentExecute unwrap the comments so that their content is always executed
+	ExecutableCommentExecute
+	// ExecutableCommentSkip remove the comments, statements made of them only are skipped
+	ExecutableCommentSkip
+)
+
+// WithExecutableComments how version-conditional comments /*!NNNNN ... */ are handled,
+// ExecutableCommentPass by default
+func WithExecutableComments(mode ExecutableCommentMode) SourceOption {
+	return func(o *sourceOption) {
+		o.executableComments = mode
+	}
+}
+
+type CommentMode int
+
+const (
+	// CommentTrimLeading remove the comments before each statement, eg: the table headers written by Dump
+	CommentTrimLeading CommentMode = iota
+	// CommentKeep send the comments to the server as they are
+	CommentKeep
+	// CommentStripAll remove every comment, executable comments excepted
+	CommentStripAll
+)
+
+// WithComments how comments (-- ..., # ..., /* ... */) are handled, CommentTrimLeading by default
+func WithComments(mode CommentMode) SourceOption {
+	return func(o *sourceOption) {
+		o.comments = mode
+	}
+}
+
+// clientCommands mysql client commands that may appear in dumps written by other tools,
+// they have no meaning for the server
+var clientCommands = map[string]bool{
+	"SOURCE":    true,
+	"CHARSET":   true,
+	"WARNINGS":  true,
+	"NOWARNING": true,
+	"PAGER":     true,
+	"NOPAGER":   true,
+	"TEE":       true,
+	"NOTEE":     true,
+	"PROMPT":    true,
+	"REHASH":    true,
+	"CONNECT":   true,
+	"EGO":       true,
+	"GO":        true,
+	"PRINT":     true,
+	"EXIT":      true,
+	"QUIT":      true,
+}
+
+// preprocess apply the comment options to dml, "" means the statement is skipped
+func preprocess(dml string, o *sourceOption) string {
+	// without vars the escaped ${ of a templated dump are still restored
+	if o.templateVars != nil || o.format.Has(FeatureTemplates) {
+		dml = sqlutil.ExpandPlaceholders(dml, o.templateVars)
+	}
+	if o.format.Version == 1 {
+		dml = legacyStatement(dml, o.syntax())
+	}
+
+	switch o.executableComments {
+	case ExecutableCommentExecute:
+		dml = sqlutil.ExpandExecutableComments(dml)
+	case ExecutableCommentSkip:
+		dml = sqlutil.StripExecutableComments(dml)
+	}
+
+	switch o.comments {
+	case CommentTrimLeading:
+		dml = sqlutil.TrimLeadingComments(dml)
+	case CommentStripAll:
+		dml = sqlutil.StripComments(dml)
+	}
+
+	// client commands end at the end of the line, what follows is the next statement
+	for {
+		dml = strings.TrimSpace(dml)
+		if sqlutil.IsBlank(dml) {
+			return ""
+		}
+
+		verb := sqlutil.StatementVerb(dml)
+		if verb == "USE" {
+			// the tables of WithColumnMap and WithSchemaReconciliation are per database
+			o.dumpColumns, o.targetTables = nil, nil
+		}
+		if verb == "USE" && o.database != "" {
+			return "USE " + sqlutil.QuoteIdentifier(o.database)
+		}
+		if !clientCommands[verb] && !strings.HasPrefix(dml, "\\") {
+			dml = o.mapColumns(dml)
+			dml = o.mapTable(dml)
+			if dml == "" {
+				return ""
+			}
+			return applySourceProfile(o.profile, dml)
+		}
+
+		line := dml
+		if idx := strings.Index(dml, "\n"); idx != -1 {
+			line, dml = dml[:idx], dml[idx+1:]
+		} else {
+			dml = ""
+		}
+		log.Printf("[warn] skip client command: %s\n", line)
+	}
 }
 
-func newDBWrapper(db *sql.DB, dryRun, debug bool) *dbWrapper {
+const slowStatementPreviewSize = 128
+
+const savepointName = "mysqldump_sp"
+
+type dbWrapper struct {
+	ctx          context.Context
+	DB           QueryerExecer
+	debug        bool
+	debugLog     DebugLog
+	debugCount   int
+	dryRun       bool
+	dryRunWriter io.Writer
+
+	slowThreshold time.Duration
+	result        *SourceResult
+	audit         *auditLog
+	timing        *timingReport
+	timeout       time.Duration
+}
 
-	return &dbWrapper{
-		DB:     db,
-		dryRun: dryRun,
-		debug:  debug,
+func newDBWrapper(ctx context.Context, db QueryerExecer, o *sourceOption) *dbWrapper {
+
+	wrapper := &dbWrapper{
+		ctx:           ctx,
+		DB:            db,
+		dryRun:        o.dryRun,
+		debug:         o.debug,
+		debugLog:      o.debugLog,
+		dryRunWriter:  o.dryRunWriter,
+		slowThreshold: o.slowThreshold,
+		result:        o.result,
+		timeout:       o.statementTimeout,
+	}
+	if o.auditLog != nil {
+		wrapper.audit = newAuditLog(o.auditLog)
+	}
+	if o.timingReport {
+		wrapper.timing = newTimingReport()
 	}
+	return wrapper
 }
 
 func (db *dbWrapper) Exec(query string, args ...interface{}) (sql.Result, error) {
 	if db.debug {
-		log.Printf("[debug] [query]\n%s\n", query)
+		db.logDebug(query)
 	}
 
 	if db.dryRun {
+		if db.dryRunWriter != nil {
+			_, err := io.WriteString(db.dryRunWriter, strings.TrimSuffix(query, ";")+";\n")
+			return nil, err
+		}
 		return nil, nil
 	}
-	return db.DB.Exec(query, args...)
+
+	ctx := db.ctx
+	if db.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, db.timeout)
+		defer cancel()
+	}
+
+	start := time.Now()
+	res, err := db.DB.ExecContext(ctx, query, args...)
+	cost := time.Since(start)
+	if err != nil && ctx.Err() == context.DeadlineExceeded && db.ctx.Err() == nil {
+		err = fmt.Errorf("statement timeout %s: %w", db.timeout, err)
+	}
+
+	if db.audit != nil {
+		if auditErr := db.audit.record(query, start, cost, res, err); auditErr != nil {
+			return res, auditErr
+		}
+	}
+
+	if db.result != nil {
+		db.result.Statements++
+	}
+
+	if db.timing != nil && err == nil {
+		db.timing.record(query, cost, res)
+	}
+
+	if db.slowThreshold > 0 && cost > db.slowThreshold {
+		preview := query
+		if len(preview) > slowStatementPreviewSize {
+			preview = preview[:slowStatementPreviewSize]
+		}
+		log.Printf("[warn] [slow] cost %s, %d bytes: %s\n", cost, len(query), preview)
+
+		if db.result != nil {
+			db.result.SlowStatements = append(db.result.SlowStatements, SlowStatement{
+				Statement: preview,
+				Duration:  cost,
+				Bytes:     len(query),
+			})
+		}
+	}
+	return res, err
+}
+
+// logDebug log query as set by DebugLog
+func (db *dbWrapper) logDebug(query string) {
+	// one statement out of 1/SampleRate, evenly spread
+	if rate := db.debugLog.SampleRate; rate > 0 && rate < 1 {
+		db.debugCount++
+		if int(float64(db.debugCount)*rate) == int(float64(db.debugCount-1)*rate) {
+			return
+		}
+	}
+
+	preview := query
+	if db.debugLog.Redact {
+		preview = sqlutil.RedactLiterals(preview)
+	}
+	if db.debugLog.MaxBytes > 0 && len(preview) > db.debugLog.MaxBytes {
+		preview = fmt.Sprintf("%s... (%d bytes)", preview[:db.debugLog.MaxBytes], len(query))
+	}
+	log.Printf("[debug] [query]\n%s\n", preview)
 }
 
 // Source Load the sql statement and execute it
 func Source(dns string, reader io.Reader, opts ...SourceOption) error {
+	sourcer, err := NewSourcer(dns, opts...)
+	if err != nil {
+		log.Printf("[error] %v\n", err)
+		return err
+	}
+	defer func() {
+		_ = sourcer.Close()
+	}()
+
+	return sourcer.Run(context.Background(), reader)
+}
+
+// Sourcer a Source configuration and its connection pool, reusable for many restores.
+// Run may be called concurrently, each run uses a connection of its own.
+type Sourcer struct {
+	dns  string
+	opts []SourceOption
+	db   *sql.DB
+	// the connection of every run, see NewSourcerWithConn
+	conn QueryerExecer
+}
+
+// NewSourcer a Sourcer loading into the database of dns, Close it once done
+func NewSourcer(dns string, opts ...SourceOption) (*Sourcer, error) {
+	db, err := sql.Open("mysql", dns)
+	if err != nil {
+		return nil, err
+	}
+	db.SetConnMaxLifetime(3600)
+
+	return &Sourcer{
+		dns:  dns,
+		opts: opts,
+		db:   db,
+	}, nil
+}
+
+// NewSourcerWithConn a Sourcer running on conn, eg: a *sql.DB of go-sqlmock in tests. The
+// restore changes the session (autocommit, savepoints), conn must be a *sql.Conn or a
+// *sql.DB limited to one connection, and Run must not be called concurrently. The
+// statements are loaded into the current database of conn.
+func NewSourcerWithConn(conn QueryerExecer, opts ...SourceOption) *Sourcer {
+	return &Sourcer{
+		opts: opts,
+		conn: conn,
+	}
+}
+
+// DSN the dsn of the target database, "" for NewSourcerWithConn
+func (s *Sourcer) DSN() string {
+	return s.dns
+}
+
+// Options the options of the Sourcer, eg: to create a Sourcer with more options
+func (s *Sourcer) Options() []SourceOption {
+	return append([]SourceOption(nil), s.opts...)
+}
+
+// Close the connections of the Sourcer, the conn of NewSourcerWithConn is left open
+func (s *Sourcer) Close() error {
+	if s.db == nil {
+		return nil
+	}
+	return s.db.Close()
+}
+
+// Run load the statements of reader, opts are applied after the options of the Sourcer
+// for this run only. Cancelling ctx aborts the running statement.
+func (s *Sourcer) Run(ctx context.Context, reader io.Reader, opts ...SourceOption) (err error) {
 
 	start := time.Now()
 	log.Printf("[info] [source] start at %s\n", start.Format("2006-01-02 15:04:05"))
@@ -73,94 +505,249 @@ func Source(dns string, reader io.Reader, opts ...SourceOption) error {
 		log.Printf("[info] [source] end at %s, cost %s\n", end.Format("2006-01-02 15:04:05"), end.Sub(start))
 	}()
 
-	var err error
-	var db *sql.DB
 	var o sourceOption
+	for _, opt := range s.opts {
+		opt(&o)
+	}
 	for _, opt := range opts {
 		opt(&o)
 	}
 
-	dbName, err := GetDBNameFromDNS(dns)
-	if err != nil {
-		log.Printf("[error] %v\n", err)
-		return err
+	// autocommit and savepoints are bound to the session, keep one connection, a dry run
+	// never reaches the server
+	var conn QueryerExecer = s.conn
+	var dbName string
+	if conn == nil {
+		dbName, err = GetDBNameFromDNS(s.dns)
+		if err != nil {
+			log.Printf("[error] %v\n", err)
+			return err
+		}
+	}
+	if conn == nil && !o.dryRun {
+		poolConn, err := s.db.Conn(ctx)
+		if err != nil {
+			log.Printf("[error] %v\n", err)
+			return err
+		}
+		stop := killOnCancel(ctx, s.db, poolConn)
+		defer func() {
+			stop()
+			// a failed restore leaves an open transaction, it must not reach the next run
+			if err != nil && (releaseExecContext(poolConn, "ROLLBACK") != nil || releaseExecContext(poolConn, "SET autocommit=1") != nil) {
+				discardConn(poolConn)
+			}
+			_ = poolConn.Close()
+		}()
+		conn = poolConn
 	}
 
-	db, err = sql.Open("mysql", dns)
-	if err != nil {
-		log.Printf("[error] %v\n", err)
-		return err
+	if o.preflight != nil && !o.dryRun {
+		err = sourcePreflight(ctx, conn, dbName, *o.preflight)
+		if err != nil {
+			log.Printf("[error] %v\n", err)
+			return err
+		}
+	}
+
+	dbWrapper := newDBWrapper(ctx, conn, &o)
+	if o.reconcile && conn != nil {
+		o.targetTableLoader = func(table string) (*targetTable, error) {
+			return loadTargetTable(ctx, conn, table)
+		}
+	}
+	if dbWrapper.timing != nil {
+		defer func() {
+			if o.result != nil {
+				o.result.Tables = dbWrapper.timing.tables
+			}
+			if o.timingWriter != nil {
+				if reportErr := dbWrapper.timing.write(o.timingWriter); reportErr != nil {
+					log.Printf("[warn] [timing] %v\n", reportErr)
+				}
+			}
+		}()
+	}
+
+	if dbName != "" {
+		_, err = dbWrapper.Exec(fmt.Sprintf("USE %s;", sqlutil.QuoteIdentifier(dbName)))
+		if err != nil {
+			log.Printf("[error] %v\n", err)
+			return err
+		}
+	}
+
+	if o.chunkStore != nil {
+		reader, err = NewChunkReader(o.chunkStore, reader)
+		if err != nil {
+			log.Printf("[error] %v\n", err)
+			return err
+		}
+	}
+
+	if o.kms != nil {
+		reader, err = NewDecryptReader(reader, o.kms)
+		if err != nil {
+			log.Printf("[error] %v\n", err)
+			return err
+		}
+	}
+
+	if o.compression != "" {
+		codec, err := getCodec(o.compression)
+		if err != nil {
+			log.Printf("[error] %v\n", err)
+			return err
+		}
+		decompressor, err := codec.NewReader(reader)
+		if err != nil {
+			log.Printf("[error] %v\n", err)
+			return err
+		}
+		defer func() {
+			_ = decompressor.Close()
+		}()
+		reader = decompressor
 	}
-	defer func() {
-		_ = db.Close()
-	}()
 
-	dbWrapper := newDBWrapper(db, o.dryRun, o.debug)
+	scanner := sqlutil.NewScanner(reader)
 
-	_, err = dbWrapper.Exec(fmt.Sprintf("USE %s;", dbName))
+	err = execStatements(dbWrapper, o.preSQL)
 	if err != nil {
 		log.Printf("[error] %v\n", err)
 		return err
 	}
 
-	db.SetConnMaxLifetime(3600)
-
-	r := bufio.NewReader(reader)
-
 	_, err = dbWrapper.Exec("SET autocommit=0;")
 	if err != nil {
 		log.Printf("[error] %v\n", err)
 		return err
 	}
 
-	for {
-		line, err := r.ReadString(';')
+	sp := &savepointState{every: o.savepointEvery, result: o.result}
+
+	var throttle *replicaThrottle
+	if o.replicaDSN != "" && !o.dryRun {
+		throttle, err = newReplicaThrottle(&o)
 		if err != nil {
-			if err == io.EOF {
-				break
-			}
 			log.Printf("[error] %v\n", err)
 			return err
 		}
+		defer throttle.close()
+	}
 
-		dml := trim(line)
+	if o.mergeInsert > 1 && o.targetPacketSize == 0 && !o.dryRun {
+		err = conn.QueryRowContext(ctx, "SELECT @@max_allowed_packet").Scan(&o.targetPacketSize)
+		if err != nil {
+			log.Printf("[warn] [mergeInsert] max_allowed_packet unknown, merged statements are not limited: %v \n", err)
+		}
+	}
+	packetLimit := o.targetPacketSize
+	if packetLimit > 2*packetMargin {
+		packetLimit -= packetMargin
+	}
 
-		// merge insert statement if mergeInsert is true
-		if o.mergeInsert > 1 && strings.HasPrefix(dml, "INSERT INTO") {
-			var insertSQLs []string
-			insertSQLs = append(insertSQLs, dml)
-			for i := 0; i < o.mergeInsert-1; i++ {
-				line, err := r.ReadString(';')
+	// pending a statement read ahead by the merge of inserts, already preprocessed
+	var pending string
+	var pendingLine int
+	var pendingOffset int64
+	first := true
+	for pending != "" || scanner.Scan() {
+		dml, line, offset := pending, pendingLine, pendingOffset
+		preprocessed := dml != ""
+		pending = ""
+		if !preprocessed {
+			dml, line, offset = scanner.Statement(), scanner.Line(), scanner.Offset()
+		}
+
+		// the format line leads the first statement
+		if first {
+			first = false
+			err = o.checkFormat(dml)
+			if err != nil {
+				log.Printf("[error] %v\n", err)
+				return err
+			}
+			if o.format.Version == 1 {
+				var stop func()
+				stop, err = o.startLegacyMode(dbWrapper)
 				if err != nil {
-					if err == io.EOF {
-						break
-					}
 					log.Printf("[error] %v\n", err)
 					return err
 				}
+				defer stop()
+				scanner.SetSyntax(o.syntax())
+			}
+		}
+
+		if !preprocessed {
+			dml = preprocess(dml, &o)
+		}
+		if dml == "" {
+			continue
+		}
 
-				l := trim(line)
+		// merge insert statement if mergeInsert is true
+		if o.mergeInsert > 1 && strings.HasPrefix(dml, "INSERT INTO") {
+			merged, mergeErr := o.syntax().SplitInsertValues(dml)
+			size := len(dml)
+			for i := 0; mergeErr == nil && merged.Tail == "" && i < o.mergeInsert-1 && scanner.Scan(); i++ {
+				l := preprocess(scanner.Statement(), &o)
+				if l == "" {
+					continue
+				}
 
-				if strings.HasPrefix(l, "INSERT INTO") {
-					insertSQLs = append(insertSQLs, l)
+				// the VALUES of l are appended, its whole size is an upper bound
+				next, ok := mergeableInsert(merged, l, o.syntax())
+				if ok && (packetLimit <= 0 || size+len(l) <= packetLimit) {
+					size += len(l)
+					merged.Rows = append(merged.Rows, next.Rows...)
 					continue
 				}
 
+				pending, pendingLine, pendingOffset = l, scanner.Line(), scanner.Offset()
 				break
 			}
+			if mergeErr == nil {
+				dml = merged.String()
+			}
+		}
 
-			dml, err = mergeInsert(insertSQLs)
+		deregister := func() {}
+		if o.localInfile {
+			dml, deregister, err = prepareLocalInfile(dml, o.localInfileDir)
 			if err != nil {
-				log.Printf("[error] [mergeInsert] %v\n", err)
+				log.Printf("[error] %v\n", err)
 				return err
 			}
 		}
 
-		_, err = dbWrapper.Exec(dml)
+		if !o.savepoints {
+			_, err = dbWrapper.Exec(dml)
+		} else {
+			err = sp.exec(dbWrapper, dml)
+		}
+		deregister()
+		if err != nil && o.conflictHandler != nil {
+			err = o.resolveConflict(dbWrapper, dml, line, err)
+		}
 		if err != nil {
+			err = newStatementError(line, offset, dml, err)
 			log.Printf("[error] %v\n", err)
 			return err
 		}
+
+		if throttle != nil {
+			err = throttle.pace(dbWrapper, sp)
+			if err != nil {
+				log.Printf("[error] %v\n", err)
+				return err
+			}
+		}
+	}
+	if err = scanner.Err(); err != nil {
+		log.Printf("[error] %v\n", err)
+		return err
 	}
 
 	_, err = dbWrapper.Exec("COMMIT;")
@@ -175,45 +762,146 @@ func Source(dns string, reader io.Reader, opts ...SourceOption) error {
 		return err
 	}
 
+	err = execStatements(dbWrapper, o.postSQL)
+	if err != nil {
+		log.Printf("[error] %v\n", err)
+		return err
+	}
+
 	return nil
 }
 
-// Merge insert statement
-// Input:
-// INSERT INTO `test` VALUES (1, 'a');
-// INSERT INTO `test` VALUES (2, 'b');
-// Output:
-// INSERT INTO `test` VALUES (1, 'a'), (2, 'b');
-func mergeInsert(insertSQLs []string) (string, error) {
-	if len(insertSQLs) == 0 {
-		return "", errors.New("no input provided")
+const statementErrorPreviewSize = 64
+
+// StatementError a statement of the dump failed, Line and Offset locate the beginning
+// of its code in the input of Source, a merged INSERT is located by its first statement
+type StatementError struct {
+	Line   int
+	Offset int64
+	// Statement the beginning of the statement
+	Statement string
+	Err       error
+}
+
+func newStatementError(line int, offset int64, dml string, err error) *StatementError {
+	preview := strings.Join(strings.Fields(dml), " ")
+	if len(preview) > statementErrorPreviewSize {
+		preview = preview[:statementErrorPreviewSize] + "..."
+	}
+	return &StatementError{
+		Line:      line,
+		Offset:    offset,
+		Statement: preview,
+		Err:       err,
+	}
+}
+
+func (e *StatementError) Error() string {
+	return fmt.Sprintf("error at line %d near '%s': %v", e.Line, e.Statement, e.Err)
+}
+
+func (e *StatementError) Unwrap() error {
+	return e.Err
+}
+
+// savepointState tracks the savepoint of WithSavepoints
+type savepointState struct {
+	every  int
+	result *SourceResult
+
+	// active savepoint, DDL implicitly commits and releases it
+	active bool
+	table  string
+	count  int
+	// skip the statements of a rolled back table or chunk
+	skipTable string
+	skipCount int
+}
+
+func (sp *savepointState) exec(db *dbWrapper, dml string) error {
+	if sqlutil.IsDDL(dml) {
+		sp.active = false
+		sp.skipTable = ""
+		_, err := db.Exec(dml)
+		return err
 	}
-	builder := strings.Builder{}
-	sql1 := insertSQLs[0]
-	sql1 = strings.TrimSuffix(sql1, ";")
-	builder.WriteString(sql1)
-	for i, insertSQL := range insertSQLs[1:] {
-		if i < len(insertSQLs)-1 {
-			builder.WriteString(",")
+
+	table := sqlutil.StatementTable(dml)
+	if sp.every > 0 {
+		if sp.skipCount > 0 {
+			sp.skipCount--
+			return nil
+		}
+		if sp.count%sp.every == 0 {
+			sp.active = false
+		}
+	} else {
+		if sp.skipTable != "" && table == sp.skipTable {
+			return nil
 		}
+		sp.skipTable = ""
+		if table != sp.table {
+			sp.active = false
+		}
+	}
 
-		valuesIdx := strings.Index(insertSQL, "VALUES")
-		if valuesIdx == -1 {
-			return "", errors.New("invalid SQL: missing VALUES keyword")
+	if !sp.active {
+		_, err := db.Exec(fmt.Sprintf("SAVEPOINT %s;", savepointName))
+		if err != nil {
+			return err
 		}
-		dml := insertSQL[valuesIdx:]
-		dml = strings.TrimPrefix(dml, "VALUES")
-		dml = strings.TrimSuffix(dml, ";")
-		builder.WriteString(dml)
+		sp.active = true
+		sp.table = table
+		sp.count = 0
+	}
+	sp.count++
+
+	_, err := db.Exec(dml)
+	if err == nil {
+		return nil
+	}
 
+	log.Printf("[warn] [savepoint] rollback table %s: %v\n", table, err)
+	_, rbErr := db.Exec(fmt.Sprintf("ROLLBACK TO SAVEPOINT %s;", savepointName))
+	if rbErr != nil {
+		return err
 	}
-	builder.WriteString(";")
 
-	return builder.String(), nil
+	if sp.result != nil {
+		sp.result.Rollbacks = append(sp.result.Rollbacks, Rollback{
+			Table: table,
+			Err:   err,
+		})
+	}
+	if sp.every > 0 {
+		sp.skipCount = sp.every - sp.count
+	} else {
+		sp.skipTable = table
+	}
+	sp.active = false
+	return nil
+}
+
+// mergeableInsert the parts of stmt when its rows can be appended to the rows of merged:
+// it inserts into the same table and columns, eg:
+//
+//	INSERT INTO `test` VALUES (1, 'a');
+//	INSERT INTO `test` VALUES (2, 'b');
+//
+// are merged into INSERT INTO `test` VALUES (1, 'a'),(2, 'b')
+func mergeableInsert(merged *sqlutil.InsertValues, stmt string, syntax sqlutil.Syntax) (*sqlutil.InsertValues, bool) {
+	if !strings.HasPrefix(stmt, "INSERT INTO") {
+		return nil, false
+	}
+	next, err := syntax.SplitInsertValues(stmt)
+	if err != nil || next.Tail != "" || insertHead(next.Head) != insertHead(merged.Head) ||
+		sqlutil.QuoteIdentifiers(next.Columns) != sqlutil.QuoteIdentifiers(merged.Columns) || (next.Columns == nil) != (merged.Columns == nil) {
+		return nil, false
+	}
+	return next, true
 }
 
-func trim(s string) string {
-	s = strings.TrimLeft(s, "\n")
-	s = strings.TrimSpace(s)
-	return s
+// insertHead the head of an INSERT without its comments and with single spaces
+func insertHead(head string) string {
+	return strings.Join(strings.Fields(sqlutil.StripComments(head)), " ")
 }
@@ -0,0 +1,125 @@
+package mysqldump
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSourceDryRunWithoutServer(t *testing.T) {
+	// nothing listens on port 1, a dry run must not connect
+	sourcer, err := NewSourcer("root:secret@tcp(127.0.0.1:1)/shop?timeout=1s",
+		WithMergeInsert(2), WithPreflight(Preflight{}), WithSchemaReconciliation())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = sourcer.Close()
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	var buf bytes.Buffer
+	dump := "CREATE TABLE `order` (`id` int NOT NULL);\nINSERT INTO `order` VALUES (1);\nINSERT INTO `order` VALUES (2);\n"
+	err = sourcer.Run(ctx, strings.NewReader(dump), WithDryRun(&buf))
+	if err != nil {
+		t.Fatalf("dry run: %v", err)
+	}
+	for _, stmt := range []string{
+		"USE `shop`;\n",
+		"CREATE TABLE `order` (`id` int NOT NULL);\n",
+		"INSERT INTO `order` VALUES (1),(2);\n",
+		"COMMIT;\n",
+	} {
+		if !strings.Contains(buf.String(), stmt) {
+			t.Errorf("dry run misses %q:\n%s", stmt, buf.String())
+		}
+	}
+}
+
+func TestPreprocessCommentModes(t *testing.T) {
+	stmt := "-- Records of order\nINSERT /*!50000 IGNORE */ /* c */ INTO `order` VALUES ('-- kept', '/* kept */') # end"
+	tests := []struct {
+		name string
+		opts []SourceOption
+		want string
+	}{
+		{"trim leading", nil, "INSERT /*!50000 IGNORE */ /* c */ INTO `order` VALUES ('-- kept', '/* kept */') # end"},
+		{"keep", []SourceOption{WithComments(CommentKeep)}, stmt},
+		{"strip", []SourceOption{WithComments(CommentStripAll)},
+			"INSERT /*!50000 IGNORE */   INTO `order` VALUES ('-- kept', '/* kept */')"},
+		{"execute", []SourceOption{WithExecutableComments(ExecutableCommentExecute)},
+			"INSERT   IGNORE   /* c */ INTO `order` VALUES ('-- kept', '/* kept */') # end"},
+		{"skip executable", []SourceOption{WithExecutableComments(ExecutableCommentSkip), WithComments(CommentStripAll)},
+			"INSERT    INTO `order` VALUES ('-- kept', '/* kept */')"},
+	}
+	for _, test := range tests {
+		var o sourceOption
+		for _, opt := range test.opts {
+			opt(&o)
+		}
+		if got := preprocess(stmt, &o); got != test.want {
+			t.Errorf("%s: preprocess = %q, want %q", test.name, got, test.want)
+		}
+	}
+}
+
+func TestSourceCommentModesReadAhead(t *testing.T) {
+	// the statements after an INSERT are read ahead by the merge of inserts, $${ tells when the
+	// placeholders of a statement are expanded twice
+	dump := "-- mysqldump-go format=2 features=templates\n-- Records of order\nINSERT INTO `order` VALUES (1);\n" +
+		"-- next\nINSERT INTO `order` VALUES ('/* kept */') /* a */;\n" +
+		"/*!40101 SET @x = '$${env}' */;\n" +
+		"INSERT INTO `order` VALUES (3);\n"
+	tests := []struct {
+		name string
+		opts []SourceOption
+		want []string
+	}{
+		{"trim leading", nil, []string{
+			"INSERT INTO `order` VALUES (1),('/* kept */');\n",
+			"/*!40101 SET @x = '${env}' */;\n",
+			"INSERT INTO `order` VALUES (3);\n",
+		}},
+		{"keep", []SourceOption{WithComments(CommentKeep)}, []string{
+			"-- mysqldump-go format=2 features=templates\n-- Records of order\nINSERT INTO `order` VALUES (1);\n",
+			"-- next\nINSERT INTO `order` VALUES ('/* kept */') /* a */;\n",
+			"/*!40101 SET @x = '${env}' */;\n",
+			"INSERT INTO `order` VALUES (3);\n",
+		}},
+		{"strip", []SourceOption{WithComments(CommentStripAll)}, []string{
+			"INSERT INTO `order` VALUES (1),('/* kept */');\n",
+			"/*!40101 SET @x = '${env}' */;\n",
+			"INSERT INTO `order` VALUES (3);\n",
+		}},
+		{"execute", []SourceOption{WithExecutableComments(ExecutableCommentExecute)}, []string{
+			"INSERT INTO `order` VALUES (1),('/* kept */');\n",
+			"SET @x = '${env}';\n",
+			"INSERT INTO `order` VALUES (3);\n",
+		}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			sourcer, err := NewSourcer("root:secret@tcp(127.0.0.1:1)/shop?timeout=1s", WithMergeInsert(10),
+				WithSourceTemplateVars(map[string]string{"env": "prod"}))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer func() {
+				_ = sourcer.Close()
+			}()
+			var buf bytes.Buffer
+			err = sourcer.Run(context.Background(), strings.NewReader(dump), append(test.opts, WithDryRun(&buf))...)
+			if err != nil {
+				t.Fatalf("dry run: %v", err)
+			}
+			for _, stmt := range test.want {
+				if n := strings.Count(buf.String(), stmt); n != 1 {
+					t.Errorf("%q written %d times:\n%s", stmt, n, buf.String())
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,354 @@
+package mysqldump
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+
+	"mysqldump/sqlutil"
+)
+
+// WithSplitTable dump the data of table with workers parallel connections, each one
+// reading a range of an integer column: the primary key when it is a single integer
+// column, else the first column of another index. The ranges are written in order, as if
+// the table was dumped by one connection, through temporary files of os.TempDir that are
+// encrypted when WithEncryption is set. The connections do not share a snapshot, use
+// it for tables that are not written to during the dump. Tables without an integer index,
+// and dumps of NewDumperWithConn which has a single connection, are dumped as usual.
+func WithSplitTable(table string, workers int) DumpOption {
+	return func(option *dumpOption) {
+		if option.splitTables == nil {
+			option.splitTables = make(map[string]int)
+		}
+		option.splitTables[table] = workers
+	}
+}
+
+// integerTypes the DATA_TYPE of the columns a table can be split on
+const integerTypes = "'tinyint', 'smallint', 'mediumint', 'int', 'bigint'"
+
+// splitColumn the integer column to split table on, "" if there is none
+func splitColumn(db dbConn, dbName, table string) (string, error) {
+	var column string
+	err := queryRows(db, "SELECT s.COLUMN_NAME FROM information_schema.STATISTICS s "+
+		"JOIN information_schema.COLUMNS c ON c.TABLE_SCHEMA = s.TABLE_SCHEMA AND c.TABLE_NAME = s.TABLE_NAME AND c.COLUMN_NAME = s.COLUMN_NAME "+
+		"WHERE s.TABLE_SCHEMA = ? AND s.TABLE_NAME = ? AND s.SEQ_IN_INDEX = 1 AND c.DATA_TYPE IN ("+integerTypes+") "+
+		"AND (s.INDEX_NAME <> 'PRIMARY' OR NOT EXISTS (SELECT 1 FROM information_schema.STATISTICS p "+
+		"WHERE p.TABLE_SCHEMA = s.TABLE_SCHEMA AND p.TABLE_NAME = s.TABLE_NAME AND p.INDEX_NAME = 'PRIMARY' AND p.SEQ_IN_INDEX = 2)) "+
+		"ORDER BY s.INDEX_NAME = 'PRIMARY' DESC, s.NON_UNIQUE, s.INDEX_NAME",
+		[]interface{}{dbName, table}, func(values []string) {
+			if column == "" {
+				column = values[0]
+			}
+		})
+	return column, err
+}
+
+// splitRanges the conditions of workers ranges of column covering every row: the first
+// range also holds the NULLs and the last one is open ended. No ranges when the bounds
+// are not integers.
+func splitRanges(db dbConn, table, column, where string, workers int) ([]string, error) {
+	query := fmt.Sprintf("SELECT MIN(%s), MAX(%s) FROM %s", sqlutil.QuoteIdentifier(column),
+		sqlutil.QuoteIdentifier(column), sqlutil.QuoteIdentifier(table))
+	if strings.TrimSpace(where) != "" {
+		query += " WHERE " + where
+	}
+
+	var minValue, maxValue *string
+	err := db.QueryRow(query).Scan(&minValue, &maxValue) // ignore_security_alert_wait_for_fix SQL
+	if err != nil {
+		return nil, err
+	}
+	if minValue == nil || maxValue == nil {
+		// no rows, or only NULLs
+		return nil, nil
+	}
+
+	// BIGINT UNSIGNED values above the int64 range are parsed as unsigned, the bounds
+	// are offsets from the minimum
+	var span uint64
+	var bound func(offset uint64) string
+	if lo, err := strconv.ParseUint(*minValue, 10, 64); err == nil {
+		hi, err := strconv.ParseUint(*maxValue, 10, 64)
+		if err != nil {
+			return nil, nil
+		}
+		span = hi - lo
+		bound = func(offset uint64) string {
+			return strconv.FormatUint(lo+offset, 10)
+		}
+	} else {
+		lo, err := strconv.ParseInt(*minValue, 10, 64)
+		if err != nil {
+			return nil, nil
+		}
+		hi, err := strconv.ParseInt(*maxValue, 10, 64)
+		if err != nil {
+			return nil, nil
+		}
+		span = uint64(hi) - uint64(lo)
+		bound = func(offset uint64) string {
+			return strconv.FormatInt(lo+int64(offset), 10)
+		}
+	}
+
+	quoted := sqlutil.QuoteIdentifier(column)
+	if span < uint64(workers) {
+		workers = int(span) + 1
+	}
+	step := span / uint64(workers)
+	ranges := make([]string, 0, workers)
+	for i := 0; i < workers; i++ {
+		var conds []string
+		if i > 0 {
+			conds = append(conds, fmt.Sprintf("%s >= %s", quoted, bound(step*uint64(i))))
+		}
+		if i < workers-1 {
+			conds = append(conds, fmt.Sprintf("%s < %s", quoted, bound(step*uint64(i+1))))
+		}
+		cond := strings.Join(conds, " AND ")
+		if i == 0 {
+			cond = fmt.Sprintf("(%s OR %s IS NULL)", cond, quoted)
+		}
+		ranges = append(ranges, cond)
+	}
+	return ranges, nil
+}
+
+// writeSplitTableData the data of a WithSplitTable table, each range is dumped into a
+// temporary file by a connection of its own, the files are then copied in order into buf.
+// The files are encrypted when the dump is, see rangeFile.
+// ok is false when the table can not be split.
+func writeSplitTableData(db dbConn, dbName, table, where string, buf *SafeWriter, o *dumpOption) (rows int, ok bool, err error) {
+	// the ranges of a Vitess shard would need the shard of their connection
+	if o.acquire == nil || o.vitessShard != "" {
+		return 0, false, nil
+	}
+
+	column, err := splitColumn(db, dbName, table)
+	if err != nil {
+		return 0, false, err
+	}
+	if column == "" {
+		o.warnf("%s.%s has no integer index to split on, it is dumped by a single connection", dbName, table)
+		return 0, false, nil
+	}
+
+	if o.isSingleTransaction || o.isFlushLogs {
+		o.warnf("%s.%s is split, its rows are read outside of the dump transaction", dbName, table)
+	}
+
+	ranges, err := splitRanges(db, table, column, where, o.splitTables[table])
+	if err != nil {
+		return 0, false, err
+	}
+	if len(ranges) < 2 {
+		return 0, false, nil
+	}
+
+	type part struct {
+		file   *rangeFile
+		rows   int
+		result DumpResult
+		err    error
+	}
+	parts := make([]part, len(ranges))
+	defer func() {
+		for _, p := range parts {
+			if p.file != nil {
+				p.file.remove()
+			}
+		}
+	}()
+
+	for i := range parts {
+		parts[i].file, err = createRangeFile(o.kms != nil)
+		if err != nil {
+			return 0, true, err
+		}
+	}
+
+	var wg sync.WaitGroup
+	for i, cond := range ranges {
+		p := &parts[i]
+		if strings.TrimSpace(where) != "" {
+			cond = fmt.Sprintf("(%s) AND %s", where, cond)
+		}
+
+		wg.Add(1)
+		go func(cond string) {
+			defer wg.Done()
+			p.rows, p.err = writeTableRange(dbName, table, cond, p.file.writer(), &p.result, o)
+		}(cond)
+	}
+	wg.Wait()
+
+	for _, p := range parts {
+		if p.err != nil {
+			return 0, true, p.err
+		}
+	}
+	var chunks int
+	for _, p := range parts {
+		for _, warning := range p.result.Warnings {
+			o.warnf("%s", warning)
+		}
+		if o.result != nil {
+			o.result.WriterStalls += p.result.WriterStalls
+			o.result.WriterStallTime += p.result.WriterStallTime
+		}
+		var r io.Reader
+		r, err = p.file.reader()
+		if err != nil {
+			return 0, true, err
+		}
+		chunks, err = copyRange(buf, r, chunks, o)
+		if err != nil {
+			return 0, true, err
+		}
+		rows += p.rows
+	}
+	return rows, true, nil
+}
+
+var chunkAnnotationRe = regexp.MustCompile(` @chunk=(\d+)`)
+
+// copyRange copy the rows of a range into buf. The @chunk annotations of each range start
+// from 1, they are moved after the chunks of the previous ranges so that they number the
+// INSERT statements of the whole table. It returns the last chunk of the range.
+func copyRange(buf *SafeWriter, r io.Reader, chunks int, o *dumpOption) (int, error) {
+	if !o.isAnnotations {
+		_, err := io.Copy(buf, r)
+		return chunks, err
+	}
+
+	last := chunks
+	reader := bufio.NewReaderSize(r, BufferSize)
+	lineStart := true
+	for {
+		// values hold no raw new lines, a line starting with an annotation is one
+		line, err := reader.ReadSlice('\n')
+		if lineStart && bytes.HasPrefix(line, []byte("-- @db=")) {
+			line = chunkAnnotationRe.ReplaceAllFunc(line, func(m []byte) []byte {
+				chunk, _ := strconv.Atoi(string(m[len(" @chunk="):]))
+				last = chunks + chunk
+				return []byte(" @chunk=" + strconv.Itoa(last))
+			})
+		}
+		if _, writeErr := buf.Write(line); writeErr != nil {
+			return last, writeErr
+		}
+
+		lineStart = err == nil
+		if err == io.EOF {
+			return last, nil
+		}
+		if err != nil && err != bufio.ErrBufferFull {
+			return last, err
+		}
+	}
+}
+
+// rangeFile the temporary file of a range. The rows of a WithEncryption dump are
+// encrypted with AES-CTR under a key that stays in memory, so no plaintext reaches the disk.
+type rangeFile struct {
+	file  *os.File
+	block cipher.Block
+	iv    []byte
+}
+
+func createRangeFile(encrypt bool) (*rangeFile, error) {
+	f := &rangeFile{}
+	if encrypt {
+		key := make([]byte, 32)
+		f.iv = make([]byte, aes.BlockSize)
+		_, err := rand.Read(key)
+		if err != nil {
+			return nil, err
+		}
+		_, err = rand.Read(f.iv)
+		if err != nil {
+			return nil, err
+		}
+		f.block, err = aes.NewCipher(key)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var err error
+	f.file, err = os.CreateTemp("", "mysqldump-split-*.sql")
+	if err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// writer the writer of the range, used once
+func (f *rangeFile) writer() io.Writer {
+	if f.block == nil {
+		return f.file
+	}
+	return &cipher.StreamWriter{S: cipher.NewCTR(f.block, f.iv), W: f.file}
+}
+
+// reader the content of the range from its start
+func (f *rangeFile) reader() (io.Reader, error) {
+	_, err := f.file.Seek(0, io.SeekStart)
+	if err != nil {
+		return nil, err
+	}
+	if f.block == nil {
+		return f.file, nil
+	}
+	return &cipher.StreamReader{S: cipher.NewCTR(f.block, f.iv), R: f.file}, nil
+}
+
+func (f *rangeFile) remove() {
+	_ = f.file.Close()
+	_ = os.Remove(f.file.Name())
+}
+
+// writeTableRange dump the rows of table matching where into w with a connection of its own
+func writeTableRange(dbName, table, where string, w io.Writer, result *DumpResult, o *dumpOption) (int, error) {
+	db, release, err := o.acquire()
+	if err != nil {
+		return 0, err
+	}
+	defer release()
+
+	_, err = db.Exec("USE " + sqlutil.QuoteIdentifier(dbName))
+	if err != nil {
+		return 0, err
+	}
+	if o.tidbSnapshot != "" {
+		err = setTiDBSnapshot(db, o.tidbSnapshot)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	// the warnings of the range are reported once the ranges are done, the stats of the
+	// columns describe the whole table and are not collected
+	ro := *o
+	ro.result = result
+	ro.isColumnStats = false
+	setStatementTimeout(db, &ro)
+	setResourceGroup(db, &ro)
+
+	buf := NewSafeWriterWithSize(w, BufferSize)
+	rows, err := writeTableData(db, dbName, table, where, buf, &ro)
+	if err != nil {
+		return 0, err
+	}
+	return rows, buf.Flush()
+}
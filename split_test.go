@@ -0,0 +1,41 @@
+package mysqldump
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestCopyRangeChunks(t *testing.T) {
+	ranges := []string{
+		"-- @db=shop @table=order @chunk=1\nINSERT INTO `order` VALUES (1,'-- @db=shop @chunk=9');\n" +
+			"-- @db=shop @table=order @chunk=2\nINSERT INTO `order` VALUES (2,'a');\n",
+		"-- @db=shop @table=order @chunk=1 @partitions=p1\nINSERT INTO `order` VALUES (3,'" + strings.Repeat("x", 2*BufferSize) + "');\n",
+		"-- @db=shop @table=order @chunk=1\nINSERT INTO `order` VALUES (4,'b');\n",
+	}
+
+	var out bytes.Buffer
+	buf := NewSafeWriterWithSize(&out, BufferSize)
+	o := &dumpOption{isAnnotations: true}
+	var chunks int
+	var err error
+	for _, r := range ranges {
+		chunks, err = copyRange(buf, strings.NewReader(r), chunks, o)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err = buf.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	if chunks != 4 {
+		t.Errorf("last chunk %d, want 4", chunks)
+	}
+	want := strings.Join(ranges, "")
+	want = strings.Replace(want, "@chunk=1 @partitions=p1", "@chunk=3 @partitions=p1", 1)
+	want = strings.Replace(want, "@chunk=1\nINSERT INTO `order` VALUES (4", "@chunk=4\nINSERT INTO `order` VALUES (4", 1)
+	if out.String() != want {
+		t.Errorf("ranges copied as:\n%.300s\nwant:\n%.300s", out.String(), want)
+	}
+}
@@ -0,0 +1,117 @@
+package sqlutil
+
+import (
+	"strings"
+)
+
+// ExpandExecutableComments unwraps the version-conditional comments of stmt so that
+// their content is executed whatever the server version,
+// eg: "/*!40101 SET NAMES utf8 */" returns " SET NAMES utf8 ".
+func ExpandExecutableComments(stmt string) string {
+	return rewriteExecutableComments(stmt, func(content string) string {
+		return " " + strings.TrimLeft(content, "0123456789") + " "
+	})
+}
+
+// StripExecutableComments removes the version-conditional comments of stmt,
+// eg: "CREATE TABLE t (id int) /*!50100 PARTITION BY HASH (id) */" returns
+// "CREATE TABLE t (id int) ".
+func StripExecutableComments(stmt string) string {
+	return rewriteExecutableComments(stmt, func(string) string {
+		return ""
+	})
+}
+
+// rewriteExecutableComments replaces each /*! ... */ outside of quotes by fn of
+// its content, the text between "/*!" and "*/".
+func rewriteExecutableComments(stmt string, fn func(content string) string) string {
+	if !strings.Contains(stmt, "/*!") {
+		return stmt
+	}
+
+	var builder strings.Builder
+	var quote byte
+	for i := 0; i < len(stmt); i++ {
+		c := stmt[i]
+		if quote != 0 {
+			builder.WriteByte(c)
+			if c == '\\' && quote != '`' && i+1 < len(stmt) {
+				i++
+				builder.WriteByte(stmt[i])
+			} else if c == quote {
+				quote = 0
+			}
+			continue
+		}
+
+		switch {
+		case c == '\'' || c == '"' || c == '`':
+			quote = c
+		case strings.HasPrefix(stmt[i:], "/*!"):
+			end := strings.Index(stmt[i+3:], "*/")
+			if end == -1 {
+				builder.WriteString(stmt[i:])
+				return builder.String()
+			}
+			builder.WriteString(fn(stmt[i+3 : i+3+end]))
+			i += 3 + end + 1
+			continue
+		}
+		builder.WriteByte(c)
+	}
+	return builder.String()
+}
+
+// TrimLeadingComments removes the whitespace and comments before the first
+// keyword of stmt, executable comments /*! ... */ are kept.
+func TrimLeadingComments(stmt string) string {
+	return skipSpaceAndComments(stmt)
+}
+
+// StripComments removes every comment of stmt outside of quotes, executable
+// comments /*! ... */ and optimizer hints /*+ ... */ are kept.
+func StripComments(stmt string) string {
+	var builder strings.Builder
+	var quote byte
+	for i := 0; i < len(stmt); i++ {
+		c := stmt[i]
+		if quote != 0 {
+			builder.WriteByte(c)
+			if c == '\\' && quote != '`' && i+1 < len(stmt) {
+				i++
+				builder.WriteByte(stmt[i])
+			} else if c == quote {
+				quote = 0
+			}
+			continue
+		}
+
+		rest := stmt[i:]
+		switch {
+		case c == '\'' || c == '"' || c == '`':
+			quote = c
+		case c == '#' || isLineComment(rest):
+			end := strings.Index(rest, "\n")
+			if end == -1 {
+				return builder.String()
+			}
+			i += end
+			c = '\n'
+		case strings.HasPrefix(rest, "/*") && !strings.HasPrefix(rest, "/*!") && !strings.HasPrefix(rest, "/*+"):
+			end := strings.Index(rest[2:], "*/")
+			if end == -1 {
+				return builder.String()
+			}
+			i += 2 + end + 1
+			c = ' '
+		}
+		builder.WriteByte(c)
+	}
+	return builder.String()
+}
+
+// isLineComment "--" starts a comment only when followed by whitespace
+func isLineComment(s string) bool {
+	return len(s) >= 3 && s[0] == '-' && s[1] == '-' && (s[2] == ' ' || s[2] == '\t' || s[2] == '\n' || s[2] == '\r') ||
+		s == "--"
+}
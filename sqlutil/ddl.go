@@ -0,0 +1,69 @@
+package sqlutil
+
+import (
+	"strings"
+)
+
+// CreateTableColumns returns the column names of a CREATE TABLE statement in
+// their order, the key and constraint definitions are skipped, eg:
+// "CREATE TABLE `t` (`id` int, `name` text, PRIMARY KEY (`id`))" returns
+// [id name]. It returns nil for CREATE TABLE ... LIKE.
+func CreateTableColumns(ddl string) []string {
+	var columns []string
+	for _, definition := range createTableDefinitions(ddl) {
+		words := leadingWords(definition, 1)
+		if len(words) == 0 {
+			continue
+		}
+		if definition[0] != '`' && definition[0] != '"' {
+			switch strings.ToUpper(words[0].word) {
+			case "PRIMARY", "KEY", "INDEX", "UNIQUE", "FULLTEXT", "SPATIAL", "CONSTRAINT", "FOREIGN", "CHECK":
+				continue
+			}
+		}
+		columns = append(columns, words[0].word)
+	}
+	return columns
+}
+
+// createTableDefinitions the comma separated definitions between the parentheses of a
+// CREATE TABLE statement
+func createTableDefinitions(ddl string) []string {
+	var definitions []string
+	depth := 0
+	start := -1
+	var quote byte
+	for i := 0; i < len(ddl); i++ {
+		c := ddl[i]
+		if quote != 0 {
+			if c == '\\' && quote != '`' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+			continue
+		}
+
+		switch c {
+		case '\'', '"', '`':
+			quote = c
+		case '(':
+			depth++
+			if depth == 1 {
+				start = i + 1
+			}
+		case ')':
+			depth--
+			if depth == 0 && start != -1 {
+				definitions = append(definitions, strings.TrimSpace(ddl[start:i]))
+				return definitions
+			}
+		case ',':
+			if depth == 1 {
+				definitions = append(definitions, strings.TrimSpace(ddl[start:i]))
+				start = i + 1
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,28 @@
+// Package sqlutil exposes the SQL helpers used by mysqldump so custom tooling
+// can reuse them: DSN parsing, identifier quoting, value escaping and
+// statement splitting.
+package sqlutil
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ParseDBName returns the database name of a go-sql-driver style dsn,
+// eg: "user:password@tcp(127.0.0.1:3306)/db?charset=utf8mb4" returns "db".
+func ParseDBName(dsn string) (string, error) {
+	idx := strings.LastIndex(dsn, "/")
+	if idx == -1 {
+		return "", fmt.Errorf("dsn error: %s", dsn)
+	}
+
+	dbName := dsn[idx+1:]
+	if i := strings.Index(dbName, "?"); i != -1 {
+		dbName = dbName[:i]
+	}
+	if dbName == "" {
+		return "", fmt.Errorf("dsn error: %s", dsn)
+	}
+
+	return dbName, nil
+}
@@ -0,0 +1,104 @@
+package sqlutil
+
+import (
+	"strings"
+	"testing"
+	"unicode"
+)
+
+func FuzzSplitStatements(f *testing.F) {
+	f.Add("SELECT 1; SELECT 2")
+	f.Add("INSERT INTO `t` VALUES ('a;b', \"c\\\"d\"); -- done\n")
+	f.Add("DELIMITER ;;\nCREATE TRIGGER t BEFORE INSERT ON x FOR EACH ROW BEGIN SET @a = 1; END;;\nDELIMITER ;\n")
+	f.Add("/*!40101 SET NAMES utf8 */;\n# comment\nSELECT 1")
+	f.Add(byteOrderMark + "SELECT 1")
+
+	f.Fuzz(func(t *testing.T, input string) {
+		scanner := NewScanner(strings.NewReader(input))
+		lastOffset := int64(-1)
+		for scanner.Scan() {
+			stmt := scanner.Statement()
+			if stmt == "" || stmt != strings.Trim(stmt, whitespace) {
+				t.Fatalf("statement %q is not trimmed or is empty", stmt)
+			}
+
+			// the position is the one of the first byte of code of the statement
+			offset := scanner.Offset()
+			if offset <= lastOffset || offset >= int64(len(input)) {
+				t.Fatalf("offset %d after %d out of %d bytes", offset, lastOffset, len(input))
+			}
+			lastOffset = offset
+			if line := 1 + strings.Count(input[:offset], "\n"); scanner.Line() != line {
+				t.Fatalf("line %d at offset %d, want %d", scanner.Line(), offset, line)
+			}
+			if strings.IndexByte(whitespace, input[offset]) != -1 {
+				t.Fatalf("offset %d is whitespace", offset)
+			}
+		}
+		if err := scanner.Err(); err != nil {
+			t.Fatal(err)
+		}
+	})
+}
+
+func FuzzQuoteString(f *testing.F) {
+	f.Add("it's")
+	f.Add(`C:\new\table`)
+	f.Add("a\x00b\x1a\r\n\"")
+	f.Add(`\%_`)
+
+	f.Fuzz(func(t *testing.T, s string) {
+		for _, syntax := range []Syntax{{}, {NoBackslashEscapes: true}} {
+			quoted := syntax.QuoteString(s)
+			value, err := syntax.ParseValue(quoted)
+			if err != nil {
+				t.Fatalf("ParseValue(%s) with %+v: %v", quoted, syntax, err)
+			}
+			if value != s {
+				t.Fatalf("ParseValue(%s) with %+v = %q, want %q", quoted, syntax, value, s)
+			}
+		}
+	})
+}
+
+// reservedWords the reserved words of MySQL that matter to the header of an INSERT
+var reservedWords = map[string]bool{
+	"INSERT": true, "REPLACE": true, "LOW_PRIORITY": true, "DELAYED": true, "HIGH_PRIORITY": true,
+	"IGNORE": true, "INTO": true, "PARTITION": true, "VALUES": true, "SELECT": true, "SET": true,
+}
+
+func FuzzSplitInsertValues(f *testing.F) {
+	f.Add("t", "a")
+	f.Add("VALUES_log", "(1),(2)")
+	f.Add("value", "VALUES (1)")
+	f.Add("we`ird", `\`)
+
+	f.Fuzz(func(t *testing.T, table, s string) {
+		names := []string{QuoteIdentifier(table), QuoteIdentifier(table) + "." + QuoteIdentifier(table)}
+		// unquoted when it is a word that is not reserved, eg: VALUES_log
+		if table != "" && !reservedWords[strings.ToUpper(table)] &&
+			strings.IndexFunc(table, func(r rune) bool { return r > unicode.MaxASCII || !isWordByte(byte(r)) }) == -1 {
+			names = append(names, table, "db."+table)
+		}
+
+		for _, name := range names {
+			stmt := "INSERT INTO " + name + " (`v`, `n`) VALUES (" + QuoteString(s) + ", 1),(NULL, 2)"
+			values, err := SplitInsertValues(stmt)
+			if err != nil {
+				t.Fatalf("SplitInsertValues(%s): %v", stmt, err)
+			}
+			if len(values.Rows) != 2 || len(values.Columns) != 2 || values.Tail != "" {
+				t.Fatalf("SplitInsertValues(%s) = %+v", stmt, values)
+			}
+
+			// put back together it holds the same values
+			_, rows, err := ParseInsertValues(values.String())
+			if err != nil {
+				t.Fatalf("ParseInsertValues(%s): %v", values.String(), err)
+			}
+			if rows[0][0] != s || rows[1][0] != nil {
+				t.Fatalf("ParseInsertValues(%s) = %q", values.String(), rows)
+			}
+		}
+	})
+}
@@ -0,0 +1,51 @@
+package sqlutil
+
+import "strings"
+
+// QuoteIdentifier wraps a table, column or database name in backticks,
+// backticks inside the name are doubled.
+func QuoteIdentifier(name string) string {
+	return "`" + strings.Replace(name, "`", "``", -1) + "`"
+}
+
+// QuoteIdentifiers quotes every identifier and joins them with commas.
+func QuoteIdentifiers(names []string) string {
+	quoted := make([]string, len(names))
+	for i, name := range names {
+		quoted[i] = QuoteIdentifier(name)
+	}
+	return strings.Join(quoted, ",")
+}
+
+// EscapeString escapes the characters that MySQL treats specially inside a
+// string literal, the result is safe to place between single quotes.
+func EscapeString(s string) string {
+	var builder strings.Builder
+	builder.Grow(len(s))
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; c {
+		case 0:
+			builder.WriteString(`\0`)
+		case '\n':
+			builder.WriteString(`\n`)
+		case '\r':
+			builder.WriteString(`\r`)
+		case '\\':
+			builder.WriteString(`\\`)
+		case '\'':
+			builder.WriteString(`\'`)
+		case '"':
+			builder.WriteString(`\"`)
+		case '\x1a':
+			builder.WriteString(`\Z`)
+		default:
+			builder.WriteByte(c)
+		}
+	}
+	return builder.String()
+}
+
+// QuoteString escapes s and wraps it in single quotes.
+func QuoteString(s string) string {
+	return "'" + EscapeString(s) + "'"
+}
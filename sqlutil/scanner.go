@@ -0,0 +1,270 @@
+package sqlutil
+
+import (
+	"bufio"
+	"io"
+	"strings"
+)
+
+// Scanner splits a stream of SQL text into statements. Unlike a plain split
+// on ';' it understands quoted strings, quoted identifiers and comments, so
+// a ';' inside them does not end the statement. Like the mysql client it
+// honors DELIMITER lines, eg: DELIMITER ;; before a trigger body.
+//
+//	scanner := sqlutil.NewScanner(reader)
+//	for scanner.Scan() {
+//		stmt := scanner.Statement()
+//	}
+//	if err := scanner.Err(); err != nil {
+//		...
+//	}
+type Scanner struct {
+	r         *bufio.Reader
+	stmt      string
+	err       error
+	delimiter string
+	syntax    Syntax
+
+	// position of the next byte, line is 1-based
+	offset int64
+	line   int
+	// position of the code of the current statement
+	stmtOffset int64
+	stmtLine   int
+}
+
+// byteOrderMark the UTF-8 byte order mark
+const byteOrderMark = "\xef\xbb\xbf"
+
+// whitespace the bytes the server skips between tokens
+const whitespace = " \t\n\r\f\v"
+
+func NewScanner(r io.Reader) *Scanner {
+	return &Scanner{
+		r:         bufio.NewReader(r),
+		delimiter: ";",
+		line:      1,
+	}
+}
+
+// SetSyntax sets the string syntax of the statements that follow, eg: without backslash
+// escapes a backslash before a quote does not keep the string open.
+func (s *Scanner) SetSyntax(syntax Syntax) {
+	s.syntax = syntax
+}
+
+// Scan advances to the next statement, it returns false when the input is
+// exhausted or an error occurred.
+func (s *Scanner) Scan() bool {
+	if s.err != nil {
+		return false
+	}
+
+	// the byte order mark of files saved by Windows editors is not SQL
+	if s.offset == 0 && s.peekComment(byteOrderMark) {
+		n, _ := s.r.Discard(len(byteOrderMark))
+		s.offset += int64(n)
+	}
+
+	for {
+		stmt, hasCode, err := s.next()
+		if err != nil && err != io.EOF {
+			s.err = err
+			return false
+		}
+
+		// statements made of comments and whitespace only are skipped
+		if hasCode {
+			s.stmt = strings.Trim(stmt, whitespace)
+			return true
+		}
+
+		if err == io.EOF {
+			return false
+		}
+	}
+}
+
+// Statement returns the most recent statement read by Scan, without the
+// terminating delimiter.
+func (s *Scanner) Statement() string {
+	return s.stmt
+}
+
+// Line returns the line of the input where the code of the most recent
+// statement starts, leading comments excluded, the first line is 1.
+func (s *Scanner) Line() int {
+	return s.stmtLine
+}
+
+// Offset returns the byte offset of the input where the code of the most
+// recent statement starts.
+func (s *Scanner) Offset() int64 {
+	return s.stmtOffset
+}
+
+// Err returns the first non-EOF error that was encountered by the Scanner.
+func (s *Scanner) Err() error {
+	return s.err
+}
+
+const (
+	stateNormal = iota
+	stateSingleQuote
+	stateDoubleQuote
+	stateBacktick
+	stateLineComment
+	stateBlockComment
+)
+
+// next reads until the end of the next statement, hasCode reports whether
+// anything other than whitespace and comments was read.
+func (s *Scanner) next() (stmt string, hasCode bool, err error) {
+	var (
+		builder strings.Builder
+		state   = stateNormal
+	)
+	// the byte just read is the first one of the code
+	markCode := func() {
+		if !hasCode {
+			hasCode = true
+			s.stmtOffset = s.offset - 1
+			s.stmtLine = s.line
+		}
+	}
+
+	for {
+		c, err := s.readByte()
+		if err != nil {
+			return builder.String(), hasCode, err
+		}
+
+		switch state {
+		case stateNormal:
+			if c == s.delimiter[0] && s.peekComment(s.delimiter[1:]) {
+				n, _ := s.r.Discard(len(s.delimiter) - 1)
+				s.offset += int64(n)
+				return builder.String(), hasCode, nil
+			}
+
+			// DELIMITER is a client command, it must start the statement
+			if !hasCode && (c == 'D' || c == 'd') && s.peekDelimiterCommand() {
+				line, err := s.r.ReadString('\n')
+				if err != nil && err != io.EOF {
+					return "", false, err
+				}
+				s.offset += int64(len(line))
+				if strings.HasSuffix(line, "\n") {
+					s.line++
+				}
+				if delimiter := strings.TrimSpace(line[len("ELIMITER"):]); delimiter != "" {
+					s.delimiter = delimiter
+				}
+				builder.Reset()
+				if err == io.EOF {
+					return "", false, err
+				}
+				continue
+			}
+
+			switch c {
+			case '\'':
+				state = stateSingleQuote
+			case '"':
+				state = stateDoubleQuote
+			case '`':
+				state = stateBacktick
+			case '#':
+				state = stateLineComment
+			case '-':
+				if s.peekComment("- ") || s.peekComment("-\t") || s.peekComment("-\n") || s.peekComment("-\r") {
+					state = stateLineComment
+				}
+			case '/':
+				// executable comments and optimizer hints are code
+				if s.peekComment("*!") || s.peekComment("*+") {
+					markCode()
+				}
+				if s.peekComment("*") {
+					state = stateBlockComment
+					builder.WriteByte(c)
+					c, _ = s.readByte()
+				}
+			}
+			if state == stateNormal || state == stateSingleQuote || state == stateDoubleQuote || state == stateBacktick {
+				if strings.IndexByte(whitespace, c) == -1 {
+					markCode()
+				}
+			}
+		case stateSingleQuote, stateDoubleQuote:
+			quote := byte('\'')
+			if state == stateDoubleQuote {
+				quote = '"'
+			}
+			if c == '\\' && !s.syntax.NoBackslashEscapes {
+				builder.WriteByte(c)
+				c, err = s.readByte()
+				if err != nil {
+					return builder.String(), hasCode, err
+				}
+			} else if c == quote {
+				state = stateNormal
+			}
+		case stateBacktick:
+			if c == '`' {
+				state = stateNormal
+			}
+		case stateLineComment:
+			if c == '\n' {
+				state = stateNormal
+			}
+		case stateBlockComment:
+			if c == '*' && s.peekComment("/") {
+				builder.WriteByte(c)
+				c, _ = s.readByte()
+				state = stateNormal
+			}
+		}
+
+		builder.WriteByte(c)
+	}
+}
+
+// readByte reads the next byte and keeps track of the position.
+func (s *Scanner) readByte() (byte, error) {
+	c, err := s.r.ReadByte()
+	if err != nil {
+		return c, err
+	}
+	s.offset++
+	if c == '\n' {
+		s.line++
+	}
+	return c, nil
+}
+
+// peekDelimiterCommand reports whether the next bytes are the rest of a
+// DELIMITER command, the leading 'D' being already read.
+func (s *Scanner) peekDelimiterCommand() bool {
+	bs, _ := s.r.Peek(len("ELIMITER "))
+	if len(bs) < len("ELIMITER ") {
+		return false
+	}
+	return strings.EqualFold(string(bs[:len("ELIMITER")]), "ELIMITER") && (bs[len("ELIMITER")] == ' ' || bs[len("ELIMITER")] == '\t')
+}
+
+// peekComment reports whether the next bytes equal prefix without consuming them.
+func (s *Scanner) peekComment(prefix string) bool {
+	bs, _ := s.r.Peek(len(prefix))
+	return string(bs) == prefix
+}
+
+// SplitStatements splits sql into statements, see Scanner.
+func SplitStatements(sql string) ([]string, error) {
+	var stmts []string
+	scanner := NewScanner(strings.NewReader(sql))
+	for scanner.Scan() {
+		stmts = append(stmts, scanner.Statement())
+	}
+	return stmts, scanner.Err()
+}
@@ -0,0 +1,60 @@
+package sqlutil
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestScannerDelimiter(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  []string
+	}{
+		{
+			name: "trigger between DELIMITER ;;",
+			input: "DELIMITER ;;\n" +
+				"CREATE TRIGGER `t` BEFORE INSERT ON `a` FOR EACH ROW BEGIN SET NEW.x = 1; SET NEW.y = 2; END ;;\n" +
+				"DELIMITER ;\n",
+			want: []string{"CREATE TRIGGER `t` BEFORE INSERT ON `a` FOR EACH ROW BEGIN SET NEW.x = 1; SET NEW.y = 2; END"},
+		},
+		{
+			name:  "procedure between DELIMITER $$",
+			input: "DELIMITER $$\nCREATE PROCEDURE p() BEGIN SELECT 1; SELECT 2; END$$\nDELIMITER ;\n",
+			want:  []string{"CREATE PROCEDURE p() BEGIN SELECT 1; SELECT 2; END"},
+		},
+		{
+			name: "back to ;",
+			input: "SELECT 1;\nDELIMITER ;;\nSELECT 2; SELECT 3;;\nDELIMITER ;\nSELECT 4;\nSELECT 5;\n" +
+				"DELIMITER $$\nSELECT 6$$\ndelimiter ;\nSELECT 7;",
+			want: []string{"SELECT 1", "SELECT 2; SELECT 3", "SELECT 4", "SELECT 5", "SELECT 6", "SELECT 7"},
+		},
+		{
+			name:  "delimiter in strings",
+			input: "DELIMITER ;;\nINSERT INTO t VALUES (';;', \"$$;;\", 'it\\';;');;\nDELIMITER ;\nSELECT ';', `a;b`;\n",
+			want:  []string{"INSERT INTO t VALUES (';;', \"$$;;\", 'it\\';;')", "SELECT ';', `a;b`"},
+		},
+		{
+			name: "delimiter in comments",
+			input: "DELIMITER $$\nCREATE PROCEDURE p() BEGIN -- ends at $$ ?\nSELECT 1; /* $$ */ END$$\nDELIMITER ;\n" +
+				"SELECT 1 # a ; comment\n;\n",
+			want: []string{"CREATE PROCEDURE p() BEGIN -- ends at $$ ?\nSELECT 1; /* $$ */ END", "SELECT 1 # a ; comment"},
+		},
+		{
+			name:  "DELIMITER in a string is not a command",
+			input: "SELECT 'a\nDELIMITER ;;\nb';\nSELECT 2;\n",
+			want:  []string{"SELECT 'a\nDELIMITER ;;\nb'", "SELECT 2"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			stmts, err := SplitStatements(test.input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(stmts, test.want) {
+				t.Errorf("statements = %q, want %q", stmts, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,241 @@
+package sqlutil
+
+import (
+	"strings"
+)
+
+// StatementVerb returns the first keyword of stmt in upper case, leading
+// comments are ignored, eg: "-- c\ninsert into t values (1)" returns "INSERT".
+func StatementVerb(stmt string) string {
+	words := leadingWords(stmt, 1)
+	if len(words) == 0 {
+		return ""
+	}
+	return strings.ToUpper(words[0].word)
+}
+
+// IsBlank reports whether stmt contains nothing but whitespace and comments,
+// executable comments /*! ... */ are not comments.
+func IsBlank(stmt string) bool {
+	return skipSpaceAndComments(stmt) == ""
+}
+
+// IsDDL reports whether stmt is a data definition statement, such statements
+// cause an implicit commit in MySQL.
+func IsDDL(stmt string) bool {
+	switch StatementVerb(stmt) {
+	case "CREATE", "DROP", "ALTER", "TRUNCATE", "RENAME":
+		return true
+	}
+	return false
+}
+
+// StatementTable returns the unquoted name of the table targeted by an
+// INSERT, REPLACE, CREATE TABLE, DROP TABLE, ALTER TABLE or TRUNCATE TABLE
+// statement, or "" for other statements.
+func StatementTable(stmt string) string {
+	words := leadingWords(stmt, 8)
+	i := statementTableIndex(words)
+	if i == -1 {
+		return ""
+	}
+	return words[i].word
+}
+
+// RenameStatementTable returns stmt targeting table name instead, for the
+// statements of StatementTable, eg: "INSERT INTO `db`.`t` VALUES (1)" becomes
+// "INSERT INTO `db`.`name` VALUES (1)". Other statements are returned as is.
+func RenameStatementTable(stmt, name string) string {
+	words := leadingWords(stmt, 8)
+	i := statementTableIndex(words)
+	if i == -1 {
+		return stmt
+	}
+	return stmt[:words[i].start] + QuoteIdentifier(name) + stmt[words[i].end:]
+}
+
+// statementTableIndex the index of the table name in the leading words of a
+// statement, -1 if it targets no table
+func statementTableIndex(words []word) int {
+	i := 0
+	next := func() string {
+		if i >= len(words) {
+			return ""
+		}
+		i++
+		return strings.ToUpper(words[i-1].word)
+	}
+	// skip optional keywords, eg: IF NOT EXISTS, IGNORE
+	skip := func(keywords ...string) {
+		for _, keyword := range keywords {
+			if i < len(words) && strings.ToUpper(words[i].word) == keyword {
+				i++
+			}
+		}
+	}
+
+	switch next() {
+	case "INSERT", "REPLACE":
+		skip("LOW_PRIORITY", "DELAYED", "HIGH_PRIORITY", "IGNORE", "INTO")
+	case "CREATE":
+		skip("TEMPORARY")
+		if next() != "TABLE" {
+			return -1
+		}
+		skip("IF", "NOT", "EXISTS")
+	case "DROP":
+		skip("TEMPORARY")
+		if next() != "TABLE" {
+			return -1
+		}
+		skip("IF", "EXISTS")
+	case "ALTER":
+		if next() != "TABLE" {
+			return -1
+		}
+	case "TRUNCATE":
+		skip("TABLE")
+	default:
+		return -1
+	}
+
+	if i >= len(words) {
+		return -1
+	}
+	return i
+}
+
+// word a leading word of a statement and its position, [start, end)
+type word struct {
+	word       string
+	start, end int
+}
+
+// leadingWords returns at most n leading words of stmt, quoted identifiers
+// are unquoted and a qualified name like `db`.`t` yields its last part.
+func leadingWords(stmt string, n int) []word {
+	var words []word
+	s := stmt
+	for len(words) < n {
+		s = skipSpaceAndComments(s)
+		if s == "" {
+			break
+		}
+		start := len(stmt) - len(s)
+
+		var w string
+		switch {
+		case s[0] == '`':
+			end := 1
+			for ; end < len(s); end++ {
+				if s[end] != '`' {
+					continue
+				}
+				if end+1 < len(s) && s[end+1] == '`' {
+					end++
+					continue
+				}
+				break
+			}
+			if end >= len(s) {
+				return words
+			}
+			w = strings.Replace(s[1:end], "``", "`", -1)
+			s = s[end+1:]
+		case isWordByte(s[0]):
+			end := 0
+			for end < len(s) && isWordByte(s[end]) {
+				end++
+			}
+			w = s[:end]
+			s = s[end:]
+		default:
+			return words
+		}
+
+		// qualified name, keep the last part only
+		if strings.HasPrefix(s, ".") {
+			s = s[1:]
+			continue
+		}
+		words = append(words, word{word: w, start: start, end: len(stmt) - len(s)})
+	}
+	return words
+}
+
+func skipSpaceAndComments(s string) string {
+	for {
+		s = strings.TrimLeft(s, whitespace)
+		switch {
+		case strings.HasPrefix(s, "#"), isLineComment(s):
+			idx := strings.Index(s, "\n")
+			if idx == -1 {
+				return ""
+			}
+			s = s[idx+1:]
+		case strings.HasPrefix(s, "/*") && !strings.HasPrefix(s, "/*!"):
+			idx := strings.Index(s, "*/")
+			if idx == -1 {
+				return ""
+			}
+			s = s[idx+2:]
+		default:
+			return s
+		}
+	}
+}
+
+func isWordByte(c byte) bool {
+	return c == '_' || c == '$' || c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= 0x80
+}
+
+// RedactLiterals replaces the string, number and hex literals of stmt by ?,
+// eg: "INSERT INTO `t` VALUES (1, 'secret')" returns
+// "INSERT INTO `t` VALUES (?, ?)". Identifiers and comments are kept.
+func RedactLiterals(stmt string) string {
+	var builder strings.Builder
+	builder.Grow(len(stmt))
+	for i := 0; i < len(stmt); i++ {
+		c := stmt[i]
+		rest := stmt[i:]
+		switch {
+		case c == '\'' || c == '"':
+			for i++; i < len(stmt) && stmt[i] != c; i++ {
+				if stmt[i] == '\\' {
+					i++
+				}
+			}
+			builder.WriteByte('?')
+		case c == '`':
+			end := strings.IndexByte(rest[1:], '`')
+			if end == -1 {
+				return builder.String() + rest
+			}
+			builder.WriteString(rest[:end+2])
+			i += end + 1
+		case c == '#' || isLineComment(rest):
+			end := strings.Index(rest, "\n")
+			if end == -1 {
+				return builder.String() + rest
+			}
+			builder.WriteString(rest[:end+1])
+			i += end
+		case strings.HasPrefix(rest, "/*") && !strings.HasPrefix(rest, "/*!"):
+			end := strings.Index(rest[2:], "*/")
+			if end == -1 {
+				return builder.String() + rest
+			}
+			builder.WriteString(rest[:end+4])
+			i += end + 3
+		case c >= '0' && c <= '9' && (i == 0 || !isWordByte(stmt[i-1])):
+			// 0x1F, 1.5e3, 42
+			for i+1 < len(stmt) && (isWordByte(stmt[i+1]) || stmt[i+1] == '.') {
+				i++
+			}
+			builder.WriteByte('?')
+		default:
+			builder.WriteByte(c)
+		}
+	}
+	return builder.String()
+}
@@ -0,0 +1,49 @@
+package sqlutil
+
+import "strings"
+
+// Syntax the string syntax of the server the SQL is written for, the zero value is the
+// default one of MySQL where a backslash escapes the next character of a string.
+type Syntax struct {
+	// NoBackslashEscapes backslashes are ordinary characters of strings, like with the
+	// sql_mode NO_BACKSLASH_ESCAPES, eg: 'C:\new' is C:\new
+	NoBackslashEscapes bool
+}
+
+// ParseInsertValues see the ParseInsertValues func.
+func (syntax Syntax) ParseInsertValues(stmt string) (columns []string, rows [][]interface{}, err error) {
+	values, rows, err := parseInsert(stmt, syntax)
+	if err != nil {
+		return nil, nil, err
+	}
+	return values.Columns, rows, nil
+}
+
+// ParseValue see the ParseValue func.
+func (syntax Syntax) ParseValue(sql string) (interface{}, error) {
+	// parsed as the last value of a row, the ")" ends it
+	p := &valuesParser{s: strings.TrimSpace(sql) + ")", syntax: syntax}
+	value, err := p.value()
+	if err != nil {
+		return nil, err
+	}
+	if p.i != len(p.s)-1 {
+		return nil, p.errorf("unexpected %q", p.s[p.i])
+	}
+	return value, nil
+}
+
+// SplitInsertValues see the SplitInsertValues func.
+func (syntax Syntax) SplitInsertValues(stmt string) (*InsertValues, error) {
+	values, _, err := parseInsert(stmt, syntax)
+	return values, err
+}
+
+// QuoteString the string literal of s, see the QuoteString func. Without backslash
+// escapes only the quotes are doubled and backslashes are kept as is.
+func (syntax Syntax) QuoteString(s string) string {
+	if !syntax.NoBackslashEscapes {
+		return QuoteString(s)
+	}
+	return "'" + strings.Replace(s, "'", "''", -1) + "'"
+}
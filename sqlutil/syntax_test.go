@@ -0,0 +1,43 @@
+package sqlutil
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSyntaxNoBackslashEscapes(t *testing.T) {
+	syntax := Syntax{NoBackslashEscapes: true}
+
+	// the backslash before the quote ends the string, the ; after it ends the statement
+	input := `INSERT INTO t VALUES ('C:\new\'); SELECT 'it''s;'`
+	scanner := NewScanner(strings.NewReader(input))
+	scanner.SetSyntax(syntax)
+	var stmts []string
+	for scanner.Scan() {
+		stmts = append(stmts, scanner.Statement())
+	}
+	want := []string{`INSERT INTO t VALUES ('C:\new\')`, `SELECT 'it''s;'`}
+	if !reflect.DeepEqual(stmts, want) {
+		t.Fatalf("statements = %q, want %q", stmts, want)
+	}
+
+	_, rows, err := syntax.ParseInsertValues(stmts[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := rows[0][0]; got != `C:\new\` {
+		t.Errorf("value = %q, want %q", got, `C:\new\`)
+	}
+
+	value, err := syntax.ParseValue(syntax.QuoteString(`it's C:\new`))
+	if err != nil || value != `it's C:\new` {
+		t.Errorf("ParseValue(QuoteString) = %q, %v", value, err)
+	}
+
+	// the default syntax still reads the backslash as an escape
+	value, err = ParseValue(`'C:\new'`)
+	if err != nil || value != "C:\new" {
+		t.Errorf("ParseValue = %q, %v", value, err)
+	}
+}
@@ -0,0 +1,59 @@
+package sqlutil
+
+import "strings"
+
+// Placeholder the placeholder of the template variable name, eg: "env" returns "${env}".
+func Placeholder(name string) string {
+	return "${" + name + "}"
+}
+
+// ExpandPlaceholders replaces the placeholders of the variables of vars in stmt by their
+// values, escaped for where they are: in a string literal, in a quoted identifier or in
+// the statement itself, eg: "USE `${db}`" returns "USE `shop`" for db=shop. Placeholders
+// of other names are kept, $${ is the escape of a literal ${.
+func ExpandPlaceholders(stmt string, vars map[string]string) string {
+	if !strings.Contains(stmt, "${") {
+		return stmt
+	}
+
+	var builder strings.Builder
+	builder.Grow(len(stmt))
+	var quote byte
+	for i := 0; i < len(stmt); i++ {
+		c := stmt[i]
+		switch {
+		case quote == 0 && (c == '\'' || c == '"' || c == '`'):
+			quote = c
+		case quote == c:
+			quote = 0
+		case quote != 0 && quote != '`' && c == '\\' && i+1 < len(stmt):
+			builder.WriteByte(c)
+			i++
+			c = stmt[i]
+		case c == '$' && strings.HasPrefix(stmt[i:], "$${"):
+			builder.WriteString("${")
+			i += 2
+			continue
+		case c == '$' && strings.HasPrefix(stmt[i:], "${"):
+			end := strings.IndexByte(stmt[i:], '}')
+			if end == -1 {
+				break
+			}
+			value, ok := vars[stmt[i+2:i+end]]
+			if !ok {
+				break
+			}
+			switch quote {
+			case '\'', '"':
+				value = EscapeString(value)
+			case '`':
+				value = strings.Replace(value, "`", "``", -1)
+			}
+			builder.WriteString(value)
+			i += end
+			continue
+		}
+		builder.WriteByte(c)
+	}
+	return builder.String()
+}
@@ -0,0 +1,484 @@
+package sqlutil
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrNoValues the INSERT statement has no VALUES list, eg: INSERT ... SELECT
+var ErrNoValues = errors.New("no VALUES in statement")
+
+// Expression a value of an INSERT statement that is not a literal, eg: "NOW()", as
+// written in the statement.
+type Expression string
+
+// ParseInsertValues returns the column list and the rows of an INSERT or
+// REPLACE ... VALUES statement. The values are nil for NULL, bool, json.Number
+// for numbers, string for strings, []byte for hex, bit and _binary strings, and
+// Expression for anything else. columns is nil when the statement has no column
+// list, eg: "INSERT INTO `t` (`a`,`b`) VALUES (1,'x'),(2,NULL)" returns
+// [a b] and [[1 x] [2 <nil>]].
+func ParseInsertValues(stmt string) (columns []string, rows [][]interface{}, err error) {
+	return Syntax{}.ParseInsertValues(stmt)
+}
+
+// ParseValue returns the value of the SQL of a value of SplitInsertValues,
+// typed like the values of ParseInsertValues, eg: `'O\'Brien'` returns "O'Brien".
+func ParseValue(sql string) (interface{}, error) {
+	return Syntax{}.ParseValue(sql)
+}
+
+// InsertValues an INSERT or REPLACE ... VALUES statement split into its parts,
+// the values are kept as written, see SplitInsertValues
+type InsertValues struct {
+	// Head the statement up to the column list, eg: "INSERT IGNORE INTO `t`"
+	Head string
+	// Columns the column list, nil when the statement has none
+	Columns []string
+	// Rows the SQL of the values of each row, eg: "'it''s'" or "NOW()"
+	Rows [][]string
+	// Tail what follows the rows, eg: "ON DUPLICATE KEY UPDATE `a` = VALUES(`a`)"
+	Tail string
+}
+
+// SplitInsertValues returns the parts of an INSERT or REPLACE ... VALUES
+// statement, eg: to add, remove or rewrite columns and put it back together
+// with String.
+func SplitInsertValues(stmt string) (*InsertValues, error) {
+	return Syntax{}.SplitInsertValues(stmt)
+}
+
+// String the statement of the parts, without a trailing semicolon
+func (v *InsertValues) String() string {
+	var builder strings.Builder
+	builder.WriteString(strings.TrimSpace(v.Head))
+	if v.Columns != nil {
+		builder.WriteString(" (")
+		builder.WriteString(QuoteIdentifiers(v.Columns))
+		builder.WriteString(")")
+	}
+	builder.WriteString(" VALUES ")
+	for i, row := range v.Rows {
+		if i > 0 {
+			builder.WriteString(",")
+		}
+		builder.WriteString("(")
+		builder.WriteString(strings.Join(row, ","))
+		builder.WriteString(")")
+	}
+	if v.Tail != "" {
+		builder.WriteString(" ")
+		builder.WriteString(v.Tail)
+	}
+	return builder.String()
+}
+
+func parseInsert(stmt string, syntax Syntax) (*InsertValues, [][]interface{}, error) {
+	p := &valuesParser{s: stmt, syntax: syntax}
+	columns, head, err := p.header()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	values := &InsertValues{Head: stmt[:head], Columns: columns}
+	var rows [][]interface{}
+	for {
+		p.skipSpace()
+		if !p.consume('(') {
+			return nil, nil, p.errorf("expected (")
+		}
+		row, raw, err := p.tuple()
+		if err != nil {
+			return nil, nil, err
+		}
+		rows = append(rows, row)
+		values.Rows = append(values.Rows, raw)
+
+		p.skipSpace()
+		if !p.consume(',') {
+			break
+		}
+	}
+
+	// ON DUPLICATE KEY UPDATE and row aliases follow the rows
+	p.skipSpace()
+	if p.i < len(p.s) && p.s[p.i] != ';' && !isWordByte(p.s[p.i]) {
+		return nil, nil, p.errorf("unexpected %q", p.s[p.i])
+	}
+	values.Tail = strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(p.s[p.i:]), ";"))
+	return values, rows, nil
+}
+
+type valuesParser struct {
+	s      string
+	i      int
+	syntax Syntax
+}
+
+func (p *valuesParser) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("offset %d: %s", p.i, fmt.Sprintf(format, args...))
+}
+
+func (p *valuesParser) skipSpace() {
+	rest := skipSpaceAndComments(p.s[p.i:])
+	p.i = len(p.s) - len(rest)
+}
+
+func (p *valuesParser) consume(c byte) bool {
+	if p.i < len(p.s) && p.s[p.i] == c {
+		p.i++
+		return true
+	}
+	return false
+}
+
+// header skip the statement up to its VALUES keyword, the last parenthesized group
+// before it is the column list, a PARTITION list excepted. head is the end of the
+// statement before the column list.
+func (p *valuesParser) header() (columns []string, head int, err error) {
+	var previous string
+	// VALUE is not reserved, it may be the name of the table, eg: INSERT INTO db.value VALUES
+	table, dot := false, false
+	for {
+		p.skipSpace()
+		if p.i >= len(p.s) {
+			return nil, 0, ErrNoValues
+		}
+
+		switch c := p.s[p.i]; {
+		case c == '(':
+			start := p.i
+			p.i++
+			names, err := p.identifiers()
+			if err != nil {
+				return nil, 0, err
+			}
+			if !strings.EqualFold(previous, "PARTITION") {
+				columns = names
+				head = start
+			}
+			previous = ""
+		case c == '`' || c == '"':
+			_, err := p.identifier()
+			if err != nil {
+				return nil, 0, err
+			}
+			previous = ""
+			table = true
+		case isWordByte(c):
+			start := p.i
+			for p.i < len(p.s) && isWordByte(p.s[p.i]) {
+				p.i++
+			}
+			previous = p.s[start:p.i]
+			if table && !dot && (strings.EqualFold(previous, "VALUES") || strings.EqualFold(previous, "VALUE")) {
+				if columns == nil {
+					head = start
+				}
+				return columns, head, nil
+			}
+			if strings.EqualFold(previous, "SELECT") || strings.EqualFold(previous, "SET") {
+				return nil, 0, ErrNoValues
+			}
+			table = table || !insertModifiers[strings.ToUpper(previous)]
+		default:
+			// the dot of a qualified name
+			dot = p.s[p.i] == '.'
+			p.i++
+			continue
+		}
+		dot = false
+	}
+}
+
+// insertModifiers the words of an INSERT or REPLACE before the table
+var insertModifiers = map[string]bool{
+	"INSERT":        true,
+	"REPLACE":       true,
+	"LOW_PRIORITY":  true,
+	"DELAYED":       true,
+	"HIGH_PRIORITY": true,
+	"IGNORE":        true,
+	"INTO":          true,
+}
+
+// identifiers a comma separated list of identifiers up to the closing parenthesis
+func (p *valuesParser) identifiers() ([]string, error) {
+	var names []string
+	for {
+		p.skipSpace()
+		name, err := p.identifier()
+		if err != nil {
+			return nil, err
+		}
+		names = append(names, name)
+
+		p.skipSpace()
+		if p.consume(')') {
+			return names, nil
+		}
+		if !p.consume(',') {
+			return nil, p.errorf("expected , or )")
+		}
+	}
+}
+
+func (p *valuesParser) identifier() (string, error) {
+	if p.i >= len(p.s) {
+		return "", p.errorf("expected an identifier")
+	}
+	if q := p.s[p.i]; q == '`' || q == '"' {
+		return p.quoted(q, false)
+	}
+	start := p.i
+	for p.i < len(p.s) && isWordByte(p.s[p.i]) {
+		p.i++
+	}
+	if start == p.i {
+		return "", p.errorf("expected an identifier")
+	}
+	return p.s[start:p.i], nil
+}
+
+// quoted the content of the quoted string or identifier at p.i, doubled quotes stand for
+// one quote and, in strings, backslashes escape the next character like the server does
+// unless the syntax has no backslash escapes
+func (p *valuesParser) quoted(q byte, backslash bool) (string, error) {
+	start := p.i
+	p.i++
+	var builder strings.Builder
+	for p.i < len(p.s) {
+		c := p.s[p.i]
+		p.i++
+		switch {
+		case c == '\\' && backslash && !p.syntax.NoBackslashEscapes && p.i < len(p.s):
+			c = p.s[p.i]
+			p.i++
+			switch c {
+			case '0':
+				builder.WriteByte(0)
+			case 'b':
+				builder.WriteByte('\b')
+			case 'n':
+				builder.WriteByte('\n')
+			case 'r':
+				builder.WriteByte('\r')
+			case 't':
+				builder.WriteByte('\t')
+			case 'Z':
+				builder.WriteByte('\x1a')
+			case '%', '_':
+				// kept escaped for LIKE patterns
+				builder.WriteByte('\\')
+				builder.WriteByte(c)
+			default:
+				builder.WriteByte(c)
+			}
+		case c == q && p.i < len(p.s) && p.s[p.i] == q:
+			builder.WriteByte(q)
+			p.i++
+		case c == q:
+			return builder.String(), nil
+		default:
+			builder.WriteByte(c)
+		}
+	}
+	p.i = start
+	return "", p.errorf("unterminated %c", q)
+}
+
+// tuple the values of a row up to the closing parenthesis, and their SQL
+func (p *valuesParser) tuple() ([]interface{}, []string, error) {
+	var row []interface{}
+	var raw []string
+	p.skipSpace()
+	if p.consume(')') {
+		return row, raw, nil
+	}
+	for {
+		p.skipSpace()
+		start := p.i
+		value, err := p.value()
+		if err != nil {
+			return nil, nil, err
+		}
+		row = append(row, value)
+		raw = append(raw, strings.TrimSpace(p.s[start:p.i]))
+
+		p.skipSpace()
+		if p.consume(')') {
+			return row, raw, nil
+		}
+		if !p.consume(',') {
+			return nil, nil, p.errorf("expected , or )")
+		}
+	}
+}
+
+func (p *valuesParser) value() (interface{}, error) {
+	start := p.i
+	rest := p.s[p.i:]
+	if rest == "" {
+		return nil, p.errorf("expected a value")
+	}
+
+	var value interface{}
+	switch c := rest[0]; {
+	case c == '\'' || c == '"':
+		s, err := p.quoted(c, true)
+		if err != nil {
+			return nil, err
+		}
+		value = s
+	case c == '_' && p.introducer():
+		charset := strings.ToLower(p.s[start+1 : p.i])
+		p.skipSpace()
+		s, err := p.quoted(p.s[p.i], true)
+		if err != nil {
+			return nil, err
+		}
+		if charset == "binary" {
+			value = []byte(s)
+		} else {
+			value = s
+		}
+	case (c == 'x' || c == 'X') && len(rest) > 1 && rest[1] == '\'':
+		p.i++
+		s, err := p.quoted('\'', false)
+		if err != nil {
+			return nil, err
+		}
+		bs, err := hex.DecodeString(s)
+		if err != nil {
+			return nil, p.errorf("%v", err)
+		}
+		value = bs
+	case c == '0' && len(rest) > 1 && (rest[1] == 'x' || rest[1] == 'X'):
+		end := 2
+		for end < len(rest) && isHexByte(rest[end]) {
+			end++
+		}
+		s := rest[2:end]
+		if len(s)%2 == 1 {
+			s = "0" + s
+		}
+		bs, err := hex.DecodeString(s)
+		if err != nil {
+			return nil, p.errorf("%v", err)
+		}
+		p.i += end
+		value = bs
+	case (c == 'b' || c == 'B') && len(rest) > 1 && rest[1] == '\'':
+		p.i++
+		s, err := p.quoted('\'', false)
+		if err != nil {
+			return nil, err
+		}
+		bs, err := bitBytes(s)
+		if err != nil {
+			return nil, p.errorf("%v", err)
+		}
+		value = bs
+	case c == '-' || c == '+' || c == '.' || c >= '0' && c <= '9':
+		end := 0
+		if c == '-' || c == '+' {
+			end++
+		}
+		for end < len(rest) && (rest[end] >= '0' && rest[end] <= '9' || rest[end] == '.' ||
+			rest[end] == 'e' || rest[end] == 'E' || (rest[end] == '-' || rest[end] == '+') && (rest[end-1] == 'e' || rest[end-1] == 'E')) {
+			end++
+		}
+		p.i += end
+		value = json.Number(rest[:end])
+	default:
+		end := 0
+		for end < len(rest) && isWordByte(rest[end]) {
+			end++
+		}
+		switch strings.ToUpper(rest[:end]) {
+		case "NULL":
+			p.i += end
+			return nil, nil
+		case "TRUE":
+			p.i += end
+			return true, nil
+		case "FALSE":
+			p.i += end
+			return false, nil
+		}
+	}
+
+	// a literal followed by more than a separator is part of an expression, eg: 1 + 1
+	p.skipSpace()
+	if value != nil && p.i < len(p.s) && (p.s[p.i] == ',' || p.s[p.i] == ')') {
+		return value, nil
+	}
+	p.i = start
+	return p.expression()
+}
+
+// introducer a charset introducer at p.i, eg: _utf8mb4 or _binary, followed by a string
+func (p *valuesParser) introducer() bool {
+	end := p.i + 1
+	for end < len(p.s) && isWordByte(p.s[end]) {
+		end++
+	}
+	rest := skipSpaceAndComments(p.s[end:])
+	if rest == "" || rest[0] != '\'' && rest[0] != '"' {
+		return false
+	}
+	p.i = end
+	return true
+}
+
+// expression the text of the value at p.i up to the next separator of the tuple
+func (p *valuesParser) expression() (interface{}, error) {
+	start := p.i
+	depth := 0
+	for p.i < len(p.s) {
+		switch c := p.s[p.i]; c {
+		case '\'', '"', '`':
+			_, err := p.quoted(c, c != '`')
+			if err != nil {
+				return nil, err
+			}
+			continue
+		case '(':
+			depth++
+		case ')':
+			if depth == 0 {
+				return Expression(strings.TrimSpace(p.s[start:p.i])), nil
+			}
+			depth--
+		case ',':
+			if depth == 0 {
+				return Expression(strings.TrimSpace(p.s[start:p.i])), nil
+			}
+		}
+		p.i++
+	}
+	return nil, p.errorf("unterminated row")
+}
+
+func isHexByte(c byte) bool {
+	return c >= '0' && c <= '9' || c >= 'a' && c <= 'f' || c >= 'A' && c <= 'F'
+}
+
+// bitBytes the big endian bytes of a bit literal, eg: "1000000001" -> 0x02 0x01
+func bitBytes(bits string) ([]byte, error) {
+	bs := make([]byte, (len(bits)+7)/8)
+	for i := 0; i < len(bits); i++ {
+		bit := len(bits) - 1 - i
+		switch bits[i] {
+		case '1':
+			bs[len(bs)-1-bit/8] |= 1 << uint(bit%8)
+		case '0':
+		default:
+			return nil, fmt.Errorf("invalid bit literal %q", bits)
+		}
+	}
+	return bs, nil
+}
@@ -0,0 +1,187 @@
+package mysqldump
+
+import (
+	"context"
+	"database/sql"
+	"log"
+)
+
+// TableSchema a dumped table, see DumpTables
+type TableSchema struct {
+	DB   string
+	Name string
+	// DDL the CREATE TABLE statement of the table
+	DDL     string
+	Columns []TableColumn
+	// PrimaryKey the primary key columns of the table, empty if it has none
+	PrimaryKey []string
+}
+
+// TableColumn a column of a TableSchema
+type TableColumn struct {
+	Name string
+	// Type the column type, eg: "int unsigned" or "varchar(64)"
+	Type     string
+	Nullable bool
+}
+
+// TableStream the tables and rows of DumpTables, read it like sql.Rows:
+//
+//	for stream.Next() {
+//		if table := stream.Table(); table != nil {
+//			...
+//		} else {
+//			row := stream.Row()
+//			...
+//		}
+//	}
+//	err = stream.Err()
+type TableStream struct {
+	cancel context.CancelFunc
+	items  chan streamItem
+	done   chan struct{}
+	err    error
+
+	table *TableSchema
+	row   *Row
+}
+
+type streamItem struct {
+	table *TableSchema
+	row   *Row
+}
+
+// DumpTables the selected tables as Go values instead of SQL: the TableSchema of each
+// table followed by its rows, eg: to serialize them in a format of your own. Dump writes
+// the same tables and rows as SQL. WithDBs, WithAllDatabases, WithTables, WithAllTables
+// and WithWhere select the rows, WithRowTransformer applies. The dump advances as the
+// stream is read, cancelling ctx or closing the stream stops it.
+func DumpTables(ctx context.Context, dns string, opts ...DumpOption) *TableStream {
+	var o dumpOption
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	s := &TableStream{
+		cancel: cancel,
+		items:  make(chan streamItem),
+		done:   make(chan struct{}),
+	}
+
+	send := func(item streamItem) error {
+		select {
+		case s.items <- item:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	go func() {
+		defer close(s.done)
+		defer close(s.items)
+
+		err := forEachTable(dns, &o, func(db dbConn, dbName, table string) error {
+			schema, err := loadTableSchemaValue(db, dbName, table)
+			if err != nil {
+				return err
+			}
+			err = send(streamItem{table: schema})
+			if err != nil {
+				return err
+			}
+
+			return scanRows(db, table, &o, func(columnTypes []*sql.ColumnType, row []interface{}) error {
+				columns := make([]string, len(columnTypes))
+				for i, columnType := range columnTypes {
+					columns[i] = columnType.Name()
+				}
+
+				rows, err := o.transformRow(table, columns, row)
+				if err != nil {
+					return err
+				}
+
+				for _, row := range rows {
+					r := &Row{
+						DB:         dbName,
+						Table:      table,
+						Columns:    columns,
+						Values:     make([]interface{}, len(columnTypes)),
+						PrimaryKey: schema.PrimaryKey,
+					}
+					for i, col := range row {
+						r.Values[i] = jsonValue(columnTypes[i], col)
+					}
+					err = send(streamItem{row: r})
+					if err != nil {
+						return err
+					}
+				}
+				return nil
+			})
+		})
+		if err != nil {
+			log.Printf("[error] %v \n", err)
+		}
+		s.err = err
+	}()
+	return s
+}
+
+// Next advance to the next table or row, false at the end of the dump or on error
+func (s *TableStream) Next() bool {
+	item, ok := <-s.items
+	s.table, s.row = item.table, item.row
+	return ok
+}
+
+// Table the current table, nil if the current item is a row
+func (s *TableStream) Table() *TableSchema {
+	return s.table
+}
+
+// Row the current row, nil if the current item is a table. Its values are typed like
+// the ones of DumpToSink.
+func (s *TableStream) Row() *Row {
+	return s.row
+}
+
+// Err the error that ended the stream once Next returned false, nil at the end of the dump
+func (s *TableStream) Err() error {
+	<-s.done
+	return s.err
+}
+
+// Close stop the dump if it is still running and wait for it
+func (s *TableStream) Close() error {
+	s.cancel()
+	for range s.items {
+	}
+	<-s.done
+	return nil
+}
+
+func loadTableSchemaValue(db dbConn, dbName, table string) (*TableSchema, error) {
+	ddl, err := getCreateTableSQL(db, table)
+	if err != nil {
+		return nil, err
+	}
+	schema := &TableSchema{DB: dbName, Name: table, DDL: ddl}
+
+	err = queryRows(db, "SELECT COLUMN_NAME, COLUMN_TYPE, IS_NULLABLE FROM information_schema.COLUMNS "+
+		"WHERE TABLE_SCHEMA = ? AND TABLE_NAME = ? ORDER BY ORDINAL_POSITION",
+		[]interface{}{dbName, table}, func(values []string) {
+			schema.Columns = append(schema.Columns, TableColumn{Name: values[0], Type: values[1], Nullable: values[2] == "YES"})
+		})
+	if err != nil {
+		return nil, err
+	}
+
+	schema.PrimaryKey, err = getPrimaryKeyColumns(db, dbName, table)
+	if err != nil {
+		return nil, err
+	}
+	return schema, nil
+}
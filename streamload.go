@@ -0,0 +1,151 @@
+package mysqldump
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// StreamLoadConfig the target of a Doris or StarRocks stream load sink
+type StreamLoadConfig struct {
+	// URL of the frontend http server, eg: http://127.0.0.1:8030
+	URL      string
+	User     string
+	Password string
+	// DB target database, the database of the dumped rows by default
+	DB string
+	// BatchRows rows per load request, 10000 by default
+	BatchRows int
+	// Client http.DefaultClient by default
+	Client *http.Client
+}
+
+type streamLoadSink struct {
+	config StreamLoadConfig
+	client *http.Client
+
+	db    string
+	table string
+	rows  int
+	body  bytes.Buffer
+	batch int
+}
+
+// NewStreamLoadSink a RowSink loading rows into Apache Doris or StarRocks through their
+// HTTP stream load API, rows are sent as JSON lines in batches of BatchRows per table
+func NewStreamLoadSink(config StreamLoadConfig) RowSink {
+	if config.BatchRows <= 0 {
+		config.BatchRows = 10000
+	}
+
+	client := config.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	// the frontend redirects to a backend, the credentials must follow
+	redirectClient := *client
+	redirectClient.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		if len(via) >= 10 {
+			return fmt.Errorf("stopped after %d redirects", len(via))
+		}
+		req.SetBasicAuth(config.User, config.Password)
+		return nil
+	}
+
+	return &streamLoadSink{
+		config: config,
+		client: &redirectClient,
+	}
+}
+
+func (s *streamLoadSink) WriteRow(row Row) error {
+	db := s.config.DB
+	if db == "" {
+		db = row.DB
+	}
+
+	if s.rows > 0 && (db != s.db || row.Table != s.table) {
+		err := s.flush()
+		if err != nil {
+			return err
+		}
+	}
+	s.db = db
+	s.table = row.Table
+
+	line, err := json.Marshal(row.Map())
+	if err != nil {
+		return err
+	}
+	s.body.Write(line)
+	s.body.WriteByte('\n')
+	s.rows++
+
+	if s.rows >= s.config.BatchRows {
+		return s.flush()
+	}
+	return nil
+}
+
+func (s *streamLoadSink) Close() error {
+	return s.flush()
+}
+
+type streamLoadResponse struct {
+	Status   string `json:"Status"`
+	Message  string `json:"Message"`
+	ErrorURL string `json:"ErrorURL"`
+}
+
+func (s *streamLoadSink) flush() error {
+	if s.rows == 0 {
+		return nil
+	}
+	defer func() {
+		s.body.Reset()
+		s.rows = 0
+	}()
+
+	s.batch++
+	url := fmt.Sprintf("%s/api/%s/%s/_stream_load", strings.TrimSuffix(s.config.URL, "/"), s.db, s.table)
+	req, err := http.NewRequest(http.MethodPut, url, bytes.NewReader(s.body.Bytes()))
+	if err != nil {
+		return err
+	}
+	req.SetBasicAuth(s.config.User, s.config.Password)
+	req.Header.Set("Expect", "100-continue")
+	req.Header.Set("format", "json")
+	req.Header.Set("read_json_by_line", "true")
+	// labels must be unique per load
+	req.Header.Set("label", fmt.Sprintf("mysqldump_%s_%s_%d_%d", s.db, s.table, time.Now().UnixNano(), s.batch))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("stream load %s.%s: %s: %s", s.db, s.table, resp.Status, body)
+	}
+
+	var result streamLoadResponse
+	err = json.Unmarshal(body, &result)
+	if err != nil {
+		return fmt.Errorf("stream load %s.%s: %v: %s", s.db, s.table, err, body)
+	}
+	if result.Status != "Success" && result.Status != "Publish Timeout" {
+		return fmt.Errorf("stream load %s.%s: %s %s %s", s.db, s.table, result.Status, result.Message, result.ErrorURL)
+	}
+	return nil
+}
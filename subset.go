@@ -0,0 +1,233 @@
+package mysqldump
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"mysqldump/sqlutil"
+)
+
+// subsetBatchSize key tuples per IN list when fetching the rows of a subset
+const subsetBatchSize = 500
+
+// WithSubset export a referentially intact slice of the data starting from the rows of
+// table matching where, eg: WithSubset("users", "id IN (1, 2, 3)"). Table is in the first
+// database of WithDBs (the database of dns by default) unless qualified as "db.table".
+// The foreign keys are followed from the selected rows to the rows they reference
+// (recursively) and to the rows referencing them (recursively, each with the rows it
+// references), the data of the other tables is skipped. Can be given several times,
+// replaces WithWhere.
+func WithSubset(table, where string) DumpOption {
+	return func(option *dumpOption) {
+		option.subsetSeeds = append(option.subsetSeeds, subsetSeed{table: table, where: where})
+	}
+}
+
+type subsetSeed struct {
+	table string
+	where string
+}
+
+// foreignKey the columns of child referencing the columns of parent, tables are db.table
+type foreignKey struct {
+	child         string
+	childColumns  []string
+	parent        string
+	parentColumns []string
+}
+
+func loadForeignKeys(db dbConn, dbs []string) ([]foreignKey, error) {
+	in := "(" + strings.TrimSuffix(strings.Repeat("?,", len(dbs)), ",") + ")"
+	args := make([]interface{}, len(dbs))
+	for i, dbName := range dbs {
+		args[i] = dbName
+	}
+
+	var fks []foreignKey
+	var constraint string
+	err := queryRows(db, "SELECT TABLE_SCHEMA, TABLE_NAME, CONSTRAINT_NAME, COLUMN_NAME, "+
+		"REFERENCED_TABLE_SCHEMA, REFERENCED_TABLE_NAME, REFERENCED_COLUMN_NAME FROM information_schema.KEY_COLUMN_USAGE "+
+		"WHERE REFERENCED_TABLE_NAME IS NOT NULL AND TABLE_SCHEMA IN "+in+
+		" ORDER BY TABLE_SCHEMA, TABLE_NAME, CONSTRAINT_NAME, ORDINAL_POSITION", args, func(values []string) {
+		child := values[0] + "." + values[1]
+		if name := child + "." + values[2]; name != constraint {
+			constraint = name
+			fks = append(fks, foreignKey{child: child, parent: values[4] + "." + values[5]})
+		}
+		fk := &fks[len(fks)-1]
+		fk.childColumns = append(fk.childColumns, values[3])
+		fk.parentColumns = append(fk.parentColumns, values[6])
+	})
+	if err != nil {
+		return nil, err
+	}
+	return fks, nil
+}
+
+// subsetFetch the rows of table whose columns match one of tuples, down also follows the
+// foreign keys referencing the rows
+type subsetFetch struct {
+	table   string
+	columns []string
+	tuples  []string
+	down    bool
+}
+
+type subsetLoader struct {
+	db  dbConn
+	fks []foreignKey
+	// conditions the union of the conditions of each table is its subset
+	conditions map[string][]string
+	visited    map[string]bool
+	pending    map[string]*subsetFetch
+}
+
+// loadSubset the WHERE condition selecting the subset rows of each table, keyed by db.table,
+// tables without rows in the subset are missing
+func loadSubset(db dbConn, dbs []string, seeds []subsetSeed) (map[string]string, error) {
+	fks, err := loadForeignKeys(db, dbs)
+	if err != nil {
+		return nil, err
+	}
+
+	l := &subsetLoader{
+		db:         db,
+		fks:        fks,
+		conditions: make(map[string][]string),
+		visited:    make(map[string]bool),
+		pending:    make(map[string]*subsetFetch),
+	}
+
+	for _, seed := range seeds {
+		table := seed.table
+		if !strings.Contains(table, ".") {
+			table = dbs[0] + "." + table
+		}
+		l.conditions[table] = append(l.conditions[table], "("+seed.where+")")
+		err = l.fetch(table, seed.where, true)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	for len(l.pending) > 0 {
+		pending := l.pending
+		l.pending = make(map[string]*subsetFetch)
+		for _, f := range pending {
+			for start := 0; start < len(f.tuples); start += subsetBatchSize {
+				end := start + subsetBatchSize
+				if end > len(f.tuples) {
+					end = len(f.tuples)
+				}
+				condition := inCondition(f.columns, f.tuples[start:end])
+				l.conditions[f.table] = append(l.conditions[f.table], condition)
+				err = l.fetch(f.table, condition, f.down)
+				if err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+
+	wheres := make(map[string]string, len(l.conditions))
+	for table, conditions := range l.conditions {
+		wheres[table] = strings.Join(conditions, " OR ")
+	}
+	return wheres, nil
+}
+
+// fetch read the rows of table matching where and queue the rows they reference, and
+// with down the rows referencing them
+func (l *subsetLoader) fetch(table, where string, down bool) error {
+	rows, err := l.db.Query(fmt.Sprintf("SELECT * FROM %s WHERE %s", qualifiedName(table), where)) // ignore_security_alert_wait_for_fix SQL
+	if err != nil {
+		return fmt.Errorf("subset of %s: %v", table, err)
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return err
+	}
+
+	for rows.Next() {
+		values := make([]sql.NullString, len(columns))
+		pointers := make([]interface{}, len(columns))
+		for i := range values {
+			pointers[i] = &values[i]
+		}
+		err = rows.Scan(pointers...)
+		if err != nil {
+			return err
+		}
+		row := make(map[string]sql.NullString, len(columns))
+		for i, column := range columns {
+			row[column] = values[i]
+		}
+
+		for _, fk := range l.fks {
+			if fk.child == table {
+				l.add(fk.parent, fk.parentColumns, row, fk.childColumns, false)
+			}
+			if down && fk.parent == table {
+				l.add(fk.child, fk.childColumns, row, fk.parentColumns, true)
+			}
+		}
+	}
+	return rows.Err()
+}
+
+// add queue the rows of table whose columns equal the rowColumns of row
+func (l *subsetLoader) add(table string, columns []string, row map[string]sql.NullString, rowColumns []string, down bool) {
+	literals := make([]string, len(rowColumns))
+	for i, column := range rowColumns {
+		value := row[column]
+		// NULL references nothing
+		if !value.Valid {
+			return
+		}
+		literals[i] = sqlutil.QuoteString(value.String)
+	}
+	tuple := "(" + strings.Join(literals, ",") + ")"
+
+	key := table + "\x00" + strings.Join(columns, ",") + "\x00" + tuple
+	// the rows fetched down already had their references followed
+	if l.visited[key+"\x00down"] || !down && l.visited[key] {
+		return
+	}
+	if down {
+		key += "\x00down"
+	}
+	l.visited[key] = true
+
+	fetchKey := fmt.Sprintf("%s\x00%s\x00%t", table, strings.Join(columns, ","), down)
+	f, ok := l.pending[fetchKey]
+	if !ok {
+		f = &subsetFetch{table: table, columns: columns, down: down}
+		l.pending[fetchKey] = f
+	}
+	f.tuples = append(f.tuples, tuple)
+}
+
+// inCondition (`a`,`b`) IN (('1','2'),('3','4'))
+func inCondition(columns []string, tuples []string) string {
+	return "(" + sqlutil.QuoteIdentifiers(columns) + ") IN (" + strings.Join(tuples, ",") + ")"
+}
+
+// qualifiedName `db`.`table` of db.table
+func qualifiedName(table string) string {
+	idx := strings.Index(table, ".")
+	return sqlutil.QuoteIdentifier(table[:idx]) + "." + sqlutil.QuoteIdentifier(table[idx+1:])
+}
+
+// dataWhere the WHERE condition of the data of table, false when its data is skipped
+func (o *dumpOption) dataWhere(dbName, table string) (string, bool) {
+	if o.subset == nil {
+		return o.where, true
+	}
+	where, ok := o.subset[dbName+"."+table]
+	return where, ok
+}
@@ -0,0 +1,36 @@
+package mysqldump
+
+import (
+	"mysqldump/sqlutil"
+)
+
+// WithTableMap load the tables of the dump into other tables, eg: {"orders":
+// "orders_restored_2024"} to compare them side by side with the live ones. The INSERT,
+// REPLACE, CREATE, DROP, ALTER and TRUNCATE TABLE statements are rewritten, the references
+// to the table elsewhere (foreign keys, views, triggers) are not. The names of the
+// foreign keys of a mapped CREATE TABLE must not exist in the database.
+func WithTableMap(tables map[string]string) SourceOption {
+	mapping := make(map[string]string, len(tables))
+	for from, to := range tables {
+		mapping[from] = to
+	}
+	return func(o *sourceOption) {
+		o.tableMap = mapping
+	}
+}
+
+// mapTable rewrite the target table of dml with WithTableMap or for RestoreShadow, ""
+// skips the statement
+func (o *sourceOption) mapTable(dml string) string {
+	if o.shadow != nil {
+		return o.shadow.rewrite(dml)
+	}
+	if len(o.tableMap) == 0 {
+		return dml
+	}
+	target, ok := o.tableMap[sqlutil.StatementTable(dml)]
+	if !ok {
+		return dml
+	}
+	return sqlutil.RenameStatementTable(dml, target)
+}
@@ -0,0 +1,34 @@
+package mysqldump
+
+import "testing"
+
+func TestMapTable(t *testing.T) {
+	o := &sourceOption{}
+	WithTableMap(map[string]string{
+		"order":  "order_restored",
+		"a`b":    "c`d",
+		"select": "order by",
+	})(o)
+
+	tests := []struct {
+		dml  string
+		want string
+	}{
+		{"INSERT INTO `order` VALUES (1,'a'),(2,'b')", "INSERT INTO `order_restored` VALUES (1,'a'),(2,'b')"},
+		{"INSERT IGNORE INTO `shop`.`order` (`id`) VALUES (1),(2)", "INSERT IGNORE INTO `shop`.`order_restored` (`id`) VALUES (1),(2)"},
+		{"REPLACE INTO `a``b` VALUES ('`order`'),('order')", "REPLACE INTO `c``d` VALUES ('`order`'),('order')"},
+		{"INSERT INTO `select` VALUES (1)", "INSERT INTO `order by` VALUES (1)"},
+		{"CREATE TABLE IF NOT EXISTS `order` (`id` int)", "CREATE TABLE IF NOT EXISTS `order_restored` (`id` int)"},
+		{"DROP TABLE IF EXISTS `order`", "DROP TABLE IF EXISTS `order_restored`"},
+		{"ALTER TABLE `order` ADD KEY `k` (`id`)", "ALTER TABLE `order_restored` ADD KEY `k` (`id`)"},
+		{"TRUNCATE TABLE `order`", "TRUNCATE TABLE `order_restored`"},
+		{"INSERT INTO `orders` VALUES (1)", "INSERT INTO `orders` VALUES (1)"},
+		{"INSERT INTO `other` VALUES ('order'),(1)", "INSERT INTO `other` VALUES ('order'),(1)"},
+		{"CREATE VIEW `order` AS SELECT 1", "CREATE VIEW `order` AS SELECT 1"},
+	}
+	for _, test := range tests {
+		if got := o.mapTable(test.dml); got != test.want {
+			t.Errorf("mapTable(%s) = %s, want %s", test.dml, got, test.want)
+		}
+	}
+}
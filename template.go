@@ -0,0 +1,63 @@
+package mysqldump
+
+import (
+	"sort"
+	"strings"
+
+	"mysqldump/sqlutil"
+)
+
+// WithTemplateVars replace the values of vars, name -> literal, by the placeholder
+// ${name} in the database names and the text values of the dump, eg: {"env": "prod"}
+// writes 'https://${env}.example.com' for 'https://prod.example.com'. Source expands the
+// placeholders with WithSourceTemplateVars, the longest literal wins when several match.
+// A ${ already in the DDL or the values is written $${ so that it is not expanded.
+func WithTemplateVars(vars map[string]string) DumpOption {
+	return func(o *dumpOption) {
+		names := make([]string, 0, len(vars))
+		for name, value := range vars {
+			if value != "" {
+				names = append(names, name)
+			}
+		}
+		sort.Slice(names, func(i, j int) bool {
+			if len(vars[names[i]]) != len(vars[names[j]]) {
+				return len(vars[names[i]]) > len(vars[names[j]])
+			}
+			return names[i] < names[j]
+		})
+
+		var pairs []string
+		for _, name := range names {
+			pairs = append(pairs, vars[name], sqlutil.Placeholder(name))
+		}
+		pairs = append(pairs, "${", "$${")
+		o.templateVars = strings.NewReplacer(pairs...)
+	}
+}
+
+// WithSourceTemplateVars expand the placeholders ${name} of the dump of WithTemplateVars
+// with the values of vars, eg: {"env": "staging"}. The values are escaped for the string
+// literal or the identifier holding the placeholder, placeholders of other names are kept.
+func WithSourceTemplateVars(vars map[string]string) SourceOption {
+	return func(o *sourceOption) {
+		o.templateVars = vars
+	}
+}
+
+// template replace the literals of WithTemplateVars in s by their placeholders
+func (o *dumpOption) template(s string) string {
+	if o.templateVars == nil {
+		return s
+	}
+	return o.templateVars.Replace(s)
+}
+
+// escapeTemplate write the ${ of the text that is not templated as $${, eg: the DDL, the
+// routine bodies and the JSON and ENUM values, so that Source does not expand it
+func (o *dumpOption) escapeTemplate(s string) string {
+	if o.templateVars == nil {
+		return s
+	}
+	return strings.Replace(s, "${", "$${", -1)
+}
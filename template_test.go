@@ -0,0 +1,66 @@
+package mysqldump
+
+import (
+	"bytes"
+	"context"
+	"database/sql/driver"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestTemplateRoundTrip(t *testing.T) {
+	table := mockTable{
+		name: "site",
+		ddl: "CREATE TABLE `site` (`url` varchar(255), `doc` json, `mode` enum('${env}','plain') " +
+			"COMMENT 'literal ${env}')",
+		columns: []*sqlmock.Column{
+			sqlmock.NewColumn("url").OfType("VARCHAR", []byte{}).Nullable(true),
+			sqlmock.NewColumn("doc").OfType("JSON", []byte{}).Nullable(true),
+			sqlmock.NewColumn("mode").OfType("ENUM", []byte{}).Nullable(true),
+		},
+		rows: [][]driver.Value{
+			{[]byte("https://prod.example.com"), []byte(`{"path": "${env}/${HOME}", "host": "prod"}`), []byte("${env}")},
+			{[]byte("it's ${env}"), []byte(`["$${env}"]`), []byte("plain")},
+		},
+	}
+	dump := mockDump(t, "shop", []mockTable{table}, WithTemplateVars(map[string]string{"env": "prod"}))
+
+	sourcer, err := NewSourcer("root:secret@tcp(127.0.0.1:1)/shop?timeout=1s",
+		WithSourceTemplateVars(map[string]string{"env": "staging", "HOME": "/root"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = sourcer.Close()
+	}()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	var buf bytes.Buffer
+	err = sourcer.Run(ctx, strings.NewReader(dump), WithDryRun(&buf))
+	if err != nil {
+		t.Fatalf("dry run: %v", err)
+	}
+
+	// only the literal of the variable is replaced, the ${ of the DDL and the values are kept
+	if !strings.Contains(buf.String(), "enum('${env}','plain') COMMENT 'literal ${env}'") {
+		t.Errorf("the DDL is not restored:\n%s", buf.String())
+	}
+	want := [][]string{
+		{"https://staging.example.com", `{"path": "${env}/${HOME}", "host": "prod"}`, "${env}"},
+		{"it's ${env}", `["$${env}"]`, "plain"},
+	}
+	rows := dumpedRows(t, buf.String())
+	if len(rows) != len(want) {
+		t.Fatalf("%d rows restored, want %d:\n%s", len(rows), len(want), buf.String())
+	}
+	for i, row := range rows {
+		for j, value := range row {
+			if value != want[i][j] {
+				t.Errorf("row %d column %d = %v, want %q", i, j, value, want[i][j])
+			}
+		}
+	}
+}
@@ -0,0 +1,114 @@
+// Package testsupport runs mysqldump round trips against a disposable MySQL server
+// started with testcontainers, for the tests of mysqldump and of its users:
+//
+//	func TestBackup(t *testing.T) {
+//		server := testsupport.StartMySQL(t, testsupport.DefaultImage)
+//		server.LoadSchema(t, strings.NewReader(schema))
+//		testsupport.AssertRoundTrip(t, server.DSN)
+//	}
+//
+// Docker must be available, the tests are skipped otherwise.
+package testsupport
+
+import (
+	"context"
+	"io"
+	"testing"
+
+	"github.com/testcontainers/testcontainers-go"
+	"github.com/testcontainers/testcontainers-go/modules/mysql"
+
+	"mysqldump"
+)
+
+// DefaultImage the image of StartMySQL when none is given
+const DefaultImage = "mysql:8.0"
+
+// MySQL a server running in a container, terminated at the end of the test
+type MySQL struct {
+	Container *mysql.MySQLContainer
+	// DSN of the database "test" as root
+	DSN string
+}
+
+// StartMySQL start a server of image, eg: "mysql:8.4" or "mariadb:11", and terminate it
+// when the test ends. The test is skipped when Docker is not available, it fails when the
+// server does not start.
+func StartMySQL(t testing.TB, image string) *MySQL {
+	t.Helper()
+	if image == "" {
+		image = DefaultImage
+	}
+	skipWithoutDocker(t)
+
+	ctx := context.Background()
+	container, err := mysql.Run(ctx, image,
+		mysql.WithDatabase("test"),
+		mysql.WithUsername("root"),
+		mysql.WithPassword("test"),
+	)
+	if container != nil {
+		t.Cleanup(func() {
+			_ = testcontainers.TerminateContainer(container)
+		})
+	}
+	if err != nil {
+		t.Fatalf("start %s: %v", image, err)
+	}
+
+	dsn, err := container.ConnectionString(ctx, "parseTime=true")
+	if err != nil {
+		t.Fatalf("dsn of %s: %v", image, err)
+	}
+	return &MySQL{
+		Container: container,
+		DSN:       dsn,
+	}
+}
+
+// skipWithoutDocker skip the test when no Docker daemon answers
+func skipWithoutDocker(t testing.TB) {
+	t.Helper()
+	// the provider panics on some hosts without Docker
+	defer func() {
+		if r := recover(); r != nil {
+			t.Skipf("docker is not available: %v", r)
+		}
+	}()
+
+	provider, err := testcontainers.ProviderDocker.GetProvider()
+	if err != nil {
+		t.Skipf("docker is not available: %v", err)
+	}
+	defer func() {
+		_ = provider.Close()
+	}()
+	err = provider.Health(context.Background())
+	if err != nil {
+		t.Skipf("docker is not available: %v", err)
+	}
+}
+
+// LoadSchema load the statements of schema into the database "test", eg: CREATE TABLE
+// and INSERT statements
+func (m *MySQL) LoadSchema(t testing.TB, schema io.Reader) {
+	t.Helper()
+	err := mysqldump.Source(m.DSN, schema)
+	if err != nil {
+		t.Fatalf("load schema: %v", err)
+	}
+}
+
+// AssertRoundTrip fail the test if the database of dsn does not survive a Dump and
+// Source unchanged, see mysqldump.RoundTripVerify
+func AssertRoundTrip(t testing.TB, dsn string, opts ...mysqldump.DumpOption) {
+	t.Helper()
+	report, err := mysqldump.RoundTripVerify(dsn, opts...)
+	if err != nil {
+		t.Fatalf("round trip: %v", err)
+	}
+	for _, table := range report.Differences() {
+		t.Errorf("round trip of %s: %d rows (checksum %d), restored %d rows (checksum %d)",
+			table.Table, table.Rows, table.Checksum, table.RestoredRows, table.RestoredChecksum)
+	}
+}
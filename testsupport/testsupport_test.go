@@ -0,0 +1,26 @@
+package testsupport_test
+
+import (
+	"strings"
+	"testing"
+
+	"mysqldump/testsupport"
+)
+
+const schema = "CREATE TABLE `order` (" +
+	"`id` BIGINT UNSIGNED PRIMARY KEY, " +
+	"`select` VARCHAR(64), " +
+	"`doc` JSON, " +
+	"`amount` DECIMAL(65,30), " +
+	"`raw` VARBINARY(16)" +
+	");\n" +
+	"INSERT INTO `order` VALUES " +
+	"(1, 'it''s C:\\\\new', '{\"quote\": \"\\\\\"\", \"nul\": \"\\\\u0000\"}', 12345678901234567890.123456789012345678901234567890, x'00ff')," +
+	"(18446744073709551615, NULL, NULL, NULL, x'')," +
+	"(3, '${env}', '[]', -0.000000000000000000000000000001, NULL);\n"
+
+func TestRoundTrip(t *testing.T) {
+	server := testsupport.StartMySQL(t, testsupport.DefaultImage)
+	server.LoadSchema(t, strings.NewReader(schema))
+	testsupport.AssertRoundTrip(t, server.DSN)
+}
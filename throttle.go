@@ -0,0 +1,87 @@
+package mysqldump
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"time"
+)
+
+// WithReplicaThrottle pace the restore so that the replica of replicaDSN keeps up: every
+// n statements the restore is committed and waits, up to timeout for each pause, until
+// the replica has executed the GTIDs of the target (WAIT_FOR_EXECUTED_GTID_SET). A
+// timeout is logged and the restore goes on, 0 waits without limit. n is 1000 by default. Requires gtid_mode=ON, and gives up the
+// single transaction of the restore, the savepoints of WithSavepoints start over after
+// each pause.
+func WithReplicaThrottle(replicaDSN string, n int, timeout time.Duration) SourceOption {
+	return func(o *sourceOption) {
+		o.replicaDSN = replicaDSN
+		o.replicaEvery = n
+		o.replicaTimeout = timeout
+	}
+}
+
+type replicaThrottle struct {
+	replica *sql.DB
+	every   int
+	timeout time.Duration
+	count   int
+}
+
+func newReplicaThrottle(o *sourceOption) (*replicaThrottle, error) {
+	replica, err := sql.Open("mysql", o.replicaDSN)
+	if err != nil {
+		return nil, err
+	}
+	every := o.replicaEvery
+	if every <= 0 {
+		every = 1000
+	}
+	return &replicaThrottle{
+		replica: replica,
+		every:   every,
+		timeout: o.replicaTimeout,
+	}, nil
+}
+
+// pace count a statement and every n statements commit and wait for the replica
+func (t *replicaThrottle) pace(db *dbWrapper, sp *savepointState) error {
+	t.count++
+	if t.count%t.every != 0 {
+		return nil
+	}
+
+	_, err := db.Exec("COMMIT;")
+	if err != nil {
+		return err
+	}
+	// COMMIT releases the savepoints
+	sp.active = false
+
+	var gtidExecuted string
+	err = db.DB.QueryRowContext(db.ctx, "SELECT @@GLOBAL.gtid_executed").Scan(&gtidExecuted)
+	if err != nil {
+		return fmt.Errorf("read gtid_executed: %w", err)
+	}
+
+	start := time.Now()
+	var timedOut int
+	if t.timeout > 0 {
+		err = t.replica.QueryRowContext(db.ctx, "SELECT WAIT_FOR_EXECUTED_GTID_SET(?, ?)", gtidExecuted, t.timeout.Seconds()).Scan(&timedOut)
+	} else {
+		err = t.replica.QueryRowContext(db.ctx, "SELECT WAIT_FOR_EXECUTED_GTID_SET(?)", gtidExecuted).Scan(&timedOut)
+	}
+	if err != nil {
+		return fmt.Errorf("wait for replica: %w", err)
+	}
+	if timedOut == 1 {
+		log.Printf("[warn] [throttle] replica still behind after %s\n", t.timeout)
+	} else if cost := time.Since(start); cost > time.Second {
+		log.Printf("[info] [throttle] waited %s for the replica\n", cost)
+	}
+	return nil
+}
+
+func (t *replicaThrottle) close() {
+	_ = t.replica.Close()
+}
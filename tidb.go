@@ -0,0 +1,21 @@
+package mysqldump
+
+// WithTiDBSnapshot read the data of TiDB as of snapshot, a TSO or a datetime like
+// "2024-01-02 15:04:05", by setting tidb_snapshot on the dump session. Every table is
+// then read from the same consistent historical version.
+//
+// The DDL of TiDB keeps its specific clauses in /*T![feature] ... */ comments
+// (CLUSTERED, AUTO_RANDOM, SHARD_ROW_ID_BITS), MySQL ignores them so the dump loads on
+// both. Loading explicit values into an AUTO_RANDOM column of TiDB requires
+// SET @@allow_auto_random_explicit_insert = true, WithProfile(ProfileTiDB) writes it
+// at the top of the dump.
+func WithTiDBSnapshot(snapshot string) DumpOption {
+	return func(option *dumpOption) {
+		option.tidbSnapshot = snapshot
+	}
+}
+
+func setTiDBSnapshot(db dbConn, snapshot string) error {
+	_, err := db.Exec("SET @@tidb_snapshot = ?", snapshot)
+	return err
+}
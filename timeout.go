@@ -0,0 +1,41 @@
+package mysqldump
+
+import (
+	"time"
+)
+
+// WithStatementTimeout abort the dump SELECTs running longer than d with the
+// MAX_EXECUTION_TIME of the session (max_statement_time on MariaDB), so that a stuck query
+// fails the dump instead of hanging it. The time includes sending the rows, d must exceed
+// the time it takes to read the largest table. A server without the variable records a
+// warning and the dump goes on without a timeout.
+func WithStatementTimeout(d time.Duration) DumpOption {
+	return func(option *dumpOption) {
+		option.statementTimeout = d
+	}
+}
+
+// WithSourceStatementTimeout fail the restore when a statement runs longer than d, the
+// connection is dropped and the server rolls back the uncommitted statements
+func WithSourceStatementTimeout(d time.Duration) SourceOption {
+	return func(o *sourceOption) {
+		o.statementTimeout = d
+	}
+}
+
+// setStatementTimeout the WithStatementTimeout limit of the session of db
+func setStatementTimeout(db dbConn, o *dumpOption) {
+	if o.statementTimeout <= 0 {
+		return
+	}
+
+	_, err := db.Exec("SET SESSION MAX_EXECUTION_TIME = ?", o.statementTimeout.Milliseconds())
+	if err == nil {
+		return
+	}
+	_, mariaErr := db.Exec("SET SESSION max_statement_time = ?", o.statementTimeout.Seconds())
+	if mariaErr == nil {
+		return
+	}
+	o.warnf("statement timeout not supported by the server: %v", err)
+}
@@ -0,0 +1,100 @@
+package mysqldump
+
+import (
+	"database/sql"
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+
+	"mysqldump/sqlutil"
+)
+
+// WithTimingReport measure the statements of each table and write a report to w at the
+// end of the restore, eg: to plan the recovery time objective from a rehearsal:
+//
+//	TABLE   STATEMENTS  ROWS     SIZE      DURATION  MB/S
+//	orders  1024        1048576  512.0 MB  1m4s      8.00
+//
+// The report is written even when the restore fails, and is also set into
+// SourceResult.Tables when WithSourceResult is given. w may be nil.
+func WithTimingReport(w io.Writer) SourceOption {
+	return func(o *sourceOption) {
+		o.timingReport = true
+		o.timingWriter = w
+	}
+}
+
+// TableTiming the statements of a table executed by Source, see WithTimingReport
+type TableTiming struct {
+	Table      string
+	Statements int
+	// Rows affected rows
+	Rows int64
+	// Bytes size of the statements
+	Bytes    int64
+	Duration time.Duration
+}
+
+// MBPerSecond the restore throughput of the table
+func (t TableTiming) MBPerSecond() float64 {
+	if t.Duration <= 0 {
+		return 0
+	}
+	return float64(t.Bytes) / (1 << 20) / t.Duration.Seconds()
+}
+
+type timingReport struct {
+	tables []TableTiming
+	index  map[string]int
+}
+
+func newTimingReport() *timingReport {
+	return &timingReport{index: make(map[string]int)}
+}
+
+// record a statement, statements without a table are left out
+func (r *timingReport) record(query string, cost time.Duration, res sql.Result) {
+	table := sqlutil.StatementTable(query)
+	if table == "" {
+		return
+	}
+
+	i, ok := r.index[table]
+	if !ok {
+		i = len(r.tables)
+		r.index[table] = i
+		r.tables = append(r.tables, TableTiming{Table: table})
+	}
+	t := &r.tables[i]
+	t.Statements++
+	t.Bytes += int64(len(query))
+	t.Duration += cost
+	if res != nil {
+		if rows, err := res.RowsAffected(); err == nil {
+			t.Rows += rows
+		}
+	}
+}
+
+// write the report of the tables followed by their total
+func (r *timingReport) write(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(tw, "TABLE\tSTATEMENTS\tROWS\tSIZE\tDURATION\tMB/S")
+
+	total := TableTiming{Table: "TOTAL"}
+	for _, t := range r.tables {
+		writeTableTiming(tw, t)
+		total.Statements += t.Statements
+		total.Rows += t.Rows
+		total.Bytes += t.Bytes
+		total.Duration += t.Duration
+	}
+	writeTableTiming(tw, total)
+	return tw.Flush()
+}
+
+func writeTableTiming(w io.Writer, t TableTiming) {
+	_, _ = fmt.Fprintf(w, "%s\t%d\t%d\t%.1f MB\t%s\t%.2f\n", t.Table, t.Statements, t.Rows,
+		float64(t.Bytes)/(1<<20), t.Duration.Round(time.Millisecond), t.MBPerSecond())
+}
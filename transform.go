@@ -0,0 +1,51 @@
+package mysqldump
+
+import (
+	"fmt"
+)
+
+// RowTransformer modify the rows while they are exported, eg: to mask, sample or filter them
+type RowTransformer interface {
+	// TransformRow returns the rows written in place of values: none drops the row, several
+	// split it. values holds the scanned values (nil, []byte, time.Time, ...), replacements
+	// must be of a type matching the column, eg: a string for a VARCHAR column. Every
+	// returned row must have one value per column.
+	TransformRow(table string, columns []string, values []interface{}) ([][]interface{}, error)
+}
+
+// RowTransformerFunc a func used as a RowTransformer
+type RowTransformerFunc func(table string, columns []string, values []interface{}) ([][]interface{}, error)
+
+func (f RowTransformerFunc) TransformRow(table string, columns []string, values []interface{}) ([][]interface{}, error) {
+	return f(table, columns, values)
+}
+
+// WithRowTransformer pass each exported row through transformers, in order, the rows
+// returned by one transformer are the input of the next one. Applies to Dump and DumpToSink.
+func WithRowTransformer(transformers ...RowTransformer) DumpOption {
+	return func(option *dumpOption) {
+		option.rowTransformers = append(option.rowTransformers, transformers...)
+	}
+}
+
+// transformRow apply the WithRowTransformer transformers to row
+func (o *dumpOption) transformRow(table string, columns []string, row []interface{}) ([][]interface{}, error) {
+	rows := [][]interface{}{row}
+	for _, transformer := range o.rowTransformers {
+		var transformed [][]interface{}
+		for _, r := range rows {
+			out, err := transformer.TransformRow(table, columns, r)
+			if err != nil {
+				return nil, fmt.Errorf("transform row of %s: %w", table, err)
+			}
+			for _, values := range out {
+				if len(values) != len(columns) {
+					return nil, fmt.Errorf("transform row of %s: %d values for %d columns", table, len(values), len(columns))
+				}
+			}
+			transformed = append(transformed, out...)
+		}
+		rows = transformed
+	}
+	return rows, nil
+}
@@ -0,0 +1,96 @@
+package mysqldump
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// TypeHandler format a non-NULL value of a column as an SQL literal
+type TypeHandler func(columnType *sql.ColumnType, value interface{}) (string, error)
+
+var (
+	typeHandlersMu sync.RWMutex
+	// typeHandlers by normalized type name, for the types Dump does not know itself
+	typeHandlers = map[string]TypeHandler{
+		// MySQL 9 vectors are loaded from their binary form, 4 bytes per float
+		"VECTOR": hexTypeHandler,
+		// the internal format of spatial values (SRID + WKB) is accepted as is
+		"GEOMETRY":           hexTypeHandler,
+		"POINT":              hexTypeHandler,
+		"LINESTRING":         hexTypeHandler,
+		"POLYGON":            hexTypeHandler,
+		"MULTIPOINT":         hexTypeHandler,
+		"MULTILINESTRING":    hexTypeHandler,
+		"MULTIPOLYGON":       hexTypeHandler,
+		"GEOMETRYCOLLECTION": hexTypeHandler,
+		"GEOMCOLLECTION":     hexTypeHandler,
+	}
+)
+
+// RegisterTypeHandler format the values of the columns whose DatabaseTypeName is name
+// (case and UNSIGNED ignored) with fn, for the types Dump does not support out of the box,
+// eg: a type of a newer server, or one that a driver version names differently.
+// It replaces a previous handler of name and is safe for concurrent use.
+func RegisterTypeHandler(name string, fn TypeHandler) {
+	typeHandlersMu.Lock()
+	defer typeHandlersMu.Unlock()
+	typeHandlers[normalizeTypeName(name)] = fn
+}
+
+// normalizeTypeName eg: "unsigned bigint" -> "BIGINT"
+func normalizeTypeName(name string) string {
+	name = strings.ToUpper(name)
+	name = strings.Replace(name, "UNSIGNED", "", -1)
+	return strings.Replace(name, " ", "", -1)
+}
+
+// typeHandlerValue format col with the handler registered for typ
+func typeHandlerValue(typ string, columnType *sql.ColumnType, col interface{}) (string, error) {
+	typeHandlersMu.RLock()
+	fn, ok := typeHandlers[typ]
+	typeHandlersMu.RUnlock()
+	if !ok {
+		return "", fmt.Errorf("unsupported type: %s, see RegisterTypeHandler", typ)
+	}
+	return fn(columnType, col)
+}
+
+func hexTypeHandler(_ *sql.ColumnType, value interface{}) (string, error) {
+	bs, ok := value.([]byte)
+	if !ok {
+		return "", fmt.Errorf("%T value of a binary column", value)
+	}
+	if len(bs) == 0 {
+		return "x''", nil
+	}
+	return fmt.Sprintf("0x%X", bs), nil
+}
+
+// ColumnSerializer format a non-NULL scanned value, eg: []byte, int64 or time.Time, as an
+// SQL literal
+type ColumnSerializer func(value interface{}) (string, error)
+
+var (
+	columnSerializersMu sync.RWMutex
+	columnSerializers   = make(map[string]ColumnSerializer)
+)
+
+// RegisterColumnSerializer format the values of the columns whose DatabaseTypeName is
+// typeName (case and UNSIGNED ignored) with fn instead of the built-in formatting, eg: to
+// work around a driver specific representation. Unlike RegisterTypeHandler it also
+// overrides the types Dump supports. It is safe for concurrent use.
+func RegisterColumnSerializer(typeName string, fn func(value interface{}) (string, error)) {
+	columnSerializersMu.Lock()
+	defer columnSerializersMu.Unlock()
+	columnSerializers[normalizeTypeName(typeName)] = fn
+}
+
+// columnSerializer the RegisterColumnSerializer serializer of a DatabaseTypeName
+func columnSerializer(databaseTypeName string) (ColumnSerializer, bool) {
+	columnSerializersMu.RLock()
+	defer columnSerializersMu.RUnlock()
+	fn, ok := columnSerializers[normalizeTypeName(databaseTypeName)]
+	return fn, ok
+}
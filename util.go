@@ -3,16 +3,26 @@ package mysqldump
 import (
 	"fmt"
 	"strings"
+
+	"mysqldump/sqlutil"
 )
 
+// GetDBNameFromDNS kept for compatibility, see sqlutil.ParseDBName.
 func GetDBNameFromDNS(dns string) (string, error) {
-	ss1 := strings.Split(dns, "/")
-	if len(ss1) == 2 {
-		ss2 := strings.Split(ss1[1], "?")
-		if len(ss2) == 2 {
-			return ss2[0], nil
+	return sqlutil.ParseDBName(dns)
+}
+
+// fileName the file name of a database or table name, encoded like the files of MySQL and
+// mydumper: ASCII letters, digits and _ are kept, any other character is @ and the hex code
+// point, eg: "a/b" is "a@002fb" and ".." is "@002e@002e"
+func fileName(name string) string {
+	var b strings.Builder
+	for _, r := range name {
+		if r == '_' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' {
+			b.WriteRune(r)
+			continue
 		}
+		b.WriteString(fmt.Sprintf("@%04x", r))
 	}
-
-	return "", fmt.Errorf("dns error: %s", dns)
+	return b.String()
 }
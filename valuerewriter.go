@@ -0,0 +1,57 @@
+package mysqldump
+
+import (
+	"encoding/json"
+	"log"
+
+	"mysqldump/sqlutil"
+)
+
+// WithValueRewriter rewrite the values of column of table in the INSERT and REPLACE
+// statements with fn, eg: to replace the host names of production with the ones of a
+// staging environment while restoring. fn gets the text of string and number values,
+// NULL, binary and expression values are left as is. Like WithColumnMap the columns of
+// the dump are read from its CREATE TABLE statement, table and column are the names in
+// the dump. Rewriters of the same column apply in order.
+func WithValueRewriter(table, column string, fn func(string) string) SourceOption {
+	return func(o *sourceOption) {
+		if o.valueRewriters == nil {
+			o.valueRewriters = make(map[string][]valueRewriter)
+		}
+		o.valueRewriters[table] = append(o.valueRewriters[table], valueRewriter{column: column, fn: fn})
+	}
+}
+
+type valueRewriter struct {
+	column string
+	fn     func(string) string
+}
+
+// rewriteValues apply the rewriters to the rows of values
+func rewriteValues(values *sqlutil.InsertValues, rewriters []valueRewriter, syntax sqlutil.Syntax) {
+	for _, rewriter := range rewriters {
+		idx := -1
+		for i, column := range values.Columns {
+			if column == rewriter.column {
+				idx = i
+			}
+		}
+		if idx == -1 {
+			continue
+		}
+
+		for _, row := range values.Rows {
+			value, err := syntax.ParseValue(row[idx])
+			if err != nil {
+				log.Printf("[warn] value of %s not rewritten: %v\n", rewriter.column, err)
+				continue
+			}
+			switch v := value.(type) {
+			case string:
+				row[idx] = syntax.QuoteString(rewriter.fn(v))
+			case json.Number:
+				row[idx] = syntax.QuoteString(rewriter.fn(string(v)))
+			}
+		}
+	}
+}
@@ -0,0 +1,42 @@
+package mysqldump
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRewriteValues(t *testing.T) {
+	o := &sourceOption{}
+	staging := func(s string) string {
+		return strings.Replace(s, "prod.example.com", "staging.example.com", -1)
+	}
+	WithValueRewriter("site", "url", staging)(o)
+	WithValueRewriter("site", "url", strings.ToUpper)(o)
+	WithValueRewriter("site", "a`b", func(s string) string { return s + "0" })(o)
+	WithValueRewriter("other", "url", strings.ToUpper)(o)
+
+	// want "" for the statements left as is
+	tests := []struct {
+		dml  string
+		want string
+	}{
+		{"CREATE TABLE `site` (\n  `id` int NOT NULL,\n  `url` text,\n  `a``b` int\n)", ""},
+		// the rewriters of a column apply in order, numbers are rewritten as strings
+		{"INSERT INTO `site` VALUES (1,'https://prod.example.com/it''s',5),(2,'http://prod.example.com',-1)",
+			"INSERT INTO `site` (`id`,`url`,`a``b`) VALUES (1,'HTTPS://STAGING.EXAMPLE.COM/IT\\'S','50'),(2,'HTTP://STAGING.EXAMPLE.COM','-10')"},
+		// NULL, binary and expression values are left as is
+		{"REPLACE INTO `site` (`url`,`id`) VALUES (NULL,1),(0x70726F64,2),(CONCAT('a','b'),3)",
+			"REPLACE INTO `site` (`url`,`id`) VALUES (NULL,1),(0x70726F64,2),(CONCAT('a','b'),3)"},
+		{"INSERT INTO `site` (`id`,`url`) VALUES (1,'a\\nb')", "INSERT INTO `site` (`id`,`url`) VALUES (1,'A\\nB')"},
+		{"INSERT INTO `siteb` VALUES (1,'prod.example.com')", ""},
+	}
+	for _, test := range tests {
+		want := test.want
+		if want == "" {
+			want = test.dml
+		}
+		if got := o.mapColumns(test.dml); got != want {
+			t.Errorf("mapColumns(%s) = %s, want %s", test.dml, got, want)
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package mysqldump
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+type vaultTransit struct {
+	addr   string
+	token  string
+	key    string
+	client *http.Client
+}
+
+// NewVaultTransit a KMS wrapping the data keys with the transit secrets engine of HashiCorp
+// Vault, eg: NewVaultTransit("https://vault:8200", token, "mysqldump"). The key version is
+// part of the wrapped key, rotate the key with transit/keys/<key>/rotate.
+func NewVaultTransit(addr, token, key string) KMS {
+	return &vaultTransit{
+		addr:   strings.TrimSuffix(addr, "/"),
+		token:  token,
+		key:    key,
+		client: &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+func (v *vaultTransit) call(op string, request map[string]string, field string) (string, error) {
+	body, err := json.Marshal(request)
+	if err != nil {
+		return "", err
+	}
+	req, err := http.NewRequest(http.MethodPost, fmt.Sprintf("%s/v1/transit/%s/%s", v.addr, op, v.key), bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("X-Vault-Token", v.token)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := v.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	var response struct {
+		Data   map[string]string `json:"data"`
+		Errors []string          `json:"errors"`
+	}
+	err = json.NewDecoder(resp.Body).Decode(&response)
+	if err != nil {
+		return "", fmt.Errorf("vault transit %s: %s", op, resp.Status)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("vault transit %s: %s %s", op, resp.Status, strings.Join(response.Errors, ", "))
+	}
+	return response.Data[field], nil
+}
+
+func (v *vaultTransit) WrapKey(dataKey []byte) ([]byte, string, error) {
+	ciphertext, err := v.call("encrypt", map[string]string{"plaintext": base64.StdEncoding.EncodeToString(dataKey)}, "ciphertext")
+	if err != nil {
+		return nil, "", err
+	}
+	return []byte(ciphertext), v.key, nil
+}
+
+func (v *vaultTransit) UnwrapKey(wrapped []byte, keyID string) ([]byte, error) {
+	if keyID != v.key {
+		return nil, fmt.Errorf("data key wrapped by vault key %s, not %s", keyID, v.key)
+	}
+	plaintext, err := v.call("decrypt", map[string]string{"ciphertext": string(wrapped)}, "plaintext")
+	if err != nil {
+		return nil, err
+	}
+	return base64.StdEncoding.DecodeString(plaintext)
+}
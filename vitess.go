@@ -0,0 +1,43 @@
+package mysqldump
+
+// WithVitessShards dump keyspace through VTGate one shard at a time, eg:
+// WithVitessShards("commerce", "-80", "80-"). Each shard is read with USE `keyspace:shard`
+// so that every query hits a single shard, there is no consistent snapshot across shards.
+// The schema is read from the first shard and the output uses the keyspace name only,
+// so that the dump can be loaded through VTGate or into a plain MySQL server.
+func WithVitessShards(keyspace string, shards ...string) DumpOption {
+	return func(option *dumpOption) {
+		option.vitessKeyspace = keyspace
+		option.vitessShards = shards
+		option.profile = ProfilePlanetScale
+	}
+}
+
+func dumpVitessKeyspace(db dbConn, o *dumpOption, buf *SafeWriter, views *[]viewDef) error {
+	// OLAP workload streams the results instead of enforcing the row limit of OLTP
+	_, err := db.Exec("SET workload = 'olap'")
+	if err != nil {
+		return err
+	}
+
+	shards := o.vitessShards
+	if len(shards) == 0 {
+		// VTGate routes the queries to all shards of the keyspace
+		shards = []string{""}
+	}
+
+	for i, shard := range shards {
+		phase := phaseShardData
+		if i == 0 {
+			phase = phaseAll
+		}
+
+		o.vitessShard = shard
+		err = dumpDB(db, o.vitessKeyspace, o, buf, phase, views)
+		if err != nil {
+			return err
+		}
+	}
+	o.vitessShard = ""
+	return nil
+}
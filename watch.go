@@ -0,0 +1,76 @@
+package mysqldump
+
+import (
+	"context"
+	"log"
+	"sort"
+	"time"
+)
+
+// SchemaDiff the difference between two fingerprint snapshots, tables are "db.table"
+type SchemaDiff struct {
+	Added   []string
+	Removed []string
+	Changed []string
+}
+
+func (d SchemaDiff) Empty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Changed) == 0
+}
+
+// DiffFingerprints compare two results of SchemaFingerprint
+func DiffFingerprints(old, new map[string]string) SchemaDiff {
+	var diff SchemaDiff
+	for table, fingerprint := range new {
+		oldFingerprint, ok := old[table]
+		if !ok {
+			diff.Added = append(diff.Added, table)
+		} else if oldFingerprint != fingerprint {
+			diff.Changed = append(diff.Changed, table)
+		}
+	}
+	for table := range old {
+		if _, ok := new[table]; !ok {
+			diff.Removed = append(diff.Removed, table)
+		}
+	}
+
+	sort.Strings(diff.Added)
+	sort.Strings(diff.Removed)
+	sort.Strings(diff.Changed)
+	return diff
+}
+
+// Watch fingerprint the schemas every interval and call onChange when they
+// differ from the previous check. It blocks until ctx is done, opts select the
+// watched databases and tables like for SchemaFingerprint.
+func Watch(ctx context.Context, dns string, interval time.Duration, onChange func(SchemaDiff), opts ...DumpOption) error {
+	snapshot, err := SchemaFingerprint(dns, opts...)
+	if err != nil {
+		return err
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+
+		current, err := SchemaFingerprint(dns, opts...)
+		if err != nil {
+			// keep watching, the server may be temporarily unavailable
+			log.Printf("[warn] [watch] %v \n", err)
+			continue
+		}
+
+		diff := DiffFingerprints(snapshot, current)
+		snapshot = current
+		if !diff.Empty() {
+			onChange(diff)
+		}
+	}
+}
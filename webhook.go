@@ -0,0 +1,78 @@
+package mysqldump
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// webhookTimeout the timeout of a webhook call, a failed call is a warning
+const webhookTimeout = 10 * time.Second
+
+const (
+	EventTableStarted  = "table_started"
+	EventTableFinished = "table_finished"
+	EventDumpFinished  = "dump_finished"
+)
+
+// WebhookEvent the JSON body POSTed by WithWebhook
+type WebhookEvent struct {
+	Event string    `json:"event"`
+	Time  time.Time `json:"time"`
+	DB    string    `json:"db,omitempty"`
+	Table string    `json:"table,omitempty"`
+	// Rows the rows written for the table, table_finished only
+	Rows       int   `json:"rows"`
+	DurationMs int64 `json:"duration_ms"`
+	// Tables the finished tables, dump_finished only
+	Tables int    `json:"tables"`
+	Error  string `json:"error,omitempty"`
+}
+
+// WithWebhook POST a WebhookEvent to url when the dump of a table starts and finishes
+// and when the dump finishes, eg: to track the backup coverage of each table. The calls
+// are synchronous, a failed call is a warning and the dump goes on.
+func WithWebhook(url string) DumpOption {
+	return func(option *dumpOption) {
+		option.webhook = &webhook{
+			url:    url,
+			client: &http.Client{Timeout: webhookTimeout},
+		}
+	}
+}
+
+type webhook struct {
+	url    string
+	client *http.Client
+	tables int
+}
+
+func (o *dumpOption) notify(event WebhookEvent) {
+	if o.webhook == nil {
+		return
+	}
+	event.Time = time.Now().UTC()
+	if event.Event == EventTableFinished {
+		o.webhook.tables++
+	}
+	if event.Event == EventDumpFinished {
+		event.Tables = o.webhook.tables
+	}
+
+	body, err := json.Marshal(event)
+	if err != nil {
+		o.warnf("webhook %s: %v", event.Event, err)
+		return
+	}
+	resp, err := o.webhook.client.Post(o.webhook.url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		o.warnf("webhook %s: %v", event.Event, err)
+		return
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		o.warnf("webhook %s: %v", event.Event, fmt.Errorf("status %s", resp.Status))
+	}
+}
@@ -0,0 +1,61 @@
+package mysqldump
+
+import (
+	"time"
+)
+
+// writeQueueSize the statements read ahead of the writer, a slower writer blocks the reads
+const writeQueueSize = 64
+
+// writeQueue write the statements of a table to buf in a goroutine of its own, so that the
+// rows are read while the previous ones are written
+type writeQueue struct {
+	ch     chan string
+	done   chan struct{}
+	closed bool
+
+	stalls    int
+	stallTime time.Duration
+}
+
+func newWriteQueue(buf *SafeWriter) *writeQueue {
+	q := &writeQueue{
+		ch:   make(chan string, writeQueueSize),
+		done: make(chan struct{}),
+	}
+	go func() {
+		defer close(q.done)
+		for s := range q.ch {
+			_, _ = buf.WriteString(s)
+		}
+	}()
+	return q
+}
+
+// send queue s, block while the queue is full
+func (q *writeQueue) send(s string) {
+	select {
+	case q.ch <- s:
+	default:
+		start := time.Now()
+		q.ch <- s
+		q.stalls++
+		q.stallTime += time.Since(start)
+	}
+}
+
+// close wait for the queued statements to be written and record the stalls into the
+// result, buf may be written again once it returns
+func (q *writeQueue) close(o *dumpOption) {
+	if q.closed {
+		return
+	}
+	q.closed = true
+	close(q.ch)
+	<-q.done
+
+	if o.result != nil {
+		o.result.WriterStalls += q.stalls
+		o.result.WriterStallTime += q.stallTime
+	}
+}
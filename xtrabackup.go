@@ -0,0 +1,103 @@
+package mysqldump
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// BinlogPosition binary log coordinates of a backup
+type BinlogPosition struct {
+	File     string
+	Position uint64
+	// GTIDSet executed GTID set, empty when GTIDs are disabled
+	GTIDSet string
+}
+
+// ReadXtrabackupBinlogInfo read the binlog coordinates recorded by Percona XtraBackup
+// in dir, from xtrabackup_binlog_info ("<file>\t<position>[\t<gtid set>]").
+func ReadXtrabackupBinlogInfo(dir string) (BinlogPosition, error) {
+	path := filepath.Join(dir, "xtrabackup_binlog_info")
+	bs, err := os.ReadFile(path)
+	if err != nil {
+		return BinlogPosition{}, err
+	}
+
+	fields := strings.Fields(strings.TrimSpace(string(bs)))
+	if len(fields) < 2 {
+		return BinlogPosition{}, fmt.Errorf("invalid %s: %q", path, string(bs))
+	}
+
+	position, err := strconv.ParseUint(fields[1], 10, 64)
+	if err != nil {
+		return BinlogPosition{}, fmt.Errorf("invalid %s: %v", path, err)
+	}
+
+	return BinlogPosition{
+		File:     fields[0],
+		Position: position,
+		// a long GTID set may be wrapped over several lines
+		GTIDSet: strings.Join(fields[2:], ""),
+	}, nil
+}
+
+// ReadMydumperMetadata read the binlog coordinates from the metadata file written by
+// mydumper in dir, the SHOW MASTER STATUS section is used.
+func ReadMydumperMetadata(dir string) (BinlogPosition, error) {
+	path := filepath.Join(dir, "metadata")
+	file, err := os.Open(path)
+	if err != nil {
+		return BinlogPosition{}, err
+	}
+	defer func() {
+		_ = file.Close()
+	}()
+
+	var pos BinlogPosition
+	var inMaster bool
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case strings.HasPrefix(line, "SHOW MASTER STATUS"), line == "[source]", line == "[master]":
+			inMaster = true
+			continue
+		case strings.HasPrefix(line, "SHOW SLAVE STATUS"), strings.HasPrefix(line, "["), strings.HasPrefix(line, "Finished"):
+			inMaster = false
+			continue
+		}
+		if !inMaster {
+			continue
+		}
+
+		// "Log: mysql-bin.000001" (old format) or "File = mysql-bin.000001" (ini format)
+		sep := strings.IndexAny(line, ":=")
+		if sep == -1 {
+			continue
+		}
+		key := strings.TrimSpace(line[:sep])
+		value := strings.Trim(strings.TrimSpace(line[sep+1:]), `"`)
+		switch strings.ToLower(key) {
+		case "log", "file":
+			pos.File = value
+		case "pos", "position":
+			pos.Position, err = strconv.ParseUint(value, 10, 64)
+			if err != nil {
+				return BinlogPosition{}, fmt.Errorf("invalid %s: %v", path, err)
+			}
+		case "gtid", "executed_gtid_set":
+			pos.GTIDSet = value
+		}
+	}
+	if err = scanner.Err(); err != nil {
+		return BinlogPosition{}, err
+	}
+
+	if pos.File == "" {
+		return BinlogPosition{}, fmt.Errorf("no binlog position in %s", path)
+	}
+	return pos, nil
+}
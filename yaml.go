@@ -0,0 +1,105 @@
+package mysqldump
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+)
+
+var yamlPlainKeyRe = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// DumpYAMLFixtures write the rows of the selected tables into dir in the go-testfixtures layout,
+// one <table>.yml file per table. When several databases are exported each one gets its own
+// sub directory. WithDBs, WithAllDatabases, WithTables, WithAllTables and WithWhere select the rows.
+func DumpYAMLFixtures(dns string, dir string, opts ...DumpOption) error {
+	var o dumpOption
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	multiDB := o.isAllDB || len(o.dbs) > 1
+
+	err := forEachTable(dns, &o, func(db dbConn, dbName, table string) error {
+		tableDir := dir
+		if multiDB {
+			tableDir = filepath.Join(dir, dbName)
+		}
+		err := os.MkdirAll(tableDir, 0755)
+		if err != nil {
+			return err
+		}
+
+		file, err := os.Create(filepath.Join(tableDir, table+".yml"))
+		if err != nil {
+			return err
+		}
+		defer func() {
+			_ = file.Close()
+		}()
+
+		buf := NewSafeWriterWithSize(file, BufferSize)
+		empty := true
+		err = scanRows(db, table, &o, func(columnTypes []*sql.ColumnType, row []interface{}) error {
+			empty = false
+			for i, col := range row {
+				prefix := "  "
+				if i == 0 {
+					prefix = "- "
+				}
+				_, _ = buf.WriteString(fmt.Sprintf("%s%s: %s\n", prefix, yamlKey(columnTypes[i].Name()), yamlValue(columnTypes[i], col)))
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		if empty {
+			_, _ = buf.WriteString("[]\n")
+		}
+
+		err = buf.Flush()
+		if err != nil {
+			return err
+		}
+		return file.Close()
+	})
+	if err != nil {
+		log.Printf("[error] %v \n", err)
+		return err
+	}
+	return nil
+}
+
+func yamlKey(name string) string {
+	if yamlPlainKeyRe.MatchString(name) {
+		return name
+	}
+	return strconv.Quote(name)
+}
+
+// yamlValue Go quoted strings are valid YAML double-quoted scalars
+func yamlValue(columnType *sql.ColumnType, col interface{}) string {
+	if col == nil {
+		return "null"
+	}
+
+	switch goType(columnType) {
+	case "int64", "uint64", "float64":
+		return rawString(col)
+	case "bool":
+		s := rawString(col)
+		return strconv.FormatBool(s == "1" || s == "true")
+	case "[]byte":
+		if bs, ok := col.([]byte); ok && len(bs) == 0 {
+			return `""`
+		}
+		// go-testfixtures passes RAW= values to the database untouched
+		return strconv.Quote(fmt.Sprintf("RAW=0x%X", col))
+	default:
+		return strconv.Quote(rawString(col))
+	}
+}